@@ -1,20 +1,39 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"referral-system/internal/auth"
 	"referral-system/internal/config"
 	"referral-system/internal/controllers"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/infrastructure/eventbus"
+	"referral-system/internal/infrastructure/jwtkeys"
 	"referral-system/internal/infrastructure/logger/handlers/slogpretty"
 	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/infrastructure/mailer"
+	"referral-system/internal/infrastructure/oauth"
+	"referral-system/internal/infrastructure/passwords"
+	"referral-system/internal/infrastructure/qr"
+	"referral-system/internal/infrastructure/ratelimit"
+	"referral-system/internal/infrastructure/scheduler"
+	"referral-system/internal/middlewares"
+	"referral-system/internal/repositories"
 	"referral-system/internal/repositories/postgres"
 	"referral-system/internal/routes"
 	"referral-system/internal/services"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
@@ -42,6 +61,10 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+
+// @securityDefinitions.apikey XAPIKey
+// @in header
+// @name X-API-Key
 func main() {
 	// считываем флаги
 	configPath := flag.String("config", "config.yaml", "Path to the configuration file")
@@ -77,18 +100,95 @@ func main() {
 	userRepo := postgres.NewPostgresUserRepository(dbConn)
 	referralCodeRepo := postgres.NewPostgresReferralCodeRepository(dbConn)
 	referralRepo := postgres.NewPostgresReferralRepository(dbConn)
+	loginAttemptRepo := postgres.NewPostgresLoginAttemptRepository(dbConn)
+	recoveryCodeRepo := postgres.NewPostgresRecoveryCodeRepository(dbConn)
+	mfaChallengeRepo := postgres.NewPostgresMFAChallengeRepository(dbConn)
+	identityRepo := postgres.NewPostgresUserIdentityRepository(dbConn)
+	oauthStateRepo := postgres.NewPostgresOAuthStateRepository(dbConn)
+	apiKeyRepo := postgres.NewPostgresAPIKeyRepository(dbConn)
+	campaignRepo := postgres.NewPostgresCampaignRepository(dbConn)
+	codeBatchRepo := postgres.NewPostgresCodeBatchRepository(dbConn)
+	clickRepo := postgres.NewPostgresReferralClickRepository(dbConn)
+	leaderboardRepo := postgres.NewPostgresLeaderboardRepository(dbConn)
+	tierRepo := postgres.NewPostgresTierRepository(dbConn)
+	webhookRepo := postgres.NewPostgresWebhookRepository(dbConn)
+	outboxRepo := postgres.NewPostgresOutboxRepository(dbConn)
+	jobLockRepo := postgres.NewPostgresJobLockRepository(dbConn)
+	notificationRepo := postgres.NewPostgresNotificationRepository(dbConn)
+	rateLimitRepo := mustLoadRateLimitStore(cfg.RateLimits, dbConn)
+	transactor := postgres.NewPostgresTransactor(dbConn)
+
+	// загружаем парольную политику и список утекших паролей
+	passwordPolicy, err := passwords.NewPolicy(cfg.PasswordPolicy)
+	if err != nil {
+		panic(fmt.Errorf("unable to load password policy: %v", err))
+	}
+
+	// загружаем ключи подписи JWT
+	jwtKeys := mustLoadJWTKeys(cfg.JWT, logger)
+	tokens := auth.NewTokens(jwtKeys, cfg.JWT)
 
 	// создаем копии сервисов
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	referralService := services.NewReferralService(referralCodeRepo, userRepo, referralRepo)
+	mfaService := services.NewMFAService(userRepo, recoveryCodeRepo, cfg.MFA.Issuer)
+	authService := services.NewAuthService(userRepo, loginAttemptRepo, tokens, passwordPolicy, cfg.LoginProtection, mfaService, mfaChallengeRepo)
+	tierService := services.NewTierService(mustLoadTiers(cfg.Tiers), referralRepo, tierRepo, transactor)
+	webhookService := services.NewWebhookService(webhookRepo, cfg.Webhooks)
+	logMailer := mailer.NewLogMailer(logger)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, mustLoadEmailSender(cfg.SMTP, logMailer, logger),
+		mustLoadEmailTemplates(cfg.Notifications), mustLoadNotifications(cfg), mustLoadSecret(cfg.Notifications.UnsubscribeSecret, "unsubscribe", logger))
+
+	// события из outbox доставляются и обработчикам внутри сервиса
+	bus := eventbus.NewMemoryBus()
+	bus.Subscribe(entities.EventReferralCreated, notificationService.HandleEvent)
+	bus.Subscribe(entities.EventReferralRewarded, notificationService.HandleEvent)
+	outboxRelay := services.NewOutboxRelay(outboxRepo, mustLoadEventPublishers(cfg.Outbox, webhookService, bus), cfg.Outbox)
+	referralService := services.NewReferralService(referralCodeRepo, userRepo, referralRepo, campaignRepo, authService, tierService,
+		transactor, services.NewOutboxPublisher(outboxRepo))
+	oauthService := services.NewOAuthService(mustLoadOAuthProviders(cfg.OAuth), userRepo, identityRepo, oauthStateRepo, referralService, authService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo)
+	campaignService := services.NewCampaignService(campaignRepo)
+	codeBatchService := services.NewCodeBatchService(codeBatchRepo, campaignRepo, userRepo)
+	clickService := services.NewClickService(referralCodeRepo, clickRepo, referralService, mustLoadSecret(cfg.ReferralLinks.IPHashSecret, "ip hash", logger))
+	referralStatsService := services.NewReferralStatsService(referralRepo)
+	leaderboardService := services.NewLeaderboardService(leaderboardRepo, userRepo)
+	userService := services.NewUserService(userRepo, referralCodeRepo, referralRepo, identityRepo, apiKeyRepo, clickRepo, tierRepo,
+		notificationRepo, notificationService)
+	codeExpiryService := services.NewCodeExpiryService(referralCodeRepo, notificationService, cfg.CodeExpiry)
 
 	// создаем контроллеры
-	authController := controllers.NewAuthController(authService, logger)
+	authController := controllers.NewAuthController(authService, referralService, logger)
 	referralController := controllers.NewReferralController(referralService, logger)
+	userController := controllers.NewUserController(userService, authService, logger)
+	mfaController := controllers.NewMFAController(mfaService, logger)
+	adminController := controllers.NewAdminController(authService, logger)
+	oauthController := controllers.NewOAuthController(oauthService, logger)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService, logger)
+	campaignController := controllers.NewCampaignController(campaignService, logger)
+	codeBatchController := controllers.NewCodeBatchController(codeBatchService, logger)
+	landingURL, attributionWindow := mustLoadReferralLinks(cfg.ReferralLinks)
+	referralLinkController := controllers.NewReferralLinkController(clickService, landingURL, attributionWindow, logger)
+	qrRenderer, err := qr.NewRenderer(cfg.QR)
+	if err != nil {
+		panic(fmt.Errorf("unable to load qr settings: %v", err))
+	}
+	qrController := controllers.NewQRController(referralService, qrRenderer, strings.TrimSuffix(cfg.ReferralLinks.BaseURL, "/"), logger)
+	referralStatsController := controllers.NewReferralStatsController(referralStatsService, logger)
+	leaderboardController := controllers.NewLeaderboardController(leaderboardService, logger)
+	tierController := controllers.NewTierController(tierService, logger)
+	webhookController := controllers.NewWebhookController(webhookService, logger)
+	notificationController := controllers.NewNotificationController(notificationService, logger)
 
 	// создаем копию роутера
 	router := gin.Default()
-	routes.RegisterRoutes(router, authController, referralController, cfg.JWTSecret)
+	// gin по умолчанию доверяет X-Forwarded-For от любого адреса, и блокировку входа по IP
+	// можно было бы обойти подменой заголовка
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(fmt.Errorf("invalid trusted proxies: %v", err))
+	}
+	rateLimiter := middlewares.NewRateLimiter(rateLimitRepo, mustLoadRateLimits(cfg.RateLimits), logger)
+	routes.RegisterRoutes(router, authController, referralController, referralLinkController, qrController, referralStatsController, leaderboardController, tierController, userController, mfaController, oauthController, apiKeyController, campaignController,
+		codeBatchController, webhookController, notificationController, adminController, tokens, authService, apiKeyService,
+		rateLimiter, jwtKeys, time.Duration(cfg.Database.QueryTimeout)*time.Second)
 
 	// подключаем Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -101,6 +201,24 @@ func main() {
 		IdleTimeout:  time.Duration(cfg.Timeouts.IdleTimeout) * time.Second,
 	}
 
+	// фоновые задачи останавливаются вместе с сервером
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go drainQueue(background, webhookService.ProcessDueDeliveries, time.Duration(cfg.Webhooks.PollInterval)*time.Second, "webhooks", logger)
+	go drainQueue(background, outboxRelay.RelayPending, time.Duration(cfg.Outbox.PollInterval)*time.Second, "outbox", logger)
+
+	// задачи по расписанию выполняются одним экземпляром сервиса
+	jobScheduler := mustLoadScheduler(cfg.Jobs, jobLockRepo, logger, map[string]func(ctx context.Context) (int, error){
+		"archive_expired_codes": codeExpiryService.ArchiveExpiredCodes,
+		"notify_expiring_codes": codeExpiryService.NotifyExpiringCodes,
+		"purge_rate_limits":     rateLimitRepo.PurgeRateLimits,
+		"purge_mfa_challenges":  mfaChallengeRepo.PurgeMFAChallenges,
+		"refresh_leaderboard": func(ctx context.Context) (int, error) {
+			return 0, leaderboardService.RefreshLeaderboard(ctx)
+		},
+	})
+	go jobScheduler.Run(background)
+
 	// релизуем gracefull отключение сервера
 	errChan := make(chan error, 1)
 
@@ -141,3 +259,311 @@ func setupPrettyLog() *slog.Logger {
 
 	return slog.New(handler)
 }
+
+// mustLoadJWTKeys загружает ключи подписи из конфига. Если ключи не заданы, создается
+// временный ключ: выданные токены перестанут действовать после перезапуска
+func mustLoadJWTKeys(cfg config.JWTConfig, logger *slog.Logger) *jwtkeys.KeySet {
+	if len(cfg.Keys) == 0 {
+		logger.Warn("no jwt keys configured, using an ephemeral Ed25519 key")
+
+		keys, err := jwtkeys.Generate("ephemeral")
+		if err != nil {
+			panic(fmt.Errorf("unable to generate jwt key: %v", err))
+		}
+		return keys
+	}
+
+	keys, err := jwtkeys.Load(cfg)
+	if err != nil {
+		panic(fmt.Errorf("unable to load jwt keys: %v", err))
+	}
+	if _, err := keys.SigningKeyAt(time.Now()); err != nil {
+		panic(fmt.Errorf("unable to load jwt keys: %v", err))
+	}
+	return keys
+}
+
+// mustLoadOAuthProviders создает клиентов внешних провайдеров входа. Для OIDC провайдеров
+// при старте загружается discovery документ
+func mustLoadOAuthProviders(cfg config.OAuthConfig) map[string]services.OAuthProvider {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	providers := make(map[string]services.OAuthProvider, len(cfg.Providers))
+	for name, providerCfg := range cfg.Providers {
+		switch providerCfg.Type {
+		case "", "oidc":
+			provider, err := oauth.NewOIDCProvider(ctx, providerCfg)
+			if err != nil {
+				panic(fmt.Errorf("unable to load oauth provider %q: %v", name, err))
+			}
+			providers[name] = provider
+		case "github":
+			providers[name] = oauth.NewGitHubProvider(providerCfg)
+		default:
+			panic(fmt.Errorf("oauth provider %q: unknown type %q", name, providerCfg.Type))
+		}
+	}
+
+	return providers
+}
+
+// mustLoadReferralLinks возвращает посадочную страницу реферальных ссылок и срок хранения кода в cookie
+func mustLoadReferralLinks(cfg config.ReferralLinks) (*url.URL, time.Duration) {
+	landing := cfg.LandingURL
+	if landing == "" {
+		landing = "/"
+	}
+	landingURL, err := url.Parse(landing)
+	if err != nil {
+		panic(fmt.Errorf("invalid referral landing url: %v", err))
+	}
+
+	window := time.Duration(cfg.AttributionWindow) * time.Second
+	if window <= 0 {
+		window = 30 * 24 * time.Hour
+	}
+
+	return landingURL, window
+}
+
+// defaultTiers - уровни рефереров, если они не заданы в конфиге
+var defaultTiers = []entities.Tier{
+	{Name: "bronze", Threshold: 5, Multiplier: 1.1},
+	{Name: "silver", Threshold: 20, Multiplier: 1.25},
+	{Name: "gold", Threshold: 50, Multiplier: 1.5},
+}
+
+// mustLoadTiers проверяет уровни из конфига и сортирует их по порогу
+func mustLoadTiers(cfg []config.Tier) []entities.Tier {
+	if len(cfg) == 0 {
+		return defaultTiers
+	}
+
+	tiers := make([]entities.Tier, 0, len(cfg))
+	for _, tier := range cfg {
+		if tier.Name == "" || len(tier.Name) > 32 || tier.Threshold <= 0 || tier.Multiplier <= 0 {
+			panic(fmt.Errorf("invalid tier %q: name, positive threshold and multiplier are required", tier.Name))
+		}
+		tiers = append(tiers, entities.Tier{Name: tier.Name, Threshold: tier.Threshold, Multiplier: tier.Multiplier})
+	}
+
+	slices.SortFunc(tiers, func(a, b entities.Tier) int { return a.Threshold - b.Threshold })
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].Threshold == tiers[i-1].Threshold {
+			panic(fmt.Errorf("tiers %q and %q have the same threshold", tiers[i-1].Name, tiers[i].Name))
+		}
+	}
+
+	return tiers
+}
+
+// drainQueue периодически обрабатывает очередь. Пока drain находит работу, очередь разбирается без паузы
+func drainQueue(ctx context.Context, drain func(ctx context.Context) (int, error), interval time.Duration, queue string, logger *slog.Logger) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := drain(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("failed to process queue", slog.String("queue", queue), sl.Err(err))
+			}
+			if err != nil || processed == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// mustLoadEventPublishers создает издателей, в которые публикуются события из outbox. Кроме шины
+// внутри сервиса, по умолчанию события отправляются только подписчикам вебхуков. Имена издателей
+// сохраняются в outbox, поэтому их нельзя переименовывать
+func mustLoadEventPublishers(cfg config.Outbox, webhookService services.WebhookService, bus *eventbus.MemoryBus) map[string]services.EventPublisher {
+	names := cfg.Publishers
+	if len(names) == 0 {
+		names = []string{"webhook"}
+	}
+
+	publishers := map[string]services.EventPublisher{"bus": bus}
+	for _, name := range names {
+		switch name {
+		case "webhook":
+			publishers[name] = webhookService
+		case "nats":
+			publisher, err := eventbus.NewNATSPublisher(cfg.NATS)
+			if err != nil {
+				panic(fmt.Errorf("unable to load nats publisher: %v", err))
+			}
+			publishers[name] = publisher
+		default:
+			panic(fmt.Errorf("unknown event publisher %q", name))
+		}
+	}
+
+	return publishers
+}
+
+// defaultJobSchedules - расписания встроенных задач, если они не заданы в конфиге
+var defaultJobSchedules = map[string]string{
+	"archive_expired_codes": "0 3 * * *",
+	"notify_expiring_codes": "0 * * * *",
+	"purge_rate_limits":     "*/10 * * * *",
+	"purge_mfa_challenges":  "0 * * * *",
+	"refresh_leaderboard":   "*/5 * * * *",
+}
+
+// mustLoadScheduler добавляет в планировщик встроенные задачи с расписанием из конфига.
+// Задача возвращает число обработанных записей, оно пишется в лог
+func mustLoadScheduler(cfg map[string]config.Job, locker scheduler.Locker, logger *slog.Logger,
+	jobs map[string]func(ctx context.Context) (int, error)) *scheduler.Scheduler {
+	for name := range cfg {
+		if _, ok := jobs[name]; !ok {
+			panic(fmt.Errorf("unknown job %q", name))
+		}
+	}
+
+	s := scheduler.New(locker, logger)
+	for _, name := range slices.Sorted(maps.Keys(jobs)) {
+		jobCfg := cfg[name]
+		if jobCfg.Disabled {
+			continue
+		}
+
+		spec := jobCfg.Schedule
+		if spec == "" {
+			spec = defaultJobSchedules[name]
+		}
+
+		run := jobs[name]
+		err := s.Add(name, spec, func(ctx context.Context) error {
+			processed, err := run(ctx)
+			logger.Info("job processed records", slog.String("job", name), slog.Int("processed", processed))
+			return err
+		})
+		if err != nil {
+			panic(fmt.Errorf("unable to schedule jobs: %v", err))
+		}
+	}
+
+	return s
+}
+
+// mustLoadSecret возвращает секрет HMAC из конфига. Без него создается временный: подписи и хеши,
+// сделанные до перезапуска, перестанут совпадать
+func mustLoadSecret(secret, name string, logger *slog.Logger) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+
+	logger.Warn("no " + name + " secret configured, using an ephemeral one")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Errorf("unable to generate %s secret: %v", name, err))
+	}
+	return key
+}
+
+// defaultRateLimits - лимиты групп маршрутов, если они не заданы в конфиге
+var defaultRateLimits = map[string]config.RateLimit{
+	"auth":  {Requests: 10, Period: 60, Key: entities.RateLimitKeyIP},
+	"codes": {Requests: 60, Period: 60, Key: entities.RateLimitKeyIP},
+	"api":   {Requests: 300, Period: 60, Key: entities.RateLimitKeyAPIKey},
+}
+
+// mustLoadRateLimits дополняет лимиты групп из конфига значениями по умолчанию. Отключенные группы
+// не попадают в результат и не ограничиваются
+func mustLoadRateLimits(cfg config.RateLimits) map[string]entities.RateLimit {
+	for name := range cfg.Groups {
+		if _, ok := defaultRateLimits[name]; !ok {
+			panic(fmt.Errorf("unknown rate limit group %q", name))
+		}
+	}
+
+	limits := make(map[string]entities.RateLimit, len(defaultRateLimits))
+	for name, defaults := range defaultRateLimits {
+		groupCfg := cfg.Groups[name]
+		if groupCfg.Disabled {
+			continue
+		}
+
+		limit := entities.RateLimit{
+			Requests: cmp.Or(groupCfg.Requests, defaults.Requests),
+			Period:   time.Duration(cmp.Or(groupCfg.Period, defaults.Period)) * time.Second,
+			Key:      cmp.Or(groupCfg.Key, defaults.Key),
+		}
+		limit.Burst = cmp.Or(groupCfg.Burst, limit.Requests)
+
+		if limit.Requests <= 0 || limit.Period <= 0 || limit.Burst <= 0 {
+			panic(fmt.Errorf("invalid rate limit %q: positive requests, period and burst are required", name))
+		}
+		if !slices.Contains([]string{entities.RateLimitKeyIP, entities.RateLimitKeyUser, entities.RateLimitKeyAPIKey}, limit.Key) {
+			panic(fmt.Errorf("invalid rate limit %q: unknown key %q", name, limit.Key))
+		}
+		limits[name] = limit
+	}
+
+	return limits
+}
+
+// mustLoadRateLimitStore возвращает хранилище корзин лимитов. В памяти лимиты считаются отдельно
+// на каждом экземпляре сервиса
+func mustLoadRateLimitStore(cfg config.RateLimits, db *pgxpool.Pool) repositories.RateLimitRepository {
+	switch cfg.Backend {
+	case "", "memory":
+		return ratelimit.NewMemoryStore()
+	case "postgres":
+		return postgres.NewPostgresRateLimitRepository(db)
+	default:
+		panic(fmt.Errorf("unknown rate limit backend %q", cfg.Backend))
+	}
+}
+
+// mustLoadEmailSender возвращает SMTP клиент или, если почтовый сервер не настроен, пишет письма в лог
+func mustLoadEmailSender(cfg config.SMTP, logMailer *mailer.LogMailer, logger *slog.Logger) services.EmailSender {
+	if cfg.Host == "" {
+		logger.Warn("no smtp host configured, emails will only be logged")
+		return logMailer
+	}
+
+	sender, err := mailer.NewSMTPSender(cfg)
+	if err != nil {
+		panic(fmt.Errorf("unable to load smtp settings: %v", err))
+	}
+	return sender
+}
+
+// mustLoadEmailTemplates загружает встроенные шаблоны писем
+func mustLoadEmailTemplates(cfg config.Notifications) *mailer.Templates {
+	templates, err := mailer.NewTemplates(cmp.Or(cfg.DefaultLocale, i18n.DefaultLocale))
+	if err != nil {
+		panic(fmt.Errorf("unable to load email templates: %v", err))
+	}
+	return templates
+}
+
+// mustLoadNotifications возвращает настройки уведомлений. Ссылки отписки по умолчанию ведут
+// на публичный адрес реферальных ссылок
+func mustLoadNotifications(cfg *config.Config) config.Notifications {
+	notifications := cfg.Notifications
+	if notifications.BaseURL == "" {
+		notifications.BaseURL = cfg.ReferralLinks.BaseURL
+	}
+	if notifications.BaseURL != "" {
+		if _, err := url.ParseRequestURI(notifications.BaseURL); err != nil {
+			panic(fmt.Errorf("invalid notifications base url: %v", err))
+		}
+	}
+	return notifications
+}
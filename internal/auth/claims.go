@@ -0,0 +1,52 @@
+// Package auth описывает содержимое токенов доступа и передачу аутентифицированного
+// пользователя от JWTMiddleware к контроллерам
+package auth
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidSubject = errors.New("token subject is not a user id")
+
+// Claims - содержимое токена. Subject хранит ID пользователя строкой, как требует RFC 7519
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenVersion int      `json:"token_version"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// NewClaims создает claims для пользователя. Время жизни, издателя и jti выставляет Tokens.Issue
+func NewClaims(userID, tokenVersion int, roles ...string) *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: strconv.Itoa(userID)},
+		TokenVersion:     tokenVersion,
+		Roles:            roles,
+	}
+}
+
+// UserID возвращает ID пользователя из Subject
+func (c *Claims) UserID() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidSubject
+	}
+	return id, nil
+}
+
+// User - аутентифицированный пользователь текущего запроса. У сервисного API ключа ID равен нулю
+type User struct {
+	ID           int
+	TokenVersion int
+	Roles        []string
+	Scopes       []string
+	APIKeyID     int // ключ, которым выполнен запрос, или 0 для сессии
+}
+
+// HasRole сообщает, есть ли у пользователя роль
+func (u User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userKey - ключ пользователя в контексте gin
+const userKey = "auth.user"
+
+type authenticatedAtKey struct{}
+
+// SetUser сохраняет аутентифицированного пользователя в контексте запроса
+func SetUser(c *gin.Context, user User) {
+	c.Set(userKey, user)
+}
+
+// UserFromContext возвращает пользователя, которого положил JWTMiddleware.
+// false означает, что запрос не прошел аутентификацию
+func UserFromContext(c *gin.Context) (User, bool) {
+	value, exists := c.Get(userKey)
+	if !exists {
+		return User{}, false
+	}
+
+	user, ok := value.(User)
+	return user, ok
+}
+
+// WithAuthenticatedAt сохраняет время входа, которым получен токен запроса
+func WithAuthenticatedAt(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, authenticatedAtKey{}, at)
+}
+
+// AuthenticatedAtFromContext возвращает время входа. false означает, что запрос выполнен не сессией,
+// например API ключом
+func AuthenticatedAtFromContext(ctx context.Context) (time.Time, bool) {
+	at, ok := ctx.Value(authenticatedAtKey{}).(time.Time)
+	return at, ok && !at.IsZero()
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"referral-system/internal/entities"
+	"slices"
+)
+
+// Права доступа. Сессии получают их по роли пользователя, API ключи - при создании
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeReferralsRead  = "referrals:read"
+	ScopeReferralsWrite = "referrals:write"
+	ScopeAdminUsers     = "admin:users"
+	ScopeAdminCampaigns = "admin:campaigns"
+	ScopeAdminReferrals = "admin:referrals"
+	ScopeAdminWebhooks  = "admin:webhooks"
+)
+
+// UserScopes - права, действующие от имени конкретного пользователя
+var UserScopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopeReferralsRead, ScopeReferralsWrite}
+
+// ServiceScopes - права, которые можно выдать сервисному ключу без привязки к пользователю
+var ServiceScopes = []string{ScopeAdminUsers, ScopeAdminCampaigns, ScopeAdminReferrals, ScopeAdminWebhooks}
+
+// ScopesForRoles возвращает права, положенные ролям
+func ScopesForRoles(roles []string) []string {
+	scopes := slices.Clone(UserScopes)
+	if slices.Contains(roles, entities.RoleAdmin) {
+		scopes = append(scopes, ServiceScopes...)
+	}
+	return scopes
+}
+
+// HasScope сообщает, разрешено ли пользователю действие
+func (u User) HasScope(scope string) bool {
+	return slices.Contains(u.Scopes, scope)
+}
+
+// IsSession сообщает, что запрос аутентифицирован токеном сессии, а не API ключом
+func (u User) IsSession() bool {
+	return u.APIKeyID == 0
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"referral-system/internal/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultIssuer   = "referral-system"
+	defaultAudience = "referral-system"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// KeySet подписывает токены и выбирает ключ для их проверки
+type KeySet interface {
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	Algorithms() []string
+}
+
+// Tokens выпускает и проверяет токены с фиксированным издателем
+type Tokens struct {
+	keys     KeySet
+	issuer   string
+	audience string
+}
+
+// NewTokens создает Tokens. Audience из конфига используется по умолчанию для токенов доступа
+func NewTokens(keys KeySet, cfg config.JWTConfig) *Tokens {
+	tokens := &Tokens{keys: keys, issuer: cfg.Issuer, audience: cfg.Audience}
+	if tokens.issuer == "" {
+		tokens.issuer = defaultIssuer
+	}
+	if tokens.audience == "" {
+		tokens.audience = defaultAudience
+	}
+	return tokens
+}
+
+// Issue подписывает claims, выставляя iss, iat, exp и jti. Если audience не задана, используется
+// audience токенов доступа
+func (t *Tokens) Issue(claims *Claims, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.Issuer = t.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	claims.ID = jti
+	if len(claims.Audience) == 0 {
+		claims.Audience = jwt.ClaimStrings{t.audience}
+	}
+
+	return t.keys.Sign(claims)
+}
+
+// Parse проверяет токен доступа
+func (t *Tokens) Parse(tokenString string) (*Claims, error) {
+	return t.ParseFor(tokenString, t.audience)
+}
+
+// ParseFor проверяет подпись, срок действия, издателя и audience токена
+func (t *Tokens) ParseFor(tokenString, audience string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, t.keys.Keyfunc,
+		jwt.WithValidMethods(t.keys.Algorithms()),
+		jwt.WithIssuer(t.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"referral-system/internal/auth"
+	"referral-system/internal/config"
+	"referral-system/internal/infrastructure/jwtkeys"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokens(t *testing.T, cfg config.JWTConfig) (*auth.Tokens, *jwtkeys.KeySet) {
+	keys, err := jwtkeys.Generate("test")
+	require.NoError(t, err)
+	return auth.NewTokens(keys, cfg), keys
+}
+
+func TestTokens_IssueAndParse(t *testing.T) {
+	tokens, _ := newTokens(t, config.JWTConfig{Issuer: "referrals", Audience: "api"})
+
+	signed, err := tokens.Issue(auth.NewClaims(7, 2, "admin"), time.Hour)
+	require.NoError(t, err)
+
+	claims, err := tokens.Parse(signed)
+	require.NoError(t, err)
+
+	userID, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, 7, userID)
+	assert.Equal(t, 2, claims.TokenVersion)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.Equal(t, "referrals", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.IssuedAt)
+}
+
+func TestTokens_RejectsForeignIssuerAndAudience(t *testing.T) {
+	keys, err := jwtkeys.Generate("test")
+	require.NoError(t, err)
+
+	ours := auth.NewTokens(keys, config.JWTConfig{Issuer: "referrals", Audience: "api"})
+	otherIssuer := auth.NewTokens(keys, config.JWTConfig{Issuer: "billing", Audience: "api"})
+	otherAudience := auth.NewTokens(keys, config.JWTConfig{Issuer: "referrals", Audience: "admin"})
+
+	for name, issuer := range map[string]*auth.Tokens{"issuer": otherIssuer, "audience": otherAudience} {
+		t.Run(name, func(t *testing.T) {
+			signed, err := issuer.Issue(auth.NewClaims(7, 0), time.Hour)
+			require.NoError(t, err)
+
+			_, err = ours.Parse(signed)
+			assert.ErrorIs(t, err, auth.ErrInvalidToken)
+		})
+	}
+}
+
+func TestTokens_RejectsExpiredToken(t *testing.T) {
+	tokens, _ := newTokens(t, config.JWTConfig{})
+
+	signed, err := tokens.Issue(auth.NewClaims(7, 0), -time.Minute)
+	require.NoError(t, err)
+
+	_, err = tokens.Parse(signed)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestTokens_RejectsNonNumericSubject(t *testing.T) {
+	tokens, _ := newTokens(t, config.JWTConfig{})
+
+	claims := auth.NewClaims(7, 0)
+	claims.Subject = "admin"
+	signed, err := tokens.Issue(claims, time.Hour)
+	require.NoError(t, err)
+
+	_, err = tokens.Parse(signed)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestTokens_ParseForSeparatesAudiences(t *testing.T) {
+	tokens, _ := newTokens(t, config.JWTConfig{})
+
+	claims := auth.NewClaims(7, 0)
+	claims.Audience = jwt.ClaimStrings{"mfa-challenge"}
+	signed, err := tokens.Issue(claims, time.Hour)
+	require.NoError(t, err)
+
+	// Токен другого назначения не принимается как токен доступа
+	_, err = tokens.Parse(signed)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	_, err = tokens.ParseFor(signed, "mfa-challenge")
+	assert.NoError(t, err)
+}
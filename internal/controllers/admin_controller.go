@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	authService services.AuthService
+	logger      *slog.Logger
+}
+
+// NewAdminController создает новый AdminController
+func NewAdminController(authService services.AuthService, logger *slog.Logger) *AdminController {
+	return &AdminController{authService: authService, logger: logger}
+}
+
+// UnlockUser godoc
+// @Summary Разблокировка входа
+// @Description Снимает блокировку входа, наложенную после серии неудачных попыток
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/users/{id}/unlock [post]
+// @Security ApiKeyAuth
+func (ac *AdminController) UnlockUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ac.logger.Warn("invalid user id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	if err := ac.authService.UnlockUser(c.Request.Context(), userID); err != nil {
+		ac.logger.Error("failed to unlock user", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "user_unlocked"),
+	})
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService services.APIKeyService
+	logger        *slog.Logger
+}
+
+// NewAPIKeyController создает новый APIKeyController
+func NewAPIKeyController(apiKeyService services.APIKeyService, logger *slog.Logger) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService, logger: logger}
+}
+
+// createAPIKeyRequest - тело запроса на создание ключа
+type createAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required,max=100"`
+	Scopes    []string `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresIn int64    `json:"expires_in" binding:"omitempty,expires_in"` // Время жизни в секундах, 0 - бессрочно
+}
+
+// owner возвращает владельца ключей: текущего пользователя или nil для сервисных ключей
+func (kc *APIKeyController) owner(c *gin.Context, service bool) (*int, bool) {
+	if service {
+		return nil, true
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		kc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+	return &authUser.ID, true
+}
+
+func (kc *APIKeyController) create(c *gin.Context, service bool) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		kc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	userID, ok := kc.owner(c, service)
+	if !ok {
+		return
+	}
+
+	key, rawKey, err := kc.apiKeyService.CreateAPIKey(c.Request.Context(), userID, req.Name, req.Scopes,
+		time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		kc.logger.Error("failed to create api key", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": key,
+		"key":     rawKey,
+	})
+}
+
+func (kc *APIKeyController) list(c *gin.Context, service bool) {
+	userID, ok := kc.owner(c, service)
+	if !ok {
+		return
+	}
+
+	keys, err := kc.apiKeyService.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		kc.logger.Error("failed to list api keys", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+	})
+}
+
+func (kc *APIKeyController) revoke(c *gin.Context, service bool) {
+	keyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		kc.logger.Warn("invalid api key id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_api_key_id")
+		return
+	}
+
+	userID, ok := kc.owner(c, service)
+	if !ok {
+		return
+	}
+
+	if err := kc.apiKeyService.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		kc.logger.Error("failed to revoke api key", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "api_key_revoked"),
+	})
+}
+
+// CreateUserAPIKey godoc
+// @Summary Создание API ключа
+// @Description Создает ключ для доступа к API от имени пользователя через заголовок X-API-Key.
+// @Description Значение ключа возвращается только в этом ответе
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param name body string true "Название ключа"
+// @Param scopes body []string true "Права: profile:read, profile:write, referrals:read, referrals:write"
+// @Param expires_in body int64 false "Время жизни в секундах"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/api-keys [post]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) CreateUserAPIKey(c *gin.Context) {
+	kc.create(c, false)
+}
+
+// ListUserAPIKeys godoc
+// @Summary Список API ключей
+// @Description Возвращает ключи пользователя без их значений
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/api-keys [get]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) ListUserAPIKeys(c *gin.Context) {
+	kc.list(c, false)
+}
+
+// RevokeUserAPIKey godoc
+// @Summary Отзыв API ключа
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /users/me/api-keys/{id} [delete]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) RevokeUserAPIKey(c *gin.Context) {
+	kc.revoke(c, false)
+}
+
+// CreateServiceAPIKey godoc
+// @Summary Создание сервисного API ключа
+// @Description Создает ключ без привязки к пользователю для внутренних сервисов
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name body string true "Название ключа"
+// @Param scopes body []string true "Права: admin:users"
+// @Param expires_in body int64 false "Время жизни в секундах"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/api-keys [post]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) CreateServiceAPIKey(c *gin.Context) {
+	kc.create(c, true)
+}
+
+// ListServiceAPIKeys godoc
+// @Summary Список сервисных API ключей
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/api-keys [get]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) ListServiceAPIKeys(c *gin.Context) {
+	kc.list(c, true)
+}
+
+// RevokeServiceAPIKey godoc
+// @Summary Отзыв сервисного API ключа
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/api-keys/{id} [delete]
+// @Security ApiKeyAuth
+func (kc *APIKeyController) RevokeServiceAPIKey(c *gin.Context) {
+	kc.revoke(c, true)
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"referral-system/internal/entities"
 	"referral-system/internal/infrastructure/logger/sl"
 	"referral-system/internal/services"
 
@@ -10,18 +12,20 @@ import (
 )
 
 type AuthController struct {
-	authService services.AuthService
-	logger      *slog.Logger
+	authService     services.AuthService
+	referralService services.ReferralService
+	logger          *slog.Logger
 }
 
 // NewAuthController создает новый AuthController
-func NewAuthController(authService services.AuthService, logger *slog.Logger) *AuthController {
-	return &AuthController{authService: authService, logger: logger}
+func NewAuthController(authService services.AuthService, referralService services.ReferralService, logger *slog.Logger) *AuthController {
+	return &AuthController{authService: authService, referralService: referralService, logger: logger}
 }
 
 // Login godoc
 // @Summary Вход пользователя
-// @Description Вход пользователя с получением JWT токена
+// @Description Вход пользователя с получением JWT токена. Если у пользователя включен второй фактор,
+// @Description вместо токена возвращаются mfa_required и mfa_token для POST /auth/login/mfa
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -29,23 +33,73 @@ func NewAuthController(authService services.AuthService, logger *slog.Logger) *A
 // @Param password body string true "Пароль"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login [post]
 func (ac *AuthController) Login(c *gin.Context) {
 	var req struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,max=72"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ac.logger.Warn("failed to bind request", sl.Err(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		invalidRequest(c, err)
+		return
+	}
+
+	user, token, err := ac.authService.LoginUser(c.Request.Context(), req.Email, req.Password, c.ClientIP())
+
+	// Для входа нужен второй фактор - отдаем токен для POST /auth/login/mfa
+	var mfaErr *services.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaErr.Token,
+		})
 		return
 	}
 
-	user, token, err := ac.authService.LoginUser(req.Email, req.Password)
 	if err != nil {
 		ac.logger.Error("failed to login", sl.Err(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user,
+		"token": token,
+	})
+}
+
+// LoginMFA godoc
+// @Summary Второй шаг входа
+// @Description Завершает вход кодом из приложения-аутентификатора или кодом восстановления
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param mfa_token body string true "Токен, полученный на первом шаге входа"
+// @Param code body string true "TOTP код или код восстановления"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /auth/login/mfa [post]
+func (ac *AuthController) LoginMFA(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required,max=32"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ac.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	user, token, err := ac.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		ac.logger.Error("failed to verify second factor", sl.Err(err))
+		respondError(c, err)
 		return
 	}
 
@@ -57,33 +111,36 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 // Register godoc
 // @Summary Регистрация нового пользователя
-// @Description Регистрация нового пользователя
+// @Description Регистрация нового пользователя. Без введенного реферального кода засчитывается код
+// @Description из cookie, сохраненной при переходе по ссылке /r/{code}
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param name body string true "Имя пользователя"
 // @Param email body string true "Email пользователя"
 // @Param password body string true "Пароль"
+// @Param referral_code body string false "Реферальный код"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Router /auth/register [post]
 func (ac *AuthController) Register(c *gin.Context) {
 	var req struct {
-		Name     string `json:"name" binding:"required"`
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		Name         string `json:"name" binding:"required,username"`
+		Email        string `json:"email" binding:"required,email,max=255"`
+		Password     string `json:"password" binding:"required,password"`
+		ReferralCode string `json:"referral_code" binding:"omitempty,max=255"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ac.logger.Warn("failed to bind request", sl.Err(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		invalidRequest(c, err)
 		return
 	}
 
-	user, err := ac.authService.RegisterUser(req.Name, req.Email, req.Password)
+	user, err := ac.register(c, req.Name, req.Email, req.Password, req.ReferralCode)
 	if err != nil {
 		ac.logger.Error("failed to register user", sl.Err(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -91,3 +148,30 @@ func (ac *AuthController) Register(c *gin.Context) {
 		"user": user,
 	})
 }
+
+// register регистрирует пользователя по введенному коду, а без него - по коду из cookie перехода.
+// Недействительный код из cookie не мешает регистрации, в отличие от введенного
+func (ac *AuthController) register(c *gin.Context, name, email, password, referralCode string) (*entities.User, error) {
+	ctx := c.Request.Context()
+	if referralCode == "" {
+		if attributed, err := c.Cookie(attributionCookie); err == nil && attributed != "" {
+			if err := ac.referralService.ValidateReferralCode(ctx, attributed); err != nil {
+				ac.logger.Warn("ignoring attributed referral code", sl.Err(err), slog.String("code", attributed))
+			} else {
+				referralCode = attributed
+			}
+		}
+	}
+
+	if referralCode == "" {
+		return ac.authService.RegisterUser(ctx, name, email, password)
+	}
+
+	user, err := ac.referralService.RegisterWithReferralCode(ctx, referralCode, name, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetCookie(attributionCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	return user, nil
+}
@@ -8,11 +8,13 @@ import (
 	"referral-system/internal/controllers/mocks"
 	"referral-system/internal/entities"
 	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
 	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAuthController_Login_Success(t *testing.T) {
@@ -22,7 +24,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 	mockAuthService := mocks.NewAuthService(t)
 
 	logger := slogdiscard.NewDiscardLogger()
-	authController := controllers.NewAuthController(mockAuthService, logger)
+	authController := controllers.NewAuthController(mockAuthService, mocks.NewReferralService(t), logger)
 
 	router.POST("/auth/login", authController.Login)
 	var mockReq struct {
@@ -36,7 +38,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 		HashedPassword: mockReq.Password,
 	}
 
-	mockAuthService.On("LoginUser", mockReq.Email, mockReq.Password).
+	mockAuthService.On("LoginUser", mock.Anything, mockReq.Email, mockReq.Password, mock.Anything).
 		Return(mockUser, "valid_token", nil)
 
 	req, _ := http.NewRequest("POST", "/auth/login", strings.NewReader(`{"email": "example@mail.com", "password": "test_password"}`))
@@ -52,6 +54,44 @@ func TestAuthController_Login_Success(t *testing.T) {
 	mockAuthService.AssertExpectations(t)
 }
 
+func TestAuthController_Login_TrustsForwardedForOnlyFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		wantIP     string
+	}{
+		{"spoofed header from client", nil, "203.0.113.7:4000", "203.0.113.7"},
+		{"header from trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:4000", "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			assert.NoError(t, router.SetTrustedProxies(tt.proxies))
+
+			mockAuthService := mocks.NewAuthService(t)
+			authController := controllers.NewAuthController(mockAuthService, mocks.NewReferralService(t), slogdiscard.NewDiscardLogger())
+			router.POST("/auth/login", authController.Login)
+
+			mockAuthService.On("LoginUser", mock.Anything, "example@mail.com", "test_password", tt.wantIP).
+				Return(nil, "", services.ErrInvalidCredentials)
+
+			req, _ := http.NewRequest("POST", "/auth/login", strings.NewReader(`{"email": "example@mail.com", "password": "test_password"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
 func TestAuthController_Login_InvalidCredentials(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -59,11 +99,11 @@ func TestAuthController_Login_InvalidCredentials(t *testing.T) {
 	mockAuthService := mocks.NewAuthService(t)
 
 	logger := slogdiscard.NewDiscardLogger()
-	authController := controllers.NewAuthController(mockAuthService, logger)
+	authController := controllers.NewAuthController(mockAuthService, mocks.NewReferralService(t), logger)
 	router.POST("/auth/login", authController.Login)
 
 	// Настраиваем mock-ответ для метода LoginUser
-	mockAuthService.On("LoginUser", "example@mail.com", "wrong_password").
+	mockAuthService.On("LoginUser", mock.Anything, "example@mail.com", "wrong_password", mock.Anything).
 		Return(nil, "", errors.New("Invalid credentials"))
 
 	req, _ := http.NewRequest("POST", "/auth/login", strings.NewReader(`{"email": "example@mail.com", "password": "wrong_password"}`))
@@ -73,7 +113,9 @@ func TestAuthController_Login_InvalidCredentials(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Invalid credentials")
+	// Текст неизвестной ошибки не попадает в ответ, клиент получает сообщение из каталога
+	assert.Contains(t, w.Body.String(), `"code":"internal_error"`)
+	assert.NotContains(t, w.Body.String(), "Invalid credentials")
 
 	mockAuthService.AssertExpectations(t)
 }
@@ -85,7 +127,7 @@ func TestAuthController_Login_InvalidRequest(t *testing.T) {
 	mockAuthService := mocks.NewAuthService(t)
 
 	logger := slogdiscard.NewDiscardLogger()
-	authController := controllers.NewAuthController(mockAuthService, logger)
+	authController := controllers.NewAuthController(mockAuthService, mocks.NewReferralService(t), logger)
 	router.POST("/auth/login", authController.Login)
 
 	// Создаем HTTP-запрос с некорректными данными
@@ -100,3 +142,80 @@ func TestAuthController_Login_InvalidRequest(t *testing.T) {
 
 	mockAuthService.AssertNotCalled(t, "LoginUser")
 }
+
+func TestAuthController_Register_ValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	authController := controllers.NewAuthController(mockAuthService, mocks.NewReferralService(t), logger)
+	router.POST("/auth/register", authController.Register)
+
+	req, _ := http.NewRequest("POST", "/auth/register", strings.NewReader(`{"name": "John", "email": "not-an-email", "password": ""}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"email"`)
+	assert.Contains(t, w.Body.String(), `"field":"password"`)
+	assert.NotContains(t, w.Body.String(), `"field":"name"`)
+
+	mockAuthService.AssertNotCalled(t, "RegisterUser")
+}
+
+func TestAuthController_Register_FallsBackToAttributionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockAuthService := mocks.NewAuthService(t)
+	mockReferralService := mocks.NewReferralService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	authController := controllers.NewAuthController(mockAuthService, mockReferralService, logger)
+	router.POST("/auth/register", authController.Register)
+
+	mockReferralService.On("ValidateReferralCode", mock.Anything, "REFCODE").Return(nil)
+	mockReferralService.On("RegisterWithReferralCode", mock.Anything, "REFCODE", "John", "john@mail.com", "Str0ng!pass").
+		Return(&entities.User{ID: 10, Name: "John", Email: "john@mail.com"}, nil)
+
+	req, _ := http.NewRequest("POST", "/auth/register", strings.NewReader(`{"name": "John", "email": "john@mail.com", "password": "Str0ng!pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "ref_code", Value: "REFCODE"})
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "ref_code=;")
+	mockAuthService.AssertNotCalled(t, "RegisterUser")
+}
+
+func TestAuthController_Register_IgnoresExpiredAttributionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockAuthService := mocks.NewAuthService(t)
+	mockReferralService := mocks.NewReferralService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	authController := controllers.NewAuthController(mockAuthService, mockReferralService, logger)
+	router.POST("/auth/register", authController.Register)
+
+	mockReferralService.On("ValidateReferralCode", mock.Anything, "OLD").Return(services.ErrReferralCodeExpired)
+	mockAuthService.On("RegisterUser", mock.Anything, "John", "john@mail.com", "Str0ng!pass").
+		Return(&entities.User{ID: 10, Name: "John", Email: "john@mail.com"}, nil)
+
+	req, _ := http.NewRequest("POST", "/auth/register", strings.NewReader(`{"name": "John", "email": "john@mail.com", "password": "Str0ng!pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "ref_code", Value: "OLD"})
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockReferralService.AssertNotCalled(t, "RegisterWithReferralCode")
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CampaignController struct {
+	campaignService services.CampaignService
+	logger          *slog.Logger
+}
+
+// NewCampaignController создает новый CampaignController
+func NewCampaignController(campaignService services.CampaignService, logger *slog.Logger) *CampaignController {
+	return &CampaignController{campaignService: campaignService, logger: logger}
+}
+
+// campaignRequest - тело запроса на создание и изменение кампании
+type campaignRequest struct {
+	Name           string    `json:"name" binding:"required,max=100"`
+	Description    string    `json:"description"`
+	StartsAt       time.Time `json:"starts_at" binding:"required"`
+	EndsAt         time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	CodeTTL        int       `json:"code_ttl" binding:"required,expires_in"` // Время жизни кодов в секундах
+	ReferrerReward int       `json:"referrer_reward" binding:"min=0"`
+	RefereeReward  int       `json:"referee_reward" binding:"min=0"`
+	MaxUsesPerCode *int      `json:"max_uses_per_code" binding:"omitempty,min=1"`
+	MaxReferrals   *int      `json:"max_referrals" binding:"omitempty,min=1"`
+}
+
+func (r *campaignRequest) campaign() *entities.Campaign {
+	return &entities.Campaign{
+		Name:           r.Name,
+		Description:    r.Description,
+		StartsAt:       r.StartsAt,
+		EndsAt:         r.EndsAt,
+		CodeTTL:        r.CodeTTL,
+		ReferrerReward: r.ReferrerReward,
+		RefereeReward:  r.RefereeReward,
+		MaxUsesPerCode: r.MaxUsesPerCode,
+		MaxReferrals:   r.MaxReferrals,
+	}
+}
+
+// campaignID читает ID кампании из пути
+func (cc *CampaignController) campaignID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		cc.logger.Warn("invalid campaign id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_campaign_id")
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateCampaign godoc
+// @Summary Создание кампании
+// @Description Создает реферальную кампанию со сроками, наградами и лимитами
+// @Tags campaigns
+// @Accept json
+// @Produce json
+// @Param name body string true "Название"
+// @Param starts_at body string true "Начало кампании, RFC3339"
+// @Param ends_at body string true "Окончание кампании, RFC3339"
+// @Param code_ttl body int true "Время жизни кодов в секундах"
+// @Param referrer_reward body int false "Награда пригласившему"
+// @Param referee_reward body int false "Награда приглашенному"
+// @Param max_uses_per_code body int false "Сколько регистраций засчитывается по одному коду"
+// @Param max_referrals body int false "Сколько регистраций засчитывается за всю кампанию"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/campaigns [post]
+// @Security ApiKeyAuth
+func (cc *CampaignController) CreateCampaign(c *gin.Context) {
+	var req campaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		cc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	campaign := req.campaign()
+	if err := cc.campaignService.CreateCampaign(c.Request.Context(), campaign); err != nil {
+		cc.logger.Error("failed to create campaign", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"campaign": campaign,
+	})
+}
+
+// ListCampaigns godoc
+// @Summary Список кампаний
+// @Tags campaigns
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/campaigns [get]
+// @Security ApiKeyAuth
+func (cc *CampaignController) ListCampaigns(c *gin.Context) {
+	campaigns, err := cc.campaignService.ListCampaigns(c.Request.Context())
+	if err != nil {
+		cc.logger.Error("failed to list campaigns", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaigns": campaigns,
+	})
+}
+
+// GetCampaign godoc
+// @Summary Получение кампании
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/campaigns/{id} [get]
+// @Security ApiKeyAuth
+func (cc *CampaignController) GetCampaign(c *gin.Context) {
+	id, ok := cc.campaignID(c)
+	if !ok {
+		return
+	}
+
+	campaign, err := cc.campaignService.GetCampaign(c.Request.Context(), id)
+	if err != nil {
+		cc.logger.Error("failed to get campaign", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaign": campaign,
+	})
+}
+
+// UpdateCampaign godoc
+// @Summary Изменение кампании
+// @Description Заменяет правила кампании. Уже выпущенные коды и начисленные награды не меняются
+// @Tags campaigns
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/campaigns/{id} [put]
+// @Security ApiKeyAuth
+func (cc *CampaignController) UpdateCampaign(c *gin.Context) {
+	id, ok := cc.campaignID(c)
+	if !ok {
+		return
+	}
+
+	var req campaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		cc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	campaign := req.campaign()
+	campaign.ID = id
+	if err := cc.campaignService.UpdateCampaign(c.Request.Context(), campaign); err != nil {
+		cc.logger.Error("failed to update campaign", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaign": campaign,
+	})
+}
+
+// DeleteCampaign godoc
+// @Summary Удаление кампании
+// @Description Удаляет кампанию, по которой еще не выпущено ни одного кода
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /admin/campaigns/{id} [delete]
+// @Security ApiKeyAuth
+func (cc *CampaignController) DeleteCampaign(c *gin.Context) {
+	id, ok := cc.campaignID(c)
+	if !ok {
+		return
+	}
+
+	if err := cc.campaignService.DeleteCampaign(c.Request.Context(), id); err != nil {
+		cc.logger.Error("failed to delete campaign", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "campaign_deleted"),
+	})
+}
+
+// GetCampaignStats godoc
+// @Summary Статистика кампании
+// @Description Количество кодов и регистраций, сумма начисленных наград
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/campaigns/{id}/stats [get]
+// @Security ApiKeyAuth
+func (cc *CampaignController) GetCampaignStats(c *gin.Context) {
+	id, ok := cc.campaignID(c)
+	if !ok {
+		return
+	}
+
+	stats, err := cc.campaignService.GetCampaignStats(c.Request.Context(), id)
+	if err != nil {
+		cc.logger.Error("failed to get campaign stats", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batchCSVFlushRows - через сколько строк CSV отправляется клиенту
+const batchCSVFlushRows = 500
+
+type CodeBatchController struct {
+	batchService services.CodeBatchService
+	logger       *slog.Logger
+}
+
+// NewCodeBatchController создает новый CodeBatchController
+func NewCodeBatchController(batchService services.CodeBatchService, logger *slog.Logger) *CodeBatchController {
+	return &CodeBatchController{batchService: batchService, logger: logger}
+}
+
+// createBatchRequest - тело запроса на выпуск пакета кодов
+type createBatchRequest struct {
+	PartnerID int `json:"partner_id" binding:"required,min=1"`
+	Size      int `json:"size" binding:"required,min=1,max=10000"`
+}
+
+// pathID читает числовой параметр пути. errorCode - код сообщения, которым отвечаем на нечисловой ID
+func (bc *CodeBatchController) pathID(c *gin.Context, errorCode string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		bc.logger.Warn("invalid path id", slog.String("code", errorCode), sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, errorCode)
+		return 0, false
+	}
+	return id, true
+}
+
+// streamCSV отдает коды пакета в CSV. Заголовки пишутся вместе с первой строкой, поэтому
+// ошибку, случившуюся до нее, еще можно вернуть обычным ответом
+func (bc *CodeBatchController) streamCSV(c *gin.Context, batchID, status int) {
+	writer := csv.NewWriter(c.Writer)
+	rows := 0
+
+	err := bc.batchService.StreamBatchCodes(c.Request.Context(), batchID, func(code *entities.ReferralCode) error {
+		if rows == 0 {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="code-batch-%d.csv"`, batchID))
+			c.Header("X-Batch-ID", strconv.Itoa(batchID))
+			c.Status(status)
+			if err := writer.Write([]string{"code", "expires_at"}); err != nil {
+				return err
+			}
+		}
+
+		rows++
+		if err := writer.Write([]string{code.Code, code.ExpiresAt.UTC().Format(time.RFC3339)}); err != nil {
+			return err
+		}
+		if rows%batchCSVFlushRows == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		bc.logger.Error("failed to stream code batch", sl.Err(err), slog.Int("batch_id", batchID))
+		if rows == 0 {
+			respondError(c, err)
+		}
+		return
+	}
+
+	writer.Flush()
+}
+
+// CreateBatch godoc
+// @Summary Выпуск пакета кодов
+// @Description Генерирует пакет одноразовых кодов кампании для партнера и возвращает их в CSV.
+// @Description Пакет можно скачать повторно по адресу из заголовка Location
+// @Tags campaigns
+// @Accept json
+// @Produce text/csv
+// @Param id path int true "ID кампании"
+// @Param partner_id body int true "Пользователь, которому засчитываются регистрации"
+// @Param size body int true "Количество кодов, не больше 10000"
+// @Success 201 {string} string "CSV с колонками code, expires_at"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/campaigns/{id}/code-batches [post]
+// @Security ApiKeyAuth
+func (bc *CodeBatchController) CreateBatch(c *gin.Context) {
+	campaignID, ok := bc.pathID(c, "invalid_campaign_id")
+	if !ok {
+		return
+	}
+
+	var req createBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	// Сервисный ключ не привязан к пользователю, автора у такого пакета нет
+	var createdBy *int
+	if authUser, exists := auth.UserFromContext(c); exists && authUser.ID != 0 {
+		createdBy = &authUser.ID
+	}
+
+	batch, err := bc.batchService.CreateBatch(c.Request.Context(), campaignID, req.PartnerID, req.Size, createdBy)
+	if err != nil {
+		bc.logger.Error("failed to create code batch", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/admin/code-batches/%d/codes.csv", batch.ID))
+	bc.streamCSV(c, batch.ID, http.StatusCreated)
+}
+
+// ListBatches godoc
+// @Summary Пакеты кодов кампании
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/campaigns/{id}/code-batches [get]
+// @Security ApiKeyAuth
+func (bc *CodeBatchController) ListBatches(c *gin.Context) {
+	campaignID, ok := bc.pathID(c, "invalid_campaign_id")
+	if !ok {
+		return
+	}
+
+	batches, err := bc.batchService.ListBatches(c.Request.Context(), campaignID)
+	if err != nil {
+		bc.logger.Error("failed to list code batches", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code_batches": batches,
+	})
+}
+
+// GetBatch godoc
+// @Summary Статус пакета кодов
+// @Tags campaigns
+// @Produce json
+// @Param id path int true "ID пакета"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/code-batches/{id} [get]
+// @Security ApiKeyAuth
+func (bc *CodeBatchController) GetBatch(c *gin.Context) {
+	batchID, ok := bc.pathID(c, "invalid_code_batch_id")
+	if !ok {
+		return
+	}
+
+	batch, err := bc.batchService.GetBatch(c.Request.Context(), batchID)
+	if err != nil {
+		bc.logger.Error("failed to get code batch", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code_batch": batch,
+	})
+}
+
+// DownloadBatch godoc
+// @Summary Скачивание пакета кодов
+// @Description Повторно отдает коды готового пакета в CSV
+// @Tags campaigns
+// @Produce text/csv
+// @Param id path int true "ID пакета"
+// @Success 200 {string} string "CSV с колонками code, expires_at"
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /admin/code-batches/{id}/codes.csv [get]
+// @Security ApiKeyAuth
+func (bc *CodeBatchController) DownloadBatch(c *gin.Context) {
+	batchID, ok := bc.pathID(c, "invalid_code_batch_id")
+	if !ok {
+		return
+	}
+
+	bc.streamCSV(c, batchID, http.StatusOK)
+}
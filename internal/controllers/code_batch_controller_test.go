@@ -0,0 +1,71 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCodeBatchController_CreateBatch_StreamsCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockBatchService := mocks.NewCodeBatchService(t)
+	batchController := controllers.NewCodeBatchController(mockBatchService, slogdiscard.NewDiscardLogger())
+	router.POST("/admin/campaigns/:id/code-batches", withUserID(1), batchController.CreateBatch)
+
+	createdBy := 1
+	mockBatchService.On("CreateBatch", mock.Anything, 4, 9, 2, &createdBy).
+		Return(&entities.CodeBatch{ID: 12, CampaignID: 4, PartnerID: 9, Size: 2, Status: entities.BatchStatusCompleted}, nil)
+
+	expiresAt := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockBatchService.On("StreamBatchCodes", mock.Anything, 12, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*entities.ReferralCode) error)
+			for _, code := range []string{"AAAAAAAAAAAA", "BBBBBBBBBBBB"} {
+				assert.NoError(t, fn(&entities.ReferralCode{Code: code, ExpiresAt: expiresAt}))
+			}
+		}).
+		Return(nil)
+
+	req, _ := http.NewRequest("POST", "/admin/campaigns/4/code-batches", strings.NewReader(`{"partner_id": 9, "size": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/admin/code-batches/12/codes.csv", w.Header().Get("Location"))
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "code,expires_at\nAAAAAAAAAAAA,2026-05-01T00:00:00Z\nBBBBBBBBBBBB,2026-05-01T00:00:00Z\n", w.Body.String())
+}
+
+func TestCodeBatchController_DownloadBatch_NotReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockBatchService := mocks.NewCodeBatchService(t)
+	batchController := controllers.NewCodeBatchController(mockBatchService, slogdiscard.NewDiscardLogger())
+	router.GET("/admin/code-batches/:id/codes.csv", batchController.DownloadBatch)
+
+	mockBatchService.On("StreamBatchCodes", mock.Anything, 12, mock.Anything).Return(services.ErrCodeBatchNotReady)
+
+	req, _ := http.NewRequest("GET", "/admin/code-batches/12/codes.csv", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrCodeBatchNotReady.Error())
+}
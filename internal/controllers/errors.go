@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"referral-system/internal/i18n"
+	"referral-system/internal/infrastructure/passwords"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/validation"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceError - HTTP статус и код сообщения в каталоге для ошибки сервиса
+type serviceError struct {
+	err    error
+	status int
+	code   string
+}
+
+// serviceErrors проверяются по порядку через errors.Is. Код ошибки стабилен, клиенты могут на него опираться
+var serviceErrors = []serviceError{
+	{services.ErrUserNotFound, http.StatusUnauthorized, "user_not_found"},
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{services.ErrInvalidMFACode, http.StatusUnauthorized, "invalid_mfa_code"},
+	{services.ErrInvalidMFAToken, http.StatusUnauthorized, "invalid_mfa_token"},
+	{services.ErrReauthRequired, http.StatusUnauthorized, "reauth_required"},
+	{services.ErrOAuthExchangeFailed, http.StatusUnauthorized, "oauth_exchange_failed"},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+
+	{services.ErrOAuthEmailNotVerified, http.StatusForbidden, "oauth_email_not_verified"},
+
+	{services.ErrInvalidReferralCode, http.StatusBadRequest, "invalid_referral_code"},
+	{services.ErrReferralCodeExpired, http.StatusBadRequest, "referral_code_expired"},
+	{services.ErrWeakPassword, http.StatusBadRequest, "weak_password"},
+	{services.ErrInvalidVerificationToken, http.StatusBadRequest, "invalid_verification_token"},
+	{services.ErrMFANotEnrolled, http.StatusBadRequest, "mfa_not_enrolled"},
+	{services.ErrInvalidOAuthState, http.StatusBadRequest, "invalid_oauth_state"},
+	{services.ErrInvalidAPIScope, http.StatusBadRequest, "invalid_api_scope"},
+	{services.ErrInvalidCampaign, http.StatusBadRequest, "invalid_campaign"},
+	{services.ErrCampaignInactive, http.StatusBadRequest, "campaign_inactive"},
+	{services.ErrReferralLimitReached, http.StatusBadRequest, "referral_limit_reached"},
+	{services.ErrInvalidCodeBatch, http.StatusBadRequest, "invalid_code_batch"},
+	{services.ErrInvalidStatsRange, http.StatusBadRequest, "invalid_stats_range"},
+	{services.ErrInvalidWebhook, http.StatusBadRequest, "invalid_webhook"},
+	{services.ErrInvalidNotificationType, http.StatusBadRequest, "invalid_notification_type"},
+	{services.ErrInvalidUnsubscribeToken, http.StatusBadRequest, "invalid_unsubscribe_token"},
+
+	{repositories.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrUnknownOAuthProvider, http.StatusNotFound, "unknown_oauth_provider"},
+	{services.ErrCampaignNotFound, http.StatusNotFound, "campaign_not_found"},
+	{services.ErrCodeBatchNotFound, http.StatusNotFound, "code_batch_not_found"},
+	{services.ErrReferralCodeNotFound, http.StatusNotFound, "referral_code_not_found"},
+	{services.ErrReferralNotFound, http.StatusNotFound, "referral_not_found"},
+	{services.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found"},
+	{services.ErrWebhookDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found"},
+
+	{repositories.ErrConflict, http.StatusConflict, "conflict"},
+	{services.ErrUserAlreadyExists, http.StatusConflict, "user_already_exists"},
+	{services.ErrReferralCodeExists, http.StatusConflict, "referral_code_exists"},
+	{services.ErrMFAAlreadyEnabled, http.StatusConflict, "mfa_already_enabled"},
+	{services.ErrCampaignInUse, http.StatusConflict, "campaign_in_use"},
+	{services.ErrCodeBatchNotReady, http.StatusConflict, "code_batch_not_ready"},
+	{services.ErrOAuthAccountExists, http.StatusConflict, "oauth_account_exists"},
+	{services.ErrOAuthIdentityLinked, http.StatusConflict, "oauth_identity_linked"},
+
+	{services.ErrTooManyAttempts, http.StatusTooManyRequests, "too_many_attempts"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "request_timeout"},
+	{services.ErrCodeBatchFailed, http.StatusInternalServerError, "code_batch_failed"},
+}
+
+// lookupError находит ошибку сервиса в таблице. Неизвестные ошибки считаются внутренними,
+// их текст клиенту не показывается
+func lookupError(err error) serviceError {
+	for _, known := range serviceErrors {
+		if errors.Is(err, known.err) {
+			return known
+		}
+	}
+	return serviceError{err: err, status: http.StatusInternalServerError, code: "internal_error"}
+}
+
+// errorStatus подбирает HTTP статус для ошибки, вернувшейся из сервиса
+func errorStatus(err error) int {
+	return lookupError(err).status
+}
+
+// respondError отвечает клиенту ошибкой сервиса с подходящим HTTP статусом. Сообщение берется из каталога
+// на языке запроса, а код ошибки от языка не зависит
+func respondError(c *gin.Context, err error) {
+	locale := i18n.FromContext(c.Request.Context())
+
+	var policyErr *passwords.PolicyError
+	if errors.As(err, &policyErr) {
+		details := make([]validation.FieldError, 0, len(policyErr.Messages))
+		for _, violation := range policyErr.Messages {
+			details = append(details, validation.FieldError{Field: "password", Code: violation.Code, Message: violation.Translate(locale)})
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.T(locale, "weak_password", nil), "code": "weak_password", "details": details})
+		return
+	}
+
+	var lockoutErr *services.LockoutError
+	if errors.As(err, &lockoutErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+	}
+
+	known := lookupError(err)
+	response := gin.H{"error": i18n.T(locale, known.code, nil), "code": known.code}
+	// Поле, которое не прошло проверку в сервисе, описывается так же, как ошибки биндинга
+	var fieldErr *services.FieldError
+	if errors.As(err, &fieldErr) {
+		response["details"] = []validation.FieldError{{Field: fieldErr.Field, Code: fieldErr.Message.Code, Message: fieldErr.Message.Translate(locale)}}
+	}
+	c.JSON(known.status, response)
+}
+
+// respondMessage отвечает ошибкой из каталога сообщений, не связанной с ошибкой сервиса
+func respondMessage(c *gin.Context, status int, code string) {
+	c.JSON(status, gin.H{"error": i18n.Translate(c.Request.Context(), code, nil), "code": code})
+}
+
+// localize возвращает сообщение из каталога на языке запроса
+func localize(c *gin.Context, code string) string {
+	return i18n.Translate(c.Request.Context(), code, nil)
+}
+
+// invalidRequest отвечает 400 и, если это ошибка валидации, перечисляет проблемные поля
+func invalidRequest(c *gin.Context, err error) {
+	locale := i18n.FromContext(c.Request.Context())
+
+	response := gin.H{"error": i18n.T(locale, "invalid_request", nil), "code": "invalid_request"}
+	if details := validation.Details(err, locale); len(details) > 0 {
+		response["details"] = details
+	}
+
+	c.JSON(http.StatusBadRequest, response)
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderboardController struct {
+	leaderboardService services.LeaderboardService
+	logger             *slog.Logger
+}
+
+// NewLeaderboardController создает новый LeaderboardController
+func NewLeaderboardController(leaderboardService services.LeaderboardService, logger *slog.Logger) *LeaderboardController {
+	return &LeaderboardController{leaderboardService: leaderboardService, logger: logger}
+}
+
+// GetLeaderboard godoc
+// @Summary Рейтинг рефереров
+// @Description Первые места по квалифицированным рефералам за период и место текущего пользователя.
+// @Description Рейтинг пересчитывается периодически, время снимка возвращается в refreshed_at
+// @Tags referral
+// @Produce json
+// @Param period query string false "week (по умолчанию), month или all"
+// @Param limit query int false "Сколько мест вернуть, 1-100, по умолчанию 10"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /referrals/leaderboard [get]
+// @Security ApiKeyAuth
+func (lc *LeaderboardController) GetLeaderboard(c *gin.Context) {
+	var req struct {
+		Period string `form:"period" binding:"omitempty,oneof=week month all"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		lc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+	if req.Period == "" {
+		req.Period = entities.LeaderboardWeek
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		lc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	leaderboard, err := lc.leaderboardService.GetLeaderboard(c.Request.Context(), req.Period, req.Limit, authUser.ID)
+	if err != nil {
+		lc.logger.Error("failed to get leaderboard", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leaderboard": leaderboard,
+	})
+}
@@ -0,0 +1,40 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLeaderboardController_GetLeaderboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockLeaderboardService := mocks.NewLeaderboardService(t)
+	leaderboardController := controllers.NewLeaderboardController(mockLeaderboardService, slogdiscard.NewDiscardLogger())
+	router.GET("/referrals/leaderboard", withUserID(1), leaderboardController.GetLeaderboard)
+
+	mockLeaderboardService.On("GetLeaderboard", mock.Anything, entities.LeaderboardWeek, 10, 1).
+		Return(&entities.Leaderboard{Period: entities.LeaderboardWeek, Me: &entities.LeaderboardEntry{Rank: 3, UserID: 1}}, nil)
+
+	req, _ := http.NewRequest("GET", "/referrals/leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rank":3`)
+
+	req, _ = http.NewRequest("GET", "/referrals/leaderboard?period=year", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaService services.MFAService
+	logger     *slog.Logger
+}
+
+// NewMFAController создает новый MFAController
+func NewMFAController(mfaService services.MFAService, logger *slog.Logger) *MFAController {
+	return &MFAController{mfaService: mfaService, logger: logger}
+}
+
+// EnrollTOTP godoc
+// @Summary Подключение TOTP
+// @Description Создает секрет для приложения-аутентификатора. Второй фактор включается после подтверждения кодом
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} entities.TOTPEnrollment
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /users/me/mfa/totp [post]
+// @Security ApiKeyAuth
+func (mc *MFAController) EnrollTOTP(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		mc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	enrollment, err := mc.mfaService.EnrollTOTP(c.Request.Context(), authUser.ID)
+	if err != nil {
+		mc.logger.Error("failed to enroll totp", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary Подтверждение TOTP
+// @Description Включает второй фактор и возвращает одноразовые коды восстановления
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param code body string true "Код из приложения-аутентификатора"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/mfa/totp/confirm [post]
+// @Security ApiKeyAuth
+func (mc *MFAController) ConfirmTOTP(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required,numeric,len=6"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		mc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	recoveryCodes, err := mc.mfaService.ConfirmTOTP(c.Request.Context(), authUser.ID, req.Code)
+	if err != nil {
+		mc.logger.Error("failed to confirm totp", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Отключение TOTP
+// @Description Отключает второй фактор после проверки кода из приложения или кода восстановления
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param code body string true "TOTP код или код восстановления"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/mfa/totp [delete]
+// @Security ApiKeyAuth
+func (mc *MFAController) DisableTOTP(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required,max=32"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		mc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := mc.mfaService.DisableTOTP(c.Request.Context(), authUser.ID, req.Code); err != nil {
+		mc.logger.Error("failed to disable totp", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "mfa_disabled"),
+	})
+}
@@ -0,0 +1,146 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	auth "referral-system/internal/auth"
+
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// APIKeyService is an autogenerated mock type for the APIKeyService type
+type APIKeyService struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, rawKey
+func (_m *APIKeyService) Authenticate(ctx context.Context, rawKey string) (auth.User, error) {
+	ret := _m.Called(ctx, rawKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 auth.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (auth.User, error)); ok {
+		return rf(ctx, rawKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) auth.User); ok {
+		r0 = rf(ctx, rawKey)
+	} else {
+		r0 = ret.Get(0).(auth.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, rawKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, userID, name, scopes, expiresIn
+func (_m *APIKeyService) CreateAPIKey(ctx context.Context, userID *int, name string, scopes []string, expiresIn time.Duration) (*entities.APIKey, string, error) {
+	ret := _m.Called(ctx, userID, name, scopes, expiresIn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *entities.APIKey
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *int, string, []string, time.Duration) (*entities.APIKey, string, error)); ok {
+		return rf(ctx, userID, name, scopes, expiresIn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *int, string, []string, time.Duration) *entities.APIKey); ok {
+		r0 = rf(ctx, userID, name, scopes, expiresIn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *int, string, []string, time.Duration) string); ok {
+		r1 = rf(ctx, userID, name, scopes, expiresIn)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *int, string, []string, time.Duration) error); ok {
+		r2 = rf(ctx, userID, name, scopes, expiresIn)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx, userID
+func (_m *APIKeyService) ListAPIKeys(ctx context.Context, userID *int) ([]*entities.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []*entities.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *int) ([]*entities.APIKey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *int) []*entities.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, userID, keyID
+func (_m *APIKeyService) RevokeAPIKey(ctx context.Context, userID *int, keyID int) error {
+	ret := _m.Called(ctx, userID, keyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *int, int) error); ok {
+		r0 = rf(ctx, userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAPIKeyService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *APIKeyService {
+	mock := &APIKeyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	entities "referral-system/internal/entities"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,9 +14,37 @@ type AuthService struct {
 	mock.Mock
 }
 
-// LoginUser provides a mock function with given fields: email, password
-func (_m *AuthService) LoginUser(email string, password string) (*entities.User, string, error) {
-	ret := _m.Called(email, password)
+// ChangePassword provides a mock function with given fields: ctx, userID, currentPassword, newPassword
+func (_m *AuthService) ChangePassword(ctx context.Context, userID int, currentPassword string, newPassword string) (string, error) {
+	ret := _m.Called(ctx, userID, currentPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) (string, error)); ok {
+		return rf(ctx, userID, currentPassword, newPassword)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) string); ok {
+		r0 = rf(ctx, userID, currentPassword, newPassword)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, string) error); ok {
+		r1 = rf(ctx, userID, currentPassword, newPassword)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginUser provides a mock function with given fields: ctx, email, password, ip
+func (_m *AuthService) LoginUser(ctx context.Context, email string, password string, ip string) (*entities.User, string, error) {
+	ret := _m.Called(ctx, email, password, ip)
 
 	if len(ret) == 0 {
 		panic("no return value specified for LoginUser")
@@ -24,25 +53,25 @@ func (_m *AuthService) LoginUser(email string, password string) (*entities.User,
 	var r0 *entities.User
 	var r1 string
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string) (*entities.User, string, error)); ok {
-		return rf(email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*entities.User, string, error)); ok {
+		return rf(ctx, email, password, ip)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *entities.User); ok {
-		r0 = rf(email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *entities.User); ok {
+		r0 = rf(ctx, email, password, ip)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) string); ok {
-		r1 = rf(email, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) string); ok {
+		r1 = rf(ctx, email, password, ip)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(email, password)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, email, password, ip)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -50,9 +79,9 @@ func (_m *AuthService) LoginUser(email string, password string) (*entities.User,
 	return r0, r1, r2
 }
 
-// RegisterUser provides a mock function with given fields: name, email, password
-func (_m *AuthService) RegisterUser(name string, email string, password string) (*entities.User, error) {
-	ret := _m.Called(name, email, password)
+// RegisterUser provides a mock function with given fields: ctx, name, email, password
+func (_m *AuthService) RegisterUser(ctx context.Context, name string, email string, password string) (*entities.User, error) {
+	ret := _m.Called(ctx, name, email, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterUser")
@@ -60,19 +89,93 @@ func (_m *AuthService) RegisterUser(name string, email string, password string)
 
 	var r0 *entities.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, string) (*entities.User, error)); ok {
-		return rf(name, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*entities.User, error)); ok {
+		return rf(ctx, name, email, password)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string) *entities.User); ok {
-		r0 = rf(name, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *entities.User); ok {
+		r0 = rf(ctx, name, email, password)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
-		r1 = rf(name, email, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, name, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartSession provides a mock function with given fields: user
+func (_m *AuthService) StartSession(user *entities.User) (string, error) {
+	ret := _m.Called(user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartSession")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*entities.User) (string, error)); ok {
+		return rf(user)
+	}
+	if rf, ok := ret.Get(0).(func(*entities.User) string); ok {
+		r0 = rf(user)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*entities.User) error); ok {
+		r1 = rf(user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlockUser provides a mock function with given fields: ctx, userID
+func (_m *AuthService) UnlockUser(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateSession provides a mock function with given fields: ctx, userID, tokenVersion
+func (_m *AuthService) ValidateSession(ctx context.Context, userID int, tokenVersion int) (string, error) {
+	ret := _m.Called(ctx, userID, tokenVersion)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateSession")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (string, error)); ok {
+		return rf(ctx, userID, tokenVersion)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) string); ok {
+		r0 = rf(ctx, userID, tokenVersion)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, tokenVersion)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -80,6 +183,43 @@ func (_m *AuthService) RegisterUser(name string, email string, password string)
 	return r0, r1
 }
 
+// VerifyMFA provides a mock function with given fields: ctx, mfaToken, code, ip
+func (_m *AuthService) VerifyMFA(ctx context.Context, mfaToken string, code string, ip string) (*entities.User, string, error) {
+	ret := _m.Called(ctx, mfaToken, code, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMFA")
+	}
+
+	var r0 *entities.User
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*entities.User, string, error)); ok {
+		return rf(ctx, mfaToken, code, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *entities.User); ok {
+		r0 = rf(ctx, mfaToken, code, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) string); ok {
+		r1 = rf(ctx, mfaToken, code, ip)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, mfaToken, code, ip)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // NewAuthService creates a new instance of AuthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAuthService(t interface {
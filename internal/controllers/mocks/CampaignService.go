@@ -0,0 +1,173 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CampaignService is an autogenerated mock type for the CampaignService type
+type CampaignService struct {
+	mock.Mock
+}
+
+// CreateCampaign provides a mock function with given fields: ctx, campaign
+func (_m *CampaignService) CreateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	ret := _m.Called(ctx, campaign)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCampaign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Campaign) error); ok {
+		r0 = rf(ctx, campaign)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCampaign provides a mock function with given fields: ctx, id
+func (_m *CampaignService) DeleteCampaign(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCampaign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCampaign provides a mock function with given fields: ctx, id
+func (_m *CampaignService) GetCampaign(ctx context.Context, id int) (*entities.Campaign, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCampaign")
+	}
+
+	var r0 *entities.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.Campaign, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.Campaign); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.Campaign)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCampaignStats provides a mock function with given fields: ctx, id
+func (_m *CampaignService) GetCampaignStats(ctx context.Context, id int) (*entities.CampaignStats, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCampaignStats")
+	}
+
+	var r0 *entities.CampaignStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.CampaignStats, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.CampaignStats); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CampaignStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCampaigns provides a mock function with given fields: ctx
+func (_m *CampaignService) ListCampaigns(ctx context.Context) ([]*entities.Campaign, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCampaigns")
+	}
+
+	var r0 []*entities.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*entities.Campaign, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*entities.Campaign); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.Campaign)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCampaign provides a mock function with given fields: ctx, campaign
+func (_m *CampaignService) UpdateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	ret := _m.Called(ctx, campaign)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCampaign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Campaign) error); ok {
+		r0 = rf(ctx, campaign)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCampaignService creates a new instance of CampaignService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCampaignService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CampaignService {
+	mock := &CampaignService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
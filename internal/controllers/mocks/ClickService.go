@@ -0,0 +1,47 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ClickService is an autogenerated mock type for the ClickService type
+type ClickService struct {
+	mock.Mock
+}
+
+// TrackClick provides a mock function with given fields: ctx, referralCode, click, ip
+func (_m *ClickService) TrackClick(ctx context.Context, referralCode string, click *entities.ReferralClick, ip string) error {
+	ret := _m.Called(ctx, referralCode, click, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TrackClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *entities.ReferralClick, string) error); ok {
+		r0 = rf(ctx, referralCode, click, ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClickService creates a new instance of ClickService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickService {
+	mock := &ClickService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
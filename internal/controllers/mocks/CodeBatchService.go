@@ -0,0 +1,137 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CodeBatchService is an autogenerated mock type for the CodeBatchService type
+type CodeBatchService struct {
+	mock.Mock
+}
+
+// CreateBatch provides a mock function with given fields: ctx, campaignID, partnerID, size, createdBy
+func (_m *CodeBatchService) CreateBatch(ctx context.Context, campaignID int, partnerID int, size int, createdBy *int) (*entities.CodeBatch, error) {
+	ret := _m.Called(ctx, campaignID, partnerID, size, createdBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBatch")
+	}
+
+	var r0 *entities.CodeBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, *int) (*entities.CodeBatch, error)); ok {
+		return rf(ctx, campaignID, partnerID, size, createdBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, *int) *entities.CodeBatch); ok {
+		r0 = rf(ctx, campaignID, partnerID, size, createdBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CodeBatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, *int) error); ok {
+		r1 = rf(ctx, campaignID, partnerID, size, createdBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBatch provides a mock function with given fields: ctx, id
+func (_m *CodeBatchService) GetBatch(ctx context.Context, id int) (*entities.CodeBatch, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBatch")
+	}
+
+	var r0 *entities.CodeBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.CodeBatch, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.CodeBatch); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CodeBatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBatches provides a mock function with given fields: ctx, campaignID
+func (_m *CodeBatchService) ListBatches(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error) {
+	ret := _m.Called(ctx, campaignID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBatches")
+	}
+
+	var r0 []*entities.CodeBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.CodeBatch, error)); ok {
+		return rf(ctx, campaignID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.CodeBatch); ok {
+		r0 = rf(ctx, campaignID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.CodeBatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, campaignID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamBatchCodes provides a mock function with given fields: ctx, id, fn
+func (_m *CodeBatchService) StreamBatchCodes(ctx context.Context, id int, fn func(*entities.ReferralCode) error) error {
+	ret := _m.Called(ctx, id, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamBatchCodes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, func(*entities.ReferralCode) error) error); ok {
+		r0 = rf(ctx, id, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCodeBatchService creates a new instance of CodeBatchService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCodeBatchService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CodeBatchService {
+	mock := &CodeBatchService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// LeaderboardService is an autogenerated mock type for the LeaderboardService type
+type LeaderboardService struct {
+	mock.Mock
+}
+
+// GetLeaderboard provides a mock function with given fields: ctx, period, limit, userID
+func (_m *LeaderboardService) GetLeaderboard(ctx context.Context, period string, limit int, userID int) (*entities.Leaderboard, error) {
+	ret := _m.Called(ctx, period, limit, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLeaderboard")
+	}
+
+	var r0 *entities.Leaderboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) (*entities.Leaderboard, error)); ok {
+		return rf(ctx, period, limit, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) *entities.Leaderboard); ok {
+		r0 = rf(ctx, period, limit, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.Leaderboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, period, limit, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefreshLeaderboard provides a mock function with given fields: ctx
+func (_m *LeaderboardService) RefreshLeaderboard(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshLeaderboard")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLeaderboardService creates a new instance of LeaderboardService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLeaderboardService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LeaderboardService {
+	mock := &LeaderboardService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
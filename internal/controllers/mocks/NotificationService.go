@@ -0,0 +1,161 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NotificationService is an autogenerated mock type for the NotificationService type
+type NotificationService struct {
+	mock.Mock
+}
+
+// GetPreferences provides a mock function with given fields: ctx, userID
+func (_m *NotificationService) GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPreferences")
+	}
+
+	var r0 entities.NotificationPreferences
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entities.NotificationPreferences, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entities.NotificationPreferences); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.NotificationPreferences)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HandleEvent provides a mock function with given fields: ctx, event
+func (_m *NotificationService) HandleEvent(ctx context.Context, event *entities.Event) error {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandleEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Event) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendEmailVerification provides a mock function with given fields: ctx, user, email, token
+func (_m *NotificationService) SendEmailVerification(ctx context.Context, user *entities.User, email string, token string) error {
+	ret := _m.Called(ctx, user, email, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendEmailVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.User, string, string) error); ok {
+		r0 = rf(ctx, user, email, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendReferralCodeExpiring provides a mock function with given fields: ctx, code
+func (_m *NotificationService) SendReferralCodeExpiring(ctx context.Context, code *entities.ExpiringReferralCode) error {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendReferralCodeExpiring")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.ExpiringReferralCode) error); ok {
+		r0 = rf(ctx, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unsubscribe provides a mock function with given fields: ctx, token
+func (_m *NotificationService) Unsubscribe(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unsubscribe")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePreferences provides a mock function with given fields: ctx, userID, preferences
+func (_m *NotificationService) UpdatePreferences(ctx context.Context, userID int, preferences entities.NotificationPreferences) (entities.NotificationPreferences, error) {
+	ret := _m.Called(ctx, userID, preferences)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePreferences")
+	}
+
+	var r0 entities.NotificationPreferences
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, entities.NotificationPreferences) (entities.NotificationPreferences, error)); ok {
+		return rf(ctx, userID, preferences)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, entities.NotificationPreferences) entities.NotificationPreferences); ok {
+		r0 = rf(ctx, userID, preferences)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.NotificationPreferences)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, entities.NotificationPreferences) error); ok {
+		r1 = rf(ctx, userID, preferences)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewNotificationService creates a new instance of NotificationService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewNotificationService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *NotificationService {
+	mock := &NotificationService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
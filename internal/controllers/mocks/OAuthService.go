@@ -0,0 +1,122 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OAuthService is an autogenerated mock type for the OAuthService type
+type OAuthService struct {
+	mock.Mock
+}
+
+// AuthorizationURL provides a mock function with given fields: ctx, provider, referralCode
+func (_m *OAuthService) AuthorizationURL(ctx context.Context, provider string, referralCode string) (string, error) {
+	ret := _m.Called(ctx, provider, referralCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthorizationURL")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, provider, referralCode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, provider, referralCode)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, provider, referralCode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Callback provides a mock function with given fields: ctx, provider, state, code
+func (_m *OAuthService) Callback(ctx context.Context, provider string, state string, code string) (*entities.User, string, error) {
+	ret := _m.Called(ctx, provider, state, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Callback")
+	}
+
+	var r0 *entities.User
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*entities.User, string, error)); ok {
+		return rf(ctx, provider, state, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *entities.User); ok {
+		r0 = rf(ctx, provider, state, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) string); ok {
+		r1 = rf(ctx, provider, state, code)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, provider, state, code)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// LinkURL provides a mock function with given fields: ctx, provider, userID
+func (_m *OAuthService) LinkURL(ctx context.Context, provider string, userID int) (string, error) {
+	ret := _m.Called(ctx, provider, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkURL")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (string, error)); ok {
+		return rf(ctx, provider, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) string); ok {
+		r0 = rf(ctx, provider, userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, provider, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOAuthService creates a new instance of OAuthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOAuthService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OAuthService {
+	mock := &OAuthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	entities "referral-system/internal/entities"
 
 	mock "github.com/stretchr/testify/mock"
@@ -15,9 +16,9 @@ type ReferralService struct {
 	mock.Mock
 }
 
-// CreateReferralCode provides a mock function with given fields: userID, expiresIn
-func (_m *ReferralService) CreateReferralCode(userID int, expiresIn time.Duration) (*entities.ReferralCode, error) {
-	ret := _m.Called(userID, expiresIn)
+// CreateReferralCode provides a mock function with given fields: ctx, userID, expiresIn, campaignID
+func (_m *ReferralService) CreateReferralCode(ctx context.Context, userID int, expiresIn time.Duration, campaignID *int) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, userID, expiresIn, campaignID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateReferralCode")
@@ -25,19 +26,19 @@ func (_m *ReferralService) CreateReferralCode(userID int, expiresIn time.Duratio
 
 	var r0 *entities.ReferralCode
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int, time.Duration) (*entities.ReferralCode, error)); ok {
-		return rf(userID, expiresIn)
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration, *int) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, userID, expiresIn, campaignID)
 	}
-	if rf, ok := ret.Get(0).(func(int, time.Duration) *entities.ReferralCode); ok {
-		r0 = rf(userID, expiresIn)
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration, *int) *entities.ReferralCode); ok {
+		r0 = rf(ctx, userID, expiresIn, campaignID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.ReferralCode)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
-		r1 = rf(userID, expiresIn)
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration, *int) error); ok {
+		r1 = rf(ctx, userID, expiresIn, campaignID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -45,17 +46,35 @@ func (_m *ReferralService) CreateReferralCode(userID int, expiresIn time.Duratio
 	return r0, r1
 }
 
-// DeleteReferralCode provides a mock function with given fields: userID
-func (_m *ReferralService) DeleteReferralCode(userID int) error {
-	ret := _m.Called(userID)
+// CreditReferral provides a mock function with given fields: ctx, referralCode, refereeID
+func (_m *ReferralService) CreditReferral(ctx context.Context, referralCode string, refereeID int) error {
+	ret := _m.Called(ctx, referralCode, refereeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreditReferral")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, referralCode, refereeID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteReferralCode provides a mock function with given fields: ctx, userID
+func (_m *ReferralService) DeleteReferralCode(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteReferralCode")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -63,9 +82,9 @@ func (_m *ReferralService) DeleteReferralCode(userID int) error {
 	return r0
 }
 
-// GetReferralCodeByUserID provides a mock function with given fields: userID
-func (_m *ReferralService) GetReferralCodeByUserID(userID int) (*entities.ReferralCode, error) {
-	ret := _m.Called(userID)
+// GetReferralCodeByUserID provides a mock function with given fields: ctx, userID
+func (_m *ReferralService) GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetReferralCodeByUserID")
@@ -73,19 +92,19 @@ func (_m *ReferralService) GetReferralCodeByUserID(userID int) (*entities.Referr
 
 	var r0 *entities.ReferralCode
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*entities.ReferralCode, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int) *entities.ReferralCode); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.ReferralCode); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.ReferralCode)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -93,9 +112,9 @@ func (_m *ReferralService) GetReferralCodeByUserID(userID int) (*entities.Referr
 	return r0, r1
 }
 
-// GetReferralsByReferrerID provides a mock function with given fields: referrerID
-func (_m *ReferralService) GetReferralsByReferrerID(referrerID int) ([]*entities.Referral, error) {
-	ret := _m.Called(referrerID)
+// GetReferralsByReferrerID provides a mock function with given fields: ctx, referrerID
+func (_m *ReferralService) GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error) {
+	ret := _m.Called(ctx, referrerID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetReferralsByReferrerID")
@@ -103,19 +122,49 @@ func (_m *ReferralService) GetReferralsByReferrerID(referrerID int) ([]*entities
 
 	var r0 []*entities.Referral
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) ([]*entities.Referral, error)); ok {
-		return rf(referrerID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.Referral, error)); ok {
+		return rf(ctx, referrerID)
 	}
-	if rf, ok := ret.Get(0).(func(int) []*entities.Referral); ok {
-		r0 = rf(referrerID)
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.Referral); ok {
+		r0 = rf(ctx, referrerID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entities.Referral)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(referrerID)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, referrerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserReferralCode provides a mock function with given fields: ctx, userID, referralCode
+func (_m *ReferralService) GetUserReferralCode(ctx context.Context, userID int, referralCode string) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, userID, referralCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserReferralCode")
+	}
+
+	var r0 *entities.ReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, userID, referralCode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *entities.ReferralCode); ok {
+		r0 = rf(ctx, userID, referralCode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.ReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, referralCode)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -123,9 +172,39 @@ func (_m *ReferralService) GetReferralsByReferrerID(referrerID int) ([]*entities
 	return r0, r1
 }
 
-// RegisterWithReferralCode provides a mock function with given fields: referralCode, name, email, password
-func (_m *ReferralService) RegisterWithReferralCode(referralCode string, name string, email string, password string) (*entities.User, error) {
-	ret := _m.Called(referralCode, name, email, password)
+// QualifyReferral provides a mock function with given fields: ctx, referralID
+func (_m *ReferralService) QualifyReferral(ctx context.Context, referralID int) (*entities.Referral, error) {
+	ret := _m.Called(ctx, referralID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QualifyReferral")
+	}
+
+	var r0 *entities.Referral
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.Referral, error)); ok {
+		return rf(ctx, referralID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.Referral); ok {
+		r0 = rf(ctx, referralID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.Referral)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, referralID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegisterWithReferralCode provides a mock function with given fields: ctx, referralCode, name, email, password
+func (_m *ReferralService) RegisterWithReferralCode(ctx context.Context, referralCode string, name string, email string, password string) (*entities.User, error) {
+	ret := _m.Called(ctx, referralCode, name, email, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterWithReferralCode")
@@ -133,19 +212,19 @@ func (_m *ReferralService) RegisterWithReferralCode(referralCode string, name st
 
 	var r0 *entities.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, string, string) (*entities.User, error)); ok {
-		return rf(referralCode, name, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*entities.User, error)); ok {
+		return rf(ctx, referralCode, name, email, password)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string, string) *entities.User); ok {
-		r0 = rf(referralCode, name, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *entities.User); ok {
+		r0 = rf(ctx, referralCode, name, email, password)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entities.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string, string) error); ok {
-		r1 = rf(referralCode, name, email, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, referralCode, name, email, password)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -153,6 +232,24 @@ func (_m *ReferralService) RegisterWithReferralCode(referralCode string, name st
 	return r0, r1
 }
 
+// ValidateReferralCode provides a mock function with given fields: ctx, referralCode
+func (_m *ReferralService) ValidateReferralCode(ctx context.Context, referralCode string) error {
+	ret := _m.Called(ctx, referralCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateReferralCode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, referralCode)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewReferralService creates a new instance of ReferralService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewReferralService(t interface {
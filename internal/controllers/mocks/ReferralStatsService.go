@@ -0,0 +1,59 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReferralStatsService is an autogenerated mock type for the ReferralStatsService type
+type ReferralStatsService struct {
+	mock.Mock
+}
+
+// GetStats provides a mock function with given fields: ctx, filter
+func (_m *ReferralStatsService) GetStats(ctx context.Context, filter entities.ReferralStatsFilter) (*entities.ReferralStats, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 *entities.ReferralStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entities.ReferralStatsFilter) (*entities.ReferralStats, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entities.ReferralStatsFilter) *entities.ReferralStats); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.ReferralStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entities.ReferralStatsFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReferralStatsService creates a new instance of ReferralStatsService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReferralStatsService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReferralStatsService {
+	mock := &ReferralStatsService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,117 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TierService is an autogenerated mock type for the TierService type
+type TierService struct {
+	mock.Mock
+}
+
+// GetTierProgress provides a mock function with given fields: ctx, userID
+func (_m *TierService) GetTierProgress(ctx context.Context, userID int) (*entities.TierProgress, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTierProgress")
+	}
+
+	var r0 *entities.TierProgress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.TierProgress, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.TierProgress); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.TierProgress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecalculateTier provides a mock function with given fields: ctx, userID
+func (_m *TierService) RecalculateTier(ctx context.Context, userID int) (*entities.TierChange, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecalculateTier")
+	}
+
+	var r0 *entities.TierChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.TierChange, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.TierChange); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.TierChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RewardMultiplier provides a mock function with given fields: ctx, userID
+func (_m *TierService) RewardMultiplier(ctx context.Context, userID int) (float64, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RewardMultiplier")
+	}
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (float64, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) float64); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTierService creates a new instance of TierService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTierService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TierService {
+	mock := &TierService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unsubscribePage подтверждает отписку. Сама отписка выполняется POST запросом, чтобы ее не
+// вызывали почтовые сканеры, открывающие все ссылки из письма
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<form method="post" action="?token={{.Token}}">
+<p>{{.Prompt}}</p>
+<button type="submit">{{.Button}}</button>
+</form>
+</body>
+</html>
+`))
+
+type NotificationController struct {
+	notificationService services.NotificationService
+	logger              *slog.Logger
+}
+
+// NewNotificationController создает новый NotificationController
+func NewNotificationController(notificationService services.NotificationService, logger *slog.Logger) *NotificationController {
+	return &NotificationController{notificationService: notificationService, logger: logger}
+}
+
+// GetPreferences godoc
+// @Summary Настройки уведомлений
+// @Description Возвращает, какие письма получает текущий пользователь
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/notifications [get]
+// @Security ApiKeyAuth
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		nc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	preferences, err := nc.notificationService.GetPreferences(c.Request.Context(), authUser.ID)
+	if err != nil {
+		nc.logger.Error("failed to get notification preferences", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"email": preferences,
+	})
+}
+
+// UpdatePreferences godoc
+// @Summary Изменение настроек уведомлений
+// @Description Включает или отключает письма переданных типов: referral_signup, reward_granted, code_expiring
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param email body map[string]bool true "Тип уведомления и признак отправки"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/notifications [patch]
+// @Security ApiKeyAuth
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	var req struct {
+		Email entities.NotificationPreferences `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		nc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		nc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	preferences, err := nc.notificationService.UpdatePreferences(c.Request.Context(), authUser.ID, req.Email)
+	if err != nil {
+		nc.logger.Error("failed to update notification preferences", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"email": preferences,
+	})
+}
+
+// UnsubscribePage godoc
+// @Summary Страница отписки
+// @Description Показывает форму подтверждения отписки по ссылке из письма
+// @Tags notifications
+// @Produce html
+// @Param token query string true "Токен из ссылки"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Router /notifications/unsubscribe [get]
+func (nc *NotificationController) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		respondMessage(c, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	locale := i18n.FromContext(c.Request.Context())
+	page := map[string]string{
+		"Locale": locale,
+		"Token":  token,
+		"Title":  i18n.T(locale, "unsubscribe_title", nil),
+		"Prompt": i18n.T(locale, "unsubscribe_prompt", nil),
+		"Button": i18n.T(locale, "unsubscribe_button", nil),
+	}
+	if err := unsubscribePage.Execute(c.Writer, page); err != nil {
+		nc.logger.Error("failed to render unsubscribe page", sl.Err(err))
+	}
+}
+
+// Unsubscribe godoc
+// @Summary Отписка от писем
+// @Description Отключает уведомления по токену из письма. Поддерживает отписку в один клик (RFC 8058)
+// @Tags notifications
+// @Produce json
+// @Param token query string true "Токен из ссылки"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /notifications/unsubscribe [post]
+func (nc *NotificationController) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		respondMessage(c, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	if err := nc.notificationService.Unsubscribe(c.Request.Context(), token); err != nil {
+		nc.logger.Warn("failed to unsubscribe", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "unsubscribed"),
+	})
+}
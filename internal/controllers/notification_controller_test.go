@@ -0,0 +1,126 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/middlewares"
+	"referral-system/internal/services"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotificationController_UpdatePreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockNotificationService := mocks.NewNotificationService(t)
+	notificationController := controllers.NewNotificationController(mockNotificationService, slogdiscard.NewDiscardLogger())
+	router.PATCH("/users/me/notifications", withUserID(1), notificationController.UpdatePreferences)
+
+	mockNotificationService.On("UpdatePreferences", mock.Anything, 1,
+		entities.NotificationPreferences{entities.NotificationRewardGranted: false}).
+		Return(entities.NotificationPreferences{
+			entities.NotificationReferralSignup: true,
+			entities.NotificationRewardGranted:  false,
+			entities.NotificationCodeExpiring:   true,
+		}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/users/me/notifications", bytes.NewBufferString(`{"email":{"reward_granted":false}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"email":{"referral_signup":true,"reward_granted":false,"code_expiring":true}}`, w.Body.String())
+}
+
+func TestNotificationController_UpdatePreferences_InvalidType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockNotificationService := mocks.NewNotificationService(t)
+	notificationController := controllers.NewNotificationController(mockNotificationService, slogdiscard.NewDiscardLogger())
+	router.PATCH("/users/me/notifications", withUserID(1), notificationController.UpdatePreferences)
+
+	mockNotificationService.On("UpdatePreferences", mock.Anything, 1, entities.NotificationPreferences{"newsletter": false}).
+		Return(nil, services.ErrInvalidNotificationType)
+
+	req, _ := http.NewRequest("PATCH", "/users/me/notifications", bytes.NewBufferString(`{"email":{"newsletter":false}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotificationController_Unsubscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockNotificationService := mocks.NewNotificationService(t)
+	notificationController := controllers.NewNotificationController(mockNotificationService, slogdiscard.NewDiscardLogger())
+	router.GET("/notifications/unsubscribe", notificationController.UnsubscribePage)
+	router.POST("/notifications/unsubscribe", notificationController.Unsubscribe)
+
+	// Переход по ссылке только показывает форму и ничего не меняет
+	req, _ := http.NewRequest("GET", "/notifications/unsubscribe?token=1.all.sig", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="?token=1.all.sig"`)
+
+	// Отписка в один клик из почтового клиента
+	mockNotificationService.On("Unsubscribe", mock.Anything, "1.all.sig").Return(nil).Once()
+	req, _ = http.NewRequest("POST", "/notifications/unsubscribe?token=1.all.sig", bytes.NewBufferString("List-Unsubscribe=One-Click"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockNotificationService.On("Unsubscribe", mock.Anything, "forged").Return(services.ErrInvalidUnsubscribeToken).Once()
+	req, _ = http.NewRequest("POST", "/notifications/unsubscribe?token=forged", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotificationController_Unsubscribe_Localized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.LocaleMiddleware())
+
+	mockNotificationService := mocks.NewNotificationService(t)
+	notificationController := controllers.NewNotificationController(mockNotificationService, slogdiscard.NewDiscardLogger())
+	router.GET("/notifications/unsubscribe", notificationController.UnsubscribePage)
+	router.POST("/notifications/unsubscribe", notificationController.Unsubscribe)
+
+	req, _ := http.NewRequest("GET", "/notifications/unsubscribe?token=1.all.sig", nil)
+	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Body.String(), `<button type="submit">Отписаться</button>`)
+
+	// Код ошибки не зависит от языка, а текст переводится
+	mockNotificationService.On("Unsubscribe", mock.Anything, "forged").Return(services.ErrInvalidUnsubscribeToken)
+	for locale, message := range map[string]string{
+		"ru": "недействительная ссылка отписки",
+		"de": "invalid unsubscribe token",
+	} {
+		req, _ = http.NewRequest("POST", "/notifications/unsubscribe?token=forged", nil)
+		req.Header.Set("Accept-Language", locale)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"`+message+`","code":"invalid_unsubscribe_token"}`, w.Body.String())
+	}
+}
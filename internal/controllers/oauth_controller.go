@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthController struct {
+	oauthService services.OAuthService
+	logger       *slog.Logger
+}
+
+// NewOAuthController создает новый OAuthController
+func NewOAuthController(oauthService services.OAuthService, logger *slog.Logger) *OAuthController {
+	return &OAuthController{oauthService: oauthService, logger: logger}
+}
+
+// Authorize godoc
+// @Summary Вход через внешнего провайдера
+// @Description Перенаправляет на страницу входа провайдера (authorization code + PKCE).
+// @Description Реферальный код сохраняется и засчитывается, если по возвращении создается новый пользователь
+// @Tags auth
+// @Param provider path string true "Имя провайдера из конфига"
+// @Param referral_code query string false "Реферальный код"
+// @Success 302
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /auth/oauth/{provider} [get]
+func (oc *OAuthController) Authorize(c *gin.Context) {
+	url, err := oc.oauthService.AuthorizationURL(c.Request.Context(), c.Param("provider"), c.Query("referral_code"))
+	if err != nil {
+		oc.logger.Error("failed to start oauth login", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// Link godoc
+// @Summary Привязка внешнего провайдера
+// @Description Возвращает адрес страницы входа провайдера. После возврата на callback аккаунт провайдера
+// @Description привязывается к текущему пользователю. Так привязывают провайдера к аккаунту с паролем
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Имя провайдера из конфига"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /users/me/identities/{provider} [post]
+// @Security ApiKeyAuth
+func (oc *OAuthController) Link(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		oc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	url, err := oc.oauthService.LinkURL(c.Request.Context(), c.Param("provider"), authUser.ID)
+	if err != nil {
+		oc.logger.Error("failed to start oauth linking", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": url})
+}
+
+// Callback godoc
+// @Summary Возврат с провайдера
+// @Description Завершает вход через провайдера: находит пользователя по привязанному аккаунту, привязывает
+// @Description аккаунт к пользователю без пароля по подтвержденному email или регистрирует нового пользователя.
+// @Description Если вход начат из профиля, аккаунт привязывается к этому пользователю. Ответ как у /auth/login
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Имя провайдера из конфига"
+// @Param state query string true "State из адреса перенаправления"
+// @Param code query string true "Код авторизации"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /auth/oauth/{provider}/callback [get]
+func (oc *OAuthController) Callback(c *gin.Context) {
+	var req struct {
+		State string `form:"state" binding:"required"`
+		Code  string `form:"code" binding:"required"`
+	}
+
+	// Провайдер сообщает об отказе пользователя параметром error
+	if providerErr := c.Query("error"); providerErr != "" {
+		oc.logger.Warn("oauth provider returned error", slog.String("error", providerErr))
+		respondError(c, services.ErrOAuthExchangeFailed)
+		return
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		oc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	user, token, err := oc.oauthService.Callback(c.Request.Context(), c.Param("provider"), req.State, req.Code)
+
+	var mfaErr *services.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaErr.Token,
+		})
+		return
+	}
+
+	if err != nil {
+		oc.logger.Error("failed to complete oauth login", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user,
+		"token": token,
+	})
+}
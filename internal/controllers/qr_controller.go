@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QRRenderer рисует QR код в заданном формате и возвращает данные с их Content-Type
+type QRRenderer interface {
+	Render(content, format string, size int) ([]byte, string, error)
+}
+
+type QRController struct {
+	referralService services.ReferralService
+	renderer        QRRenderer
+	baseURL         string
+	logger          *slog.Logger
+}
+
+// NewQRController создает новый QRController. Ссылки строятся от baseURL, а если он пуст - от адреса запроса
+func NewQRController(referralService services.ReferralService, renderer QRRenderer, baseURL string, logger *slog.Logger) *QRController {
+	return &QRController{referralService: referralService, renderer: renderer, baseURL: baseURL, logger: logger}
+}
+
+// referralLink возвращает публичную ссылку /r/{code}
+func (qc *QRController) referralLink(c *gin.Context, code string) string {
+	base := qc.baseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/r/" + url.PathEscape(code)
+}
+
+// ReferralCodeQR godoc
+// @Summary QR код реферальной ссылки
+// @Description Возвращает QR код ссылки /r/{code} для кода пользователя
+// @Tags referral
+// @Produce png
+// @Produce image/svg+xml
+// @Param code path string true "Реферальный код"
+// @Param format query string false "png (по умолчанию) или svg"
+// @Param size query int false "Сторона в пикселях, 64-1024, по умолчанию 256"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /referrals/codes/{code}/qr [get]
+// @Security ApiKeyAuth
+func (qc *QRController) ReferralCodeQR(c *gin.Context) {
+	var req struct {
+		Format string `form:"format" binding:"omitempty,oneof=png svg"`
+		Size   int    `form:"size" binding:"omitempty,min=64,max=1024"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		qc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "png"
+	}
+	if req.Size == 0 {
+		req.Size = 256
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		qc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	code, err := qc.referralService.GetUserReferralCode(c.Request.Context(), authUser.ID, c.Param("code"))
+	if err != nil {
+		qc.logger.Error("failed to get referral code", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	data, contentType, err := qc.renderer.Render(qc.referralLink(c, code.Code), req.Format, req.Size)
+	if err != nil {
+		qc.logger.Error("failed to render qr code", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	// Код и ссылка не меняются, поэтому изображение можно кешировать и сверять по ETag
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
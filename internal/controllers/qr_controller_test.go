@@ -0,0 +1,80 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeRenderer возвращает закодированную строку вместо изображения
+type fakeRenderer struct{}
+
+func (fakeRenderer) Render(content, format string, size int) ([]byte, string, error) {
+	return []byte(format + ":" + content), "image/" + format, nil
+}
+
+func newQRRouter(referralService *mocks.ReferralService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	qrController := controllers.NewQRController(referralService, fakeRenderer{}, "https://ref.example.com", slogdiscard.NewDiscardLogger())
+	router.GET("/referrals/codes/:code/qr", withUserID(1), qrController.ReferralCodeQR)
+
+	return router
+}
+
+func TestQRController_ReferralCodeQR(t *testing.T) {
+	mockReferralService := mocks.NewReferralService(t)
+	router := newQRRouter(mockReferralService)
+
+	mockReferralService.On("GetUserReferralCode", mock.Anything, 1, "REFCODE").
+		Return(&entities.ReferralCode{UserID: 1, Code: "REFCODE"}, nil)
+
+	req, _ := http.NewRequest("GET", "/referrals/codes/REFCODE/qr?format=svg", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg", w.Header().Get("Content-Type"))
+	assert.Equal(t, "svg:https://ref.example.com/r/REFCODE", w.Body.String())
+	assert.Equal(t, "private, max-age=86400", w.Header().Get("Cache-Control"))
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// Повторный запрос с тем же ETag не передает изображение
+	req, _ = http.NewRequest("GET", "/referrals/codes/REFCODE/qr?format=svg", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestQRController_ReferralCodeQR_Errors(t *testing.T) {
+	mockReferralService := mocks.NewReferralService(t)
+	router := newQRRouter(mockReferralService)
+
+	mockReferralService.On("GetUserReferralCode", mock.Anything, 1, "FOREIGN").Return(nil, services.ErrReferralCodeNotFound)
+
+	for target, status := range map[string]int{
+		"/referrals/codes/FOREIGN/qr":            http.StatusNotFound,
+		"/referrals/codes/REFCODE/qr?format=gif": http.StatusBadRequest,
+		"/referrals/codes/REFCODE/qr?size=8":     http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code, target)
+	}
+}
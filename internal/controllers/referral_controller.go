@@ -3,8 +3,10 @@ package controllers
 import (
 	"log/slog"
 	"net/http"
+	"referral-system/internal/auth"
 	"referral-system/internal/infrastructure/logger/sl"
 	"referral-system/internal/services"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,7 +29,8 @@ func NewReferralController(referralService services.ReferralService, logger *slo
 // @Accept json
 // @Produce json
 // @Param code body string true "Реферальный код"
-// @Param expires_in body int64 true "Время жизни в секундах"
+// @Param expires_in body int64 false "Время жизни в секундах, без кампании обязательно"
+// @Param campaign_id body int false "Кампания, правила которой наследует код"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
@@ -35,31 +38,32 @@ func NewReferralController(referralService services.ReferralService, logger *slo
 // @Security ApiKeyAuth
 func (rc *ReferralController) CreateReferralCode(c *gin.Context) {
 	var req struct {
-		Email     string `json:"email" binding:"required"`
-		ExpiresIn int64  `json:"expires_in" binding:"required"` // Время жизни в секундах
+		Email      string `json:"email" binding:"required,email"`
+		ExpiresIn  int64  `json:"expires_in" binding:"required_without=CampaignID,omitempty,expires_in"` // Время жизни в секундах
+		CampaignID *int   `json:"campaign_id" binding:"omitempty,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		rc.logger.Warn("failed to bind request", sl.Err(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		invalidRequest(c, err)
 		return
 	}
 
-	// Получаем user_id из контекста (передан JWT миддлварой)
-	userID, exists := c.Get("user_id")
+	// Получаем пользователя из контекста (передан JWT миддлварой)
+	authUser, exists := auth.UserFromContext(c)
 	if !exists {
 		rc.logger.Warn("Unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	expiresIn := time.Duration(req.ExpiresIn) * time.Second
 
 	// Создаем реферальный код
-	referral, err := rc.referralService.CreateReferralCode(int(userID.(float64)), expiresIn)
+	referral, err := rc.referralService.CreateReferralCode(c.Request.Context(), authUser.ID, expiresIn, req.CampaignID)
 	if err != nil {
 		rc.logger.Error("failed to create referral code", sl.Err(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -78,24 +82,24 @@ func (rc *ReferralController) CreateReferralCode(c *gin.Context) {
 // @Router /referrals [delete]
 // @Security ApiKeyAuth
 func (rc *ReferralController) DeleteReferralCode(c *gin.Context) {
-	// Получаем user_id из контекста
-	userID, exists := c.Get("user_id")
+	// Получаем пользователя из контекста
+	authUser, exists := auth.UserFromContext(c)
 	if !exists {
 		rc.logger.Warn("unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Удаляем реферальный код
-	err := rc.referralService.DeleteReferralCode(int(userID.(float64)))
+	err := rc.referralService.DeleteReferralCode(c.Request.Context(), authUser.ID)
 	if err != nil {
 		rc.logger.Error("failed to delete referral code", sl.Err(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Referral code deleted successfully",
+		"message": localize(c, "referral_code_deleted"),
 	})
 }
 
@@ -110,19 +114,19 @@ func (rc *ReferralController) DeleteReferralCode(c *gin.Context) {
 // @Router /referrals/list [get]
 // @Security ApiKeyAuth
 func (rc *ReferralController) GetReferralsByUserID(c *gin.Context) {
-	// Получаем user_id из контекста
-	userID, exists := c.Get("user_id")
+	// Получаем пользователя из контекста
+	authUser, exists := auth.UserFromContext(c)
 	if !exists {
 		rc.logger.Warn("unauthorized user")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Получаем список рефералов
-	referrals, err := rc.referralService.GetReferralsByReferrerID(int(userID.(float64)))
+	referrals, err := rc.referralService.GetReferralsByReferrerID(c.Request.Context(), authUser.ID)
 	if err != nil {
 		rc.logger.Error("failed to get referral code", sl.Err(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -130,3 +134,36 @@ func (rc *ReferralController) GetReferralsByUserID(c *gin.Context) {
 		"referrals": referrals,
 	})
 }
+
+// QualifyReferral godoc
+// @Summary Квалификация реферала
+// @Description Отмечает, что приглашенный пользователь выполнил целевое действие, например первую оплату.
+// @Description Повторный вызов не меняет время квалификации
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID связи реферера и реферала"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/referrals/{id}/qualify [post]
+// @Security ApiKeyAuth
+func (rc *ReferralController) QualifyReferral(c *gin.Context) {
+	referralID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		rc.logger.Warn("invalid referral id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_referral_id")
+		return
+	}
+
+	referral, err := rc.referralService.QualifyReferral(c.Request.Context(), referralID)
+	if err != nil {
+		rc.logger.Error("failed to qualify referral", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"referral": referral,
+	})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attributionCookie хранит код, по ссылке которого пришел посетитель, до его регистрации
+const attributionCookie = "ref_code"
+
+type ReferralLinkController struct {
+	clickService      services.ClickService
+	landingURL        *url.URL
+	attributionWindow time.Duration
+	logger            *slog.Logger
+}
+
+// NewReferralLinkController создает новый ReferralLinkController. Переходы перенаправляются на landingURL,
+// а код хранится в cookie attributionWindow
+func NewReferralLinkController(clickService services.ClickService, landingURL *url.URL, attributionWindow time.Duration,
+	logger *slog.Logger) *ReferralLinkController {
+	return &ReferralLinkController{
+		clickService:      clickService,
+		landingURL:        landingURL,
+		attributionWindow: attributionWindow,
+		logger:            logger,
+	}
+}
+
+// Redirect godoc
+// @Summary Переход по реферальной ссылке
+// @Description Учитывает переход и перенаправляет на посадочную страницу. Если код действует, он сохраняется
+// @Description в cookie и засчитывается при регистрации без введенного кода. Параметры запроса, включая UTM,
+// @Description передаются на посадочную страницу
+// @Tags referral
+// @Param code path string true "Реферальный код"
+// @Param utm_source query string false "UTM source"
+// @Param utm_medium query string false "UTM medium"
+// @Param utm_campaign query string false "UTM campaign"
+// @Success 302
+// @Router /r/{code} [get]
+func (lc *ReferralLinkController) Redirect(c *gin.Context) {
+	code := c.Param("code")
+	click := &entities.ReferralClick{
+		ReferrerURL: c.Request.Referer(),
+		UTMSource:   c.Query("utm_source"),
+		UTMMedium:   c.Query("utm_medium"),
+		UTMCampaign: c.Query("utm_campaign"),
+		UTMTerm:     c.Query("utm_term"),
+		UTMContent:  c.Query("utm_content"),
+	}
+
+	// Ссылка ведет на посадочную страницу в любом случае, даже если переход учесть не удалось
+	err := lc.clickService.TrackClick(c.Request.Context(), code, click, c.ClientIP())
+	switch {
+	case err == nil:
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(attributionCookie, code, int(lc.attributionWindow.Seconds()), "/", "", c.Request.TLS != nil, true)
+	case errorStatus(err) >= http.StatusInternalServerError:
+		lc.logger.Error("failed to track referral click", sl.Err(err))
+	default:
+		lc.logger.Warn("referral click is not attributed", sl.Err(err), slog.String("code", code))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, lc.landing(c.Request.URL.Query()))
+}
+
+// landing добавляет к посадочной странице параметры перехода, которых в ней еще нет
+func (lc *ReferralLinkController) landing(params url.Values) string {
+	target := *lc.landingURL
+	query := target.Query()
+	for key, values := range params {
+		if !query.Has(key) {
+			query[key] = values
+		}
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
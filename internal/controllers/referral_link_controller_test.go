@@ -0,0 +1,72 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newReferralLinkRouter(t *testing.T, clickService *mocks.ClickService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	landing, err := url.Parse("https://example.com/welcome?lang=ru")
+	require.NoError(t, err)
+	linkController := controllers.NewReferralLinkController(clickService, landing, time.Hour, slogdiscard.NewDiscardLogger())
+	router.GET("/r/:code", linkController.Redirect)
+
+	return router
+}
+
+func TestReferralLinkController_Redirect_SetsAttributionCookie(t *testing.T) {
+	mockClickService := mocks.NewClickService(t)
+	router := newReferralLinkRouter(t, mockClickService)
+
+	mockClickService.On("TrackClick", mock.Anything, "REFCODE", mock.MatchedBy(func(click *entities.ReferralClick) bool {
+		return click.UTMSource == "flyer" && click.ReferrerURL == "https://blog.example.com/"
+	}), mock.Anything).Return(nil)
+
+	req, _ := http.NewRequest("GET", "/r/REFCODE?utm_source=flyer", nil)
+	req.Header.Set("Referer", "https://blog.example.com/")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/welcome?lang=ru&utm_source=flyer", w.Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "ref_code", cookies[0].Name)
+	assert.Equal(t, "REFCODE", cookies[0].Value)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+}
+
+func TestReferralLinkController_Redirect_InvalidCodeStillRedirects(t *testing.T) {
+	mockClickService := mocks.NewClickService(t)
+	router := newReferralLinkRouter(t, mockClickService)
+
+	mockClickService.On("TrackClick", mock.Anything, "MISSING", mock.Anything, mock.Anything).Return(services.ErrInvalidReferralCode)
+
+	req, _ := http.NewRequest("GET", "/r/MISSING", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/welcome?lang=ru", w.Header().Get("Location"))
+	assert.Empty(t, w.Result().Cookies())
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultStatsPeriod - период статистики, если from не указан
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+type ReferralStatsController struct {
+	statsService services.ReferralStatsService
+	logger       *slog.Logger
+}
+
+// NewReferralStatsController создает новый ReferralStatsController
+func NewReferralStatsController(statsService services.ReferralStatsService, logger *slog.Logger) *ReferralStatsController {
+	return &ReferralStatsController{statsService: statsService, logger: logger}
+}
+
+// statsRequest - параметры периода. Даты from и to включаются в период целиком
+type statsRequest struct {
+	From       time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To         time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	Interval   string    `form:"interval" binding:"omitempty,oneof=day week"`
+	CampaignID *int      `form:"campaign_id" binding:"omitempty,min=1"`
+	ReferrerID *int      `form:"referrer_id" binding:"omitempty,min=1"`
+}
+
+// filter переводит параметры запроса в полуинтервал [from, to + 1 день)
+func (r *statsRequest) filter() entities.ReferralStatsFilter {
+	to := r.To
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	to = to.Add(24 * time.Hour)
+
+	from := r.From
+	if from.IsZero() {
+		from = to.Add(-defaultStatsPeriod)
+	}
+
+	return entities.ReferralStatsFilter{
+		ReferrerID: r.ReferrerID,
+		CampaignID: r.CampaignID,
+		From:       from,
+		To:         to,
+		Interval:   r.Interval,
+	}
+}
+
+func (sc *ReferralStatsController) stats(c *gin.Context, filter entities.ReferralStatsFilter) {
+	stats, err := sc.statsService.GetStats(c.Request.Context(), filter)
+	if err != nil {
+		sc.logger.Error("failed to get referral stats", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
+
+// GetMyStats godoc
+// @Summary Статистика рефералов
+// @Description Возвращает переходы, регистрации и квалифицированных рефералов пользователя,
+// @Description конверсию между ними и временной ряд по дням или неделям
+// @Tags referral
+// @Produce json
+// @Param from query string false "Начало периода, YYYY-MM-DD, по умолчанию 30 дней назад"
+// @Param to query string false "Конец периода включительно, YYYY-MM-DD, по умолчанию сегодня"
+// @Param interval query string false "day (по умолчанию) или week"
+// @Param campaign_id query int false "Только по кодам кампании"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /referrals/stats [get]
+// @Security ApiKeyAuth
+func (sc *ReferralStatsController) GetMyStats(c *gin.Context) {
+	var req statsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		sc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		sc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Пользователь видит только свою статистику
+	req.ReferrerID = &authUser.ID
+	sc.stats(c, req.filter())
+}
+
+// GetStats godoc
+// @Summary Статистика реферальной программы
+// @Description Статистика по всем реферерам или по одному из них
+// @Tags admin
+// @Produce json
+// @Param from query string false "Начало периода, YYYY-MM-DD, по умолчанию 30 дней назад"
+// @Param to query string false "Конец периода включительно, YYYY-MM-DD, по умолчанию сегодня"
+// @Param interval query string false "day (по умолчанию) или week"
+// @Param campaign_id query int false "Только по кодам кампании"
+// @Param referrer_id query int false "Только по одному рефереру"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/referrals/stats [get]
+// @Security ApiKeyAuth
+func (sc *ReferralStatsController) GetStats(c *gin.Context) {
+	var req statsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		sc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	sc.stats(c, req.filter())
+}
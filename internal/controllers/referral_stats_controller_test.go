@@ -0,0 +1,45 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReferralStatsController_GetMyStats_UsesCallerAndInclusiveRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockStatsService := mocks.NewReferralStatsService(t)
+	statsController := controllers.NewReferralStatsController(mockStatsService, slogdiscard.NewDiscardLogger())
+	router.GET("/referrals/stats", withUserID(1), statsController.GetMyStats)
+
+	mockStatsService.On("GetStats", mock.Anything, mock.MatchedBy(func(f entities.ReferralStatsFilter) bool {
+		return f.ReferrerID != nil && *f.ReferrerID == 1 && f.Interval == entities.StatsIntervalWeek &&
+			f.From.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) &&
+			f.To.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	})).Return(&entities.ReferralStats{Clicks: 4}, nil)
+
+	// referrer_id из запроса не позволяет смотреть чужую статистику
+	req, _ := http.NewRequest("GET", "/referrals/stats?from=2026-03-01&to=2026-03-31&interval=week&referrer_id=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"clicks":4`)
+
+	req, _ = http.NewRequest("GET", "/referrals/stats?interval=month", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TierController struct {
+	tierService services.TierService
+	logger      *slog.Logger
+}
+
+// NewTierController создает новый TierController
+func NewTierController(tierService services.TierService, logger *slog.Logger) *TierController {
+	return &TierController{tierService: tierService, logger: logger}
+}
+
+// GetMyTier godoc
+// @Summary Уровень реферера
+// @Description Возвращает текущий уровень, число квалифицированных рефералов, прогресс до следующего уровня
+// @Description и историю смен уровня
+// @Tags referral
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/tier [get]
+// @Security ApiKeyAuth
+func (tc *TierController) GetMyTier(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		tc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	progress, err := tc.tierService.GetTierProgress(c.Request.Context(), authUser.ID)
+	if err != nil {
+		tc.logger.Error("failed to get tier progress", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tier": progress,
+	})
+}
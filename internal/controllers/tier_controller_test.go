@@ -0,0 +1,39 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTierController_GetMyTier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockTierService := mocks.NewTierService(t)
+	tierController := controllers.NewTierController(mockTierService, slogdiscard.NewDiscardLogger())
+	router.GET("/users/me/tier", withUserID(4), tierController.GetMyTier)
+
+	mockTierService.On("GetTierProgress", mock.Anything, 4).Return(&entities.TierProgress{
+		Tier:      &entities.Tier{Name: "bronze", Threshold: 5, Multiplier: 1.1},
+		Qualified: 8,
+		NextTier:  &entities.Tier{Name: "silver", Threshold: 20, Multiplier: 1.25},
+		Remaining: 12,
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/users/me/tier", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"bronze"`)
+	assert.Contains(t, w.Body.String(), `"remaining":12`)
+}
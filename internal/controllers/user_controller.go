@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserController struct {
+	userService services.UserService
+	authService services.AuthService
+	logger      *slog.Logger
+}
+
+// NewUserController создает новый UserController
+func NewUserController(userService services.UserService, authService services.AuthService, logger *slog.Logger) *UserController {
+	return &UserController{userService: userService, authService: authService, logger: logger}
+}
+
+// GetProfile godoc
+// @Summary Профиль пользователя
+// @Description Возвращает профиль текущего пользователя
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me [get]
+// @Security ApiKeyAuth
+func (uc *UserController) GetProfile(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := uc.userService.GetUser(c.Request.Context(), authUser.ID)
+	if err != nil {
+		uc.logger.Error("failed to get user", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+// UpdateProfile godoc
+// @Summary Обновление профиля
+// @Description Меняет имя пользователя. Новый email применяется после подтверждения токеном из письма
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param name body string false "Новое имя"
+// @Param email body string false "Новый email"
+// @Param leaderboard_opt_out body bool false "Скрыть себя из рейтинга рефереров"
+// @Param locale body string false "Язык писем: en или ru"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /users/me [patch]
+// @Security ApiKeyAuth
+func (uc *UserController) UpdateProfile(c *gin.Context) {
+	var req struct {
+		Name  *string `json:"name" binding:"omitempty,username"`
+		Email *string `json:"email" binding:"omitempty,email,max=255"`
+		// LeaderboardOptOut скрывает пользователя из публичного рейтинга
+		LeaderboardOptOut *bool `json:"leaderboard_opt_out"`
+		// Locale - язык писем и уведомлений
+		Locale *string `json:"locale" binding:"omitempty,locale"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		uc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := uc.userService.UpdateProfile(c.Request.Context(), authUser.ID, req.Name, req.Email, req.LeaderboardOptOut, req.Locale)
+	if err != nil {
+		uc.logger.Error("failed to update profile", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+// ConfirmEmail godoc
+// @Summary Подтверждение нового email
+// @Description Применяет новый email по токену из письма
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body string true "Токен подтверждения"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/email/verify [post]
+// @Security ApiKeyAuth
+func (uc *UserController) ConfirmEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		uc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := uc.userService.ConfirmEmailChange(c.Request.Context(), authUser.ID, req.Token)
+	if err != nil {
+		uc.logger.Error("failed to confirm email", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+// ChangePassword godoc
+// @Summary Смена пароля
+// @Description Меняет пароль и завершает все остальные сессии пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param current_password body string false "Текущий пароль. Аккаунт без пароля задает первый пароль после свежего входа"
+// @Param new_password body string true "Новый пароль"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/password [post]
+// @Security ApiKeyAuth
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"max=72"` // пустой, если пароль задается впервые
+		NewPassword     string `json:"new_password" binding:"required,password"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		uc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	token, err := uc.authService.ChangePassword(c.Request.Context(), authUser.ID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		uc.logger.Error("failed to change password", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+	})
+}
+
+// ExportData godoc
+// @Summary Выгрузка данных пользователя
+// @Description Возвращает JSON архив с профилем, реферальными кодами и рефералами пользователя
+// @Tags users
+// @Produce json
+// @Success 200 {object} entities.UserDataExport
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me/export [get]
+// @Security ApiKeyAuth
+func (uc *UserController) ExportData(c *gin.Context) {
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	export, err := uc.userService.ExportData(c.Request.Context(), authUser.ID)
+	if err != nil {
+		uc.logger.Error("failed to export user data", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.json", export.Profile.ID, export.ExportedAt.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// DeleteAccount godoc
+// @Summary Удаление аккаунта
+// @Description Анонимизирует аккаунт пользователя. Статистика рефералов у пригласившего сохраняется
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param password body string false "Текущий пароль. Аккаунт без пароля удаляется после свежего входа"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /users/me [delete]
+// @Security ApiKeyAuth
+func (uc *UserController) DeleteAccount(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"max=72"` // пустой для аккаунта без пароля
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		uc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	authUser, exists := auth.UserFromContext(c)
+	if !exists {
+		uc.logger.Warn("unauthorized user")
+		respondMessage(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := uc.userService.DeleteAccount(c.Request.Context(), authUser.ID, req.Password); err != nil {
+		uc.logger.Error("failed to delete account", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "account_deleted"),
+	})
+}
@@ -0,0 +1,180 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/auth"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// withUserID имитирует JWT миддлвару, кладя пользователя в контекст
+func withUserID(userID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.SetUser(c, auth.User{ID: userID, Roles: []string{entities.RoleUser}})
+		c.Next()
+	}
+}
+
+func TestUserController_UpdateProfile_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockUserService := mocks.NewUserService(t)
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	userController := controllers.NewUserController(mockUserService, mockAuthService, logger)
+	router.PATCH("/users/me", withUserID(7), userController.UpdateProfile)
+
+	pendingEmail := "new@mail.com"
+	mockUserService.On("UpdateProfile", mock.Anything, 7, mock.AnythingOfType("*string"), &pendingEmail, (*bool)(nil), (*string)(nil)).
+		Return(&entities.User{ID: 7, Name: "John", Email: "old@mail.com", PendingEmail: &pendingEmail}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/users/me", strings.NewReader(`{"name": "John", "email": "new@mail.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pending_email":"new@mail.com"`)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	mockUserService.AssertExpectations(t)
+}
+
+func TestUserController_UpdateProfile_EmailTaken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockUserService := mocks.NewUserService(t)
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	userController := controllers.NewUserController(mockUserService, mockAuthService, logger)
+	router.PATCH("/users/me", withUserID(7), userController.UpdateProfile)
+
+	mockUserService.On("UpdateProfile", mock.Anything, 7, (*string)(nil), mock.AnythingOfType("*string"), (*bool)(nil), (*string)(nil)).
+		Return(nil, services.ErrUserAlreadyExists)
+
+	req, _ := http.NewRequest("PATCH", "/users/me", strings.NewReader(`{"email": "taken@mail.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockUserService.AssertExpectations(t)
+}
+
+func TestUserController_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockUserService := mocks.NewUserService(t)
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	userController := controllers.NewUserController(mockUserService, mockAuthService, logger)
+	router.POST("/users/me/password", withUserID(7), userController.ChangePassword)
+
+	mockAuthService.On("ChangePassword", mock.Anything, 7, "old_password", "new_password").
+		Return("fresh_token", nil).Once()
+	mockAuthService.On("ChangePassword", mock.Anything, 7, "wrong_password", "new_password").
+		Return("", services.ErrInvalidCredentials).Once()
+
+	req, _ := http.NewRequest("POST", "/users/me/password", strings.NewReader(`{"current_password": "old_password", "new_password": "new_password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "fresh_token")
+
+	req, _ = http.NewRequest("POST", "/users/me/password", strings.NewReader(`{"current_password": "wrong_password", "new_password": "new_password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestUserController_PasswordlessAccountConfirmsWithoutPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockUserService := mocks.NewUserService(t)
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	userController := controllers.NewUserController(mockUserService, mockAuthService, logger)
+	router.POST("/users/me/password", withUserID(7), userController.ChangePassword)
+	router.DELETE("/users/me", withUserID(7), userController.DeleteAccount)
+
+	// У аккаунта, созданного входом через провайдера, текущего пароля нет
+	mockAuthService.On("ChangePassword", mock.Anything, 7, "", "new_password").Return("fresh_token", nil).Once()
+	mockUserService.On("DeleteAccount", mock.Anything, 7, "").Return(services.ErrReauthRequired).Once()
+
+	req, _ := http.NewRequest("POST", "/users/me/password", strings.NewReader(`{"new_password": "new_password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "fresh_token")
+
+	req, _ = http.NewRequest("DELETE", "/users/me", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"reauth_required"`)
+}
+
+func TestUserController_ExportData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockUserService := mocks.NewUserService(t)
+	mockAuthService := mocks.NewAuthService(t)
+
+	logger := slogdiscard.NewDiscardLogger()
+	userController := controllers.NewUserController(mockUserService, mockAuthService, logger)
+	router.GET("/users/me/export", withUserID(7), userController.ExportData)
+
+	mockUserService.On("ExportData", mock.Anything, 7).Return(&entities.UserDataExport{
+		ExportedAt:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Profile:       &entities.User{ID: 7, Email: "example@mail.com"},
+		ReferralCodes: []*entities.ReferralCode{{ID: 1, UserID: 7, Code: "CODE"}},
+		Referrals:     []*entities.Referral{{ID: 3, ReferrerID: 7, RefereeID: 8}},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/users/me/export", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="user-7-export-20240501.json"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), `"code": "CODE"`)
+	assert.Contains(t, w.Body.String(), `"referee_id": 8`)
+
+	mockUserService.AssertExpectations(t)
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/infrastructure/logger/sl"
+	"referral-system/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookService services.WebhookService
+	logger         *slog.Logger
+}
+
+// NewWebhookController создает новый WebhookController
+func NewWebhookController(webhookService services.WebhookService, logger *slog.Logger) *WebhookController {
+	return &WebhookController{webhookService: webhookService, logger: logger}
+}
+
+// subscriptionID читает ID подписки из пути
+func (wc *WebhookController) subscriptionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		wc.logger.Warn("invalid webhook id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_webhook_id")
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateWebhook godoc
+// @Summary Создание подписки на вебхуки
+// @Description Подписывает адрес на события. Запросы подписываются заголовком Webhook-Signature
+// @Description (t=<unix>,v1=<HMAC-SHA256 от "<t>.<тело>">). Секрет возвращается только в этом ответе
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param url body string true "Адрес получателя"
+// @Param event_types body []string true "referral.created, referral.qualified, referral.rewarded"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/webhooks [post]
+// @Security ApiKeyAuth
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	var req struct {
+		URL        string   `json:"url" binding:"required,url,max=2048"`
+		EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+
+	// Сервисный ключ создает подписку без автора
+	var createdBy *int
+	if authUser, exists := auth.UserFromContext(c); exists && authUser.ID != 0 {
+		createdBy = &authUser.ID
+	}
+
+	subscription, secret, err := wc.webhookService.CreateSubscription(c.Request.Context(), req.URL, req.EventTypes, createdBy)
+	if err != nil {
+		wc.logger.Error("failed to create webhook", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": subscription,
+		"secret":  secret,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary Список подписок на вебхуки
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/webhooks [get]
+// @Security ApiKeyAuth
+func (wc *WebhookController) ListWebhooks(c *gin.Context) {
+	subscriptions, err := wc.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		wc.logger.Error("failed to list webhooks", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": subscriptions,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Удаление подписки на вебхуки
+// @Description Удаляет подписку вместе с журналом доставок
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/webhooks/{id} [delete]
+// @Security ApiKeyAuth
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	id, ok := wc.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	if err := wc.webhookService.DeleteSubscription(c.Request.Context(), id); err != nil {
+		wc.logger.Error("failed to delete webhook", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": localize(c, "webhook_deleted"),
+	})
+}
+
+// TestWebhook godoc
+// @Summary Проверочное событие
+// @Description Сразу отправляет подписке событие webhook.test и возвращает результат попытки
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/webhooks/{id}/test [post]
+// @Security ApiKeyAuth
+func (wc *WebhookController) TestWebhook(c *gin.Context) {
+	id, ok := wc.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := wc.webhookService.SendTestEvent(c.Request.Context(), id)
+	if err != nil {
+		wc.logger.Error("failed to send test webhook", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
+
+// ListDeliveries godoc
+// @Summary Журнал доставок
+// @Description Последние доставки подписки со статусом и результатом последней попытки
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param limit query int false "Сколько доставок вернуть, 1-500, по умолчанию 100"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/webhooks/{id}/deliveries [get]
+// @Security ApiKeyAuth
+func (wc *WebhookController) ListDeliveries(c *gin.Context) {
+	id, ok := wc.subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Limit int `form:"limit" binding:"omitempty,min=1,max=500"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		wc.logger.Warn("failed to bind request", sl.Err(err))
+		invalidRequest(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	deliveries, err := wc.webhookService.ListDeliveries(c.Request.Context(), id, req.Limit)
+	if err != nil {
+		wc.logger.Error("failed to list webhook deliveries", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+// ReplayDelivery godoc
+// @Summary Повторная отправка события
+// @Description Ставит в очередь новую доставку события из журнала с тем же ID события
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID доставки"
+// @Success 202 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/webhook-deliveries/{id}/replay [post]
+// @Security ApiKeyAuth
+func (wc *WebhookController) ReplayDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.logger.Warn("invalid delivery id", sl.Err(err))
+		respondMessage(c, http.StatusBadRequest, "invalid_delivery_id")
+		return
+	}
+
+	delivery, err := wc.webhookService.ReplayDelivery(c.Request.Context(), id)
+	if err != nil {
+		wc.logger.Error("failed to replay webhook delivery", sl.Err(err))
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,115 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/controllers"
+	"referral-system/internal/controllers/mocks"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/services"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookController_CreateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockWebhookService := mocks.NewWebhookService(t)
+	webhookController := controllers.NewWebhookController(mockWebhookService, slogdiscard.NewDiscardLogger())
+	router.POST("/admin/webhooks", withUserID(1), webhookController.CreateWebhook)
+
+	subscription := &entities.WebhookSubscription{ID: 5, URL: "https://example.com/hook", Secret: "whsec_abc",
+		EventTypes: []string{entities.EventReferralCreated}}
+	mockWebhookService.On("CreateSubscription", mock.Anything, "https://example.com/hook",
+		[]string{entities.EventReferralCreated}, mock.MatchedBy(func(id *int) bool { return id != nil && *id == 1 })).
+		Return(subscription, "whsec_abc", nil)
+
+	body := `{"url":"https://example.com/hook","event_types":["referral.created"]}`
+	req, _ := http.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"whsec_abc"`)
+	// Секрет не попадает в описание подписки
+	assert.Equal(t, 1, bytes.Count(w.Body.Bytes(), []byte("whsec_abc")))
+}
+
+func TestWebhookController_CreateWebhook_InvalidEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockWebhookService := mocks.NewWebhookService(t)
+	webhookController := controllers.NewWebhookController(mockWebhookService, slogdiscard.NewDiscardLogger())
+	router.POST("/admin/webhooks", withUserID(1), webhookController.CreateWebhook)
+
+	mockWebhookService.On("CreateSubscription", mock.Anything, "https://example.com/hook", []string{"user.deleted"}, mock.Anything).
+		Return(nil, "", &services.FieldError{Err: services.ErrInvalidWebhook, Field: "event_types",
+			Message: i18n.Message{Code: "webhook_unknown_event_type", Args: i18n.Args{"event_type": "user.deleted"}}})
+
+	body := `{"url":"https://example.com/hook","event_types":["user.deleted"]}`
+	req, _ := http.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	// Уточнение приходит кодом каталога и переводом, а не текстом ошибки сервиса
+	assert.JSONEq(t, `{"error":"invalid webhook subscription","code":"invalid_webhook","details":[
+		{"field":"event_types","code":"webhook_unknown_event_type","message":"unknown event type user.deleted"}]}`, w.Body.String())
+}
+
+func TestWebhookController_ListDeliveries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockWebhookService := mocks.NewWebhookService(t)
+	webhookController := controllers.NewWebhookController(mockWebhookService, slogdiscard.NewDiscardLogger())
+	router.GET("/admin/webhooks/:id/deliveries", webhookController.ListDeliveries)
+
+	status := http.StatusInternalServerError
+	mockWebhookService.On("ListDeliveries", mock.Anything, 5, 20).Return([]*entities.WebhookDelivery{
+		{ID: 9, SubscriptionID: 5, EventID: "evt_1", Status: entities.DeliveryStatusPending, Attempts: 2, ResponseStatus: &status},
+	}, nil)
+	mockWebhookService.On("ListDeliveries", mock.Anything, 6, 100).Return(nil, services.ErrWebhookNotFound)
+
+	req, _ := http.NewRequest("GET", "/admin/webhooks/5/deliveries?limit=20", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"response_status":500`)
+
+	req, _ = http.NewRequest("GET", "/admin/webhooks/6/deliveries", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWebhookController_ReplayDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockWebhookService := mocks.NewWebhookService(t)
+	webhookController := controllers.NewWebhookController(mockWebhookService, slogdiscard.NewDiscardLogger())
+	router.POST("/admin/webhook-deliveries/:id/replay", webhookController.ReplayDelivery)
+
+	mockWebhookService.On("ReplayDelivery", mock.Anything, int64(9)).
+		Return(&entities.WebhookDelivery{ID: 10, EventID: "evt_1", Status: entities.DeliveryStatusPending}, nil)
+
+	req, _ := http.NewRequest("POST", "/admin/webhook-deliveries/9/replay", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"event_id":"evt_1"`)
+}
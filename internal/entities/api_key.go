@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// APIKey - ключ для доступа к API без пароля. Ключ без UserID - сервисный
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     *int       `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // открытая часть ключа, по ней ключ можно узнать в списке
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
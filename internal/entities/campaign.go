@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+// Campaign - ограниченная по времени реферальная кампания со своими правилами
+type Campaign struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	CodeTTL        int       `json:"code_ttl"` // Время жизни кодов кампании в секундах
+	ReferrerReward int       `json:"referrer_reward"`
+	RefereeReward  int       `json:"referee_reward"`
+	MaxUsesPerCode *int      `json:"max_uses_per_code"` // nil - без ограничений
+	MaxReferrals   *int      `json:"max_referrals"`     // nil - без ограничений
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ActiveAt сообщает, идет ли кампания в момент t
+func (c *Campaign) ActiveAt(t time.Time) bool {
+	return !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
+
+// CampaignStats - статистика кампании
+type CampaignStats struct {
+	CampaignID      int `json:"campaign_id"`
+	Codes           int `json:"codes"`
+	Clicks          int `json:"clicks"` // Переходы по ссылкам /r/{code} кодов кампании
+	Referrals       int `json:"referrals"`
+	ReferrerRewards int `json:"referrer_rewards"`
+	RefereeRewards  int `json:"referee_rewards"`
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Статусы генерации пакета кодов
+const (
+	BatchStatusPending   = "pending"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+)
+
+// CodeBatch - пакет одноразовых кодов кампании, выпущенный для партнера
+type CodeBatch struct {
+	ID          int        `json:"id"`
+	CampaignID  int        `json:"campaign_id"`
+	PartnerID   int        `json:"partner_id"` // Владелец кодов, ему засчитываются регистрации
+	Size        int        `json:"size"`
+	Status      string     `json:"status"`
+	Error       *string    `json:"error,omitempty"`
+	CreatedBy   *int       `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// Типы доменных событий
+const (
+	EventReferralCreated   = "referral.created"
+	EventReferralQualified = "referral.qualified"
+	EventReferralRewarded  = "referral.rewarded"
+	EventTierChanged       = "tier.changed"
+	// EventWebhookTest отправляется только по запросу проверки подписки
+	EventWebhookTest = "webhook.test"
+)
+
+// EventTypes - события, на которые можно подписаться
+var EventTypes = []string{EventReferralCreated, EventReferralQualified, EventReferralRewarded, EventTierChanged}
+
+// Event - доменное событие. ID остается тем же при повторных доставках, по нему получатель отсеивает дубли
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
@@ -0,0 +1,33 @@
+package entities
+
+import "time"
+
+// UserIdentity - привязка аккаунта внешнего провайдера (Google, GitHub) к пользователю
+type UserIdentity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"` // ID пользователя у провайдера
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExternalIdentity - данные пользователя, полученные от провайдера после входа
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OAuthState - незавершенный вход через провайдера. Хранится до возврата пользователя на callback
+type OAuthState struct {
+	StateHash    string
+	Provider     string
+	CodeVerifier string // PKCE verifier, провайдеру передается только его хеш
+	Nonce        string
+	ReferralCode *string // код, с которым пользователь пришел на регистрацию
+	UserID       *int    // пользователь, который привязывает аккаунт из профиля; nil для входа
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+// Периоды рейтинга рефереров
+const (
+	LeaderboardWeek    = "week"
+	LeaderboardMonth   = "month"
+	LeaderboardAllTime = "all"
+)
+
+// LeaderboardEntry - место реферера в рейтинге
+type LeaderboardEntry struct {
+	Rank        int       `json:"rank"`
+	UserID      int       `json:"user_id"`
+	Name        string    `json:"name"`
+	Qualified   int       `json:"qualified"` // Квалифицированные рефералы за период
+	RefreshedAt time.Time `json:"-"`
+}
+
+// Leaderboard - верх рейтинга за период и место запросившего пользователя
+type Leaderboard struct {
+	Period      string              `json:"period"`
+	RefreshedAt *time.Time          `json:"refreshed_at"` // Время снимка, nil если в рейтинге никого нет
+	Entries     []*LeaderboardEntry `json:"entries"`
+	Me          *LeaderboardEntry   `json:"me"`        // nil, если у пользователя нет квалифицированных рефералов
+	OptedOut    bool                `json:"opted_out"` // Пользователь скрыл себя из рейтинга
+}
@@ -0,0 +1,33 @@
+package entities
+
+import "time"
+
+// Типы уведомлений. От каждого пользователь может отписаться отдельно
+const (
+	NotificationReferralSignup = "referral_signup" // по коду пользователя зарегистрировались
+	NotificationRewardGranted  = "reward_granted"  // пользователю начислена награда
+	NotificationCodeExpiring   = "code_expiring"   // срок реферального кода скоро истечет
+)
+
+// NotificationTypes - все типы уведомлений
+var NotificationTypes = []string{NotificationReferralSignup, NotificationRewardGranted, NotificationCodeExpiring}
+
+// NotificationPreferences - включено ли уведомление каждого типа. Без сохраненной настройки уведомление включено
+type NotificationPreferences map[string]bool
+
+// EmailMessage - письмо, готовое к отправке
+type EmailMessage struct {
+	To      string
+	Subject string
+	HTML    string
+	// Headers - дополнительные заголовки, например List-Unsubscribe
+	Headers map[string]string
+}
+
+// SentNotification - отметка об отправленном уведомлении, защищает от повторной отправки
+type SentNotification struct {
+	Key    string // ключ уведомления, например <ID события>:<тип>:<ID пользователя>
+	UserID int
+	Type   string
+	SentAt time.Time
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent - событие, сохраненное в одной транзакции с изменением, которое его породило.
+// Фоновая рассылка публикует его хотя бы один раз
+type OutboxEvent struct {
+	ID            int64
+	EventID       string // ID события, по нему получатели отсеивают повторы
+	EventType     string
+	Payload       json.RawMessage // событие целиком в JSON
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	PublishedAt   *time.Time
+	PublishedTo   []string   // издатели, которые уже приняли событие
+	FailedAt      *time.Time // событие не опубликовано за максимальное число попыток и больше не повторяется
+}
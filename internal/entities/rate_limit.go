@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// Ключи, по которым считаются запросы группы маршрутов
+const (
+	RateLimitKeyIP     = "ip"      // адрес клиента
+	RateLimitKeyUser   = "user"    // пользователь, для анонимных запросов - адрес
+	RateLimitKeyAPIKey = "api_key" // API ключ, для сессии - пользователь, для анонимных запросов - адрес
+)
+
+// RateLimit - маркерная корзина группы маршрутов: Burst запросов подряд, далее Requests запросов за Period
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+	Key      string
+}
+
+// RateLimitResult - состояние корзины после запроса
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // сколько запросов можно сделать сразу
+	Reset      time.Duration // через сколько корзина наполнится полностью
+	RetryAfter time.Duration // через сколько появится маркер, если запрос отклонен
+}
+
+// Interval - за сколько восполняется один маркер
+func (l RateLimit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result считает состояние корзины по задержке - сколько осталось до ее полного наполнения
+func (l RateLimit) Result(delay time.Duration, allowed bool) *RateLimitResult {
+	interval := l.Interval()
+	result := &RateLimitResult{Allowed: allowed, Reset: max(delay, 0)}
+	if allowed {
+		result.Remaining = max(int((time.Duration(l.Burst)*interval-delay)/interval), 0)
+	} else {
+		result.RetryAfter = max(delay-time.Duration(l.Burst-1)*interval, 0)
+	}
+	return result
+}
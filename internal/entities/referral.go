@@ -4,15 +4,34 @@ import "time"
 
 // ReferralCode - структура для реферального кода
 type ReferralCode struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"` // Ссылка на пользователя, который создал код
-	Code      string    `json:"code"`
-	ExpiresAt time.Time `json:"expires_at"` // Срок истечения кода
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"` // Ссылка на пользователя, который создал код
+	Code       string    `json:"code"`
+	ExpiresAt  time.Time `json:"expires_at"`  // Срок истечения кода
+	CampaignID *int      `json:"campaign_id"` // Кампания, чьи правила действуют для кода
+	MaxUses    *int      `json:"max_uses"`    // Сколько регистраций засчитывается по коду, nil - без ограничений
+	BatchID    *int      `json:"batch_id"`    // Пакет, в котором код выпущен для партнера
+}
+
+// ExpiringReferralCode - личный код, владельца которого нужно предупредить об истечении срока
+type ExpiringReferralCode struct {
+	CodeID    int
+	Code      string
+	ExpiresAt time.Time
+	UserID    int
+	Email     string
+	Name      string
 }
 
 // Referral - структура для связи между реферером и рефералом
 type Referral struct {
-	ID         int `json:"id"`
-	ReferrerID int `json:"referrer_id"` // ID реферера
-	RefereeID  int `json:"referee_id"`  // ID реферала
+	ID             int        `json:"id"`
+	ReferrerID     int        `json:"referrer_id"` // ID реферера
+	RefereeID      int        `json:"referee_id"`  // ID реферала
+	ReferralCodeID *int       `json:"referral_code_id"`
+	CampaignID     *int       `json:"campaign_id"`
+	ReferrerReward int        `json:"referrer_reward"` // Награды по правилам кампании на момент регистрации
+	RefereeReward  int        `json:"referee_reward"`
+	CreatedAt      time.Time  `json:"created_at"`
+	QualifiedAt    *time.Time `json:"qualified_at"` // Когда реферал выполнил целевое действие
 }
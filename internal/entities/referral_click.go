@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// ReferralClick - переход по реферальной ссылке /r/{code}
+type ReferralClick struct {
+	ID             int64     `json:"id"`
+	ReferralCodeID *int      `json:"referral_code_id"` // nil, если код удален или перенесен в архив
+	ReferrerID     *int      `json:"referrer_id"`      // владелец кода на момент перехода
+	CampaignID     *int      `json:"campaign_id"`
+	ReferrerURL    string    `json:"referrer_url"` // заголовок Referer перехода
+	UTMSource      string    `json:"utm_source"`
+	UTMMedium      string    `json:"utm_medium"`
+	UTMCampaign    string    `json:"utm_campaign"`
+	UTMTerm        string    `json:"utm_term"`
+	UTMContent     string    `json:"utm_content"`
+	IPHash         string    `json:"-"`
+	ClickedAt      time.Time `json:"clicked_at"`
+}
@@ -0,0 +1,39 @@
+package entities
+
+import "time"
+
+// Шаг временного ряда статистики
+const (
+	StatsIntervalDay  = "day"
+	StatsIntervalWeek = "week"
+)
+
+// ReferralStatsFilter - условия выборки статистики. Пустые ReferrerID и CampaignID означают все
+type ReferralStatsFilter struct {
+	ReferrerID *int
+	CampaignID *int
+	From       time.Time // Начало периода включительно
+	To         time.Time // Конец периода не включительно
+	Interval   string
+}
+
+// ReferralStatsBucket - показатели за один шаг временного ряда
+type ReferralStatsBucket struct {
+	Start     time.Time `json:"start"`
+	Clicks    int       `json:"clicks"`
+	Signups   int       `json:"signups"`
+	Qualified int       `json:"qualified"`
+}
+
+// ReferralStats - итоги и воронка переходы -> регистрации -> квалифицированные рефералы
+type ReferralStats struct {
+	From              time.Time              `json:"from"`
+	To                time.Time              `json:"to"`
+	Interval          string                 `json:"interval"`
+	Clicks            int                    `json:"clicks"`
+	Signups           int                    `json:"signups"`
+	Qualified         int                    `json:"qualified"`
+	SignupRate        float64                `json:"signup_rate"`        // Доля переходов, закончившихся регистрацией
+	QualificationRate float64                `json:"qualification_rate"` // Доля регистраций, ставших квалифицированными
+	Series            []*ReferralStatsBucket `json:"series"`
+}
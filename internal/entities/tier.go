@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// Tier - уровень реферера, который дается за число квалифицированных рефералов
+type Tier struct {
+	Name       string  `json:"name"`
+	Threshold  int     `json:"threshold"`  // Сколько квалифицированных рефералов нужно для уровня
+	Multiplier float64 `json:"multiplier"` // Множитель награды реферера в кампаниях
+}
+
+// TierChange - запись истории смены уровня
+type TierChange struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	FromTier  *string   `json:"from_tier"`
+	ToTier    *string   `json:"to_tier"`
+	Qualified int       `json:"qualified"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// TierProgress - текущий уровень пользователя и путь до следующего
+type TierProgress struct {
+	Tier      *Tier         `json:"tier"` // nil, пока не достигнут первый уровень
+	Qualified int           `json:"qualified"`
+	NextTier  *Tier         `json:"next_tier"` // nil на последнем уровне
+	Remaining int           `json:"remaining"` // Сколько рефералов осталось до следующего уровня
+	Progress  float64       `json:"progress"`  // Доля пути от текущего уровня до следующего
+	History   []*TierChange `json:"history"`
+}
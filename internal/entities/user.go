@@ -1,8 +1,64 @@
 package entities
 
+import "time"
+
 type User struct {
 	ID             int    `json:"id"`
 	Name           string `json:"name"`
 	Email          string `json:"email"`
-	HashedPassword string `json:"hashed_password"`
+	HashedPassword string `json:"-"`
+	Role           string `json:"role"`
+
+	// PendingEmail - новый email, ожидающий подтверждения
+	PendingEmail *string `json:"pending_email,omitempty"`
+	// EmailVerificationToken - хеш токена подтверждения нового email
+	EmailVerificationToken     *string    `json:"-"`
+	EmailVerificationExpiresAt *time.Time `json:"-"`
+	// TokenVersion увеличивается при смене пароля, отзывая ранее выданные токены
+	TokenVersion int `json:"-"`
+
+	// TOTPSecret - секрет второго фактора, задается при подключении TOTP
+	TOTPSecret  *string `json:"-"`
+	TOTPEnabled bool    `json:"totp_enabled"`
+	// TOTPLastStep - последний принятый временной шаг, защищает от повторного использования кода
+	TOTPLastStep int64 `json:"-"`
+
+	// LeaderboardOptOut скрывает пользователя из публичного рейтинга рефереров
+	LeaderboardOptOut bool `json:"leaderboard_opt_out"`
+
+	// Locale - язык писем пользователя, nil - язык по умолчанию
+	Locale *string `json:"locale"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt заполняется при анонимизации удаленного аккаунта
+	DeletedAt *time.Time `json:"-"`
+}
+
+// ProfileUpdate - поля профиля, которые пользователь меняет сам. Поля со значением nil не меняются
+type ProfileUpdate struct {
+	Name              *string
+	LeaderboardOptOut *bool
+	Locale            *string
+}
+
+// UserDataExport - выгрузка всех данных пользователя по запросу (GDPR)
+type UserDataExport struct {
+	ExportedAt    time.Time        `json:"exported_at"`
+	Profile       *User            `json:"profile"`
+	ReferralCodes []*ReferralCode  `json:"referral_codes"`
+	Referrals     []*Referral      `json:"referrals"`   // пользователи, приглашенные по коду
+	ReferredBy    *Referral        `json:"referred_by"` // по чьему коду зарегистрировался пользователь
+	Identities    []*UserIdentity  `json:"identities"`  // привязанные внешние аккаунты
+	APIKeys       []*APIKey        `json:"api_keys"`
+	Clicks        []*ReferralClick `json:"clicks"` // переходы по кодам пользователя
+	TierChanges   []*TierChange    `json:"tier_changes"`
+	// NotificationPreferences - сохраненные настройки уведомлений. Типов без настройки нет, они включены
+	NotificationPreferences NotificationPreferences `json:"notification_preferences"`
+}
+
+// TOTPEnrollment - данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
 }
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed" // попытки исчерпаны
+)
+
+// WebhookSubscription - адрес, на который отправляются события выбранных типов
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"` // ключ HMAC подписи, показывается только при создании
+	EventTypes []string  `json:"event_types"`
+	CreatedBy  *int      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery - отправка одного события одной подписке со всеми попытками
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"` // HTTP статус последней попытки
+	Error          string          `json:"error"`           // ошибка последней попытки
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	ReplayOf       *int64          `json:"replay_of"` // исходная доставка, если это повторная отправка из журнала
+}
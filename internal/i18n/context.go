@@ -0,0 +1,29 @@
+package i18n
+
+import "context"
+
+type localeKey struct{}
+
+// WithLocale сохраняет язык запроса в контексте
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext возвращает язык из контекста или язык по умолчанию
+func FromContext(ctx context.Context) string {
+	if locale, ok := LocaleFromContext(ctx); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// LocaleFromContext возвращает язык, если клиент его выбрал
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok && locale != ""
+}
+
+// Translate возвращает сообщение code на языке из контекста
+func Translate(ctx context.Context, code string, args Args) string {
+	return T(FromContext(ctx), code, args)
+}
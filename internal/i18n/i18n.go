@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultLocale - язык, на котором отвечает сервис, если клиент не выбрал другой
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// Args - значения для подстановки в сообщение вместо {имя}
+type Args map[string]any
+
+// Message - сообщение каталога с аргументами, переводится на нужный язык при выводе
+type Message struct {
+	Code string
+	Args Args
+}
+
+// catalogs - сообщения по языкам, ключ - стабильный код сообщения
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]map[string]string {
+	files, err := fs.Glob(localeFS, "locales/*.json")
+	if err != nil {
+		panic(fmt.Errorf("i18n: %w", err))
+	}
+
+	result := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := localeFS.ReadFile(file)
+		if err != nil {
+			panic(fmt.Errorf("i18n: %w", err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Errorf("i18n: parse %s: %w", file, err))
+		}
+		result[strings.TrimSuffix(path.Base(file), ".json")] = messages
+	}
+
+	if _, ok := result[DefaultLocale]; !ok {
+		panic("i18n: no catalog for the default locale")
+	}
+	return result
+}
+
+// Supported возвращает языки, для которых есть каталог
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// IsSupported сообщает, есть ли каталог для языка
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// T возвращает сообщение code на языке locale. Если перевода нет, используется язык по умолчанию,
+// а неизвестный код возвращается как есть
+func T(locale, code string, args Args) string {
+	text, ok := catalogs[locale][code]
+	if !ok {
+		text, ok = catalogs[DefaultLocale][code]
+	}
+	if !ok {
+		return code
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	replacements := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// Translate переводит сообщение на язык locale
+func (m Message) Translate(locale string) string {
+	return T(locale, m.Code, m.Args)
+}
+
+// Has сообщает, есть ли в каталоге языка сообщение code
+func Has(locale, code string) bool {
+	_, ok := catalogs[locale][code]
+	return ok
+}
+
+// Codes возвращает коды сообщений каталога языка
+func Codes(locale string) []string {
+	codes := make([]string, 0, len(catalogs[locale]))
+	for code := range catalogs[locale] {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
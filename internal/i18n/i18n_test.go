@@ -0,0 +1,68 @@
+package i18n_test
+
+import (
+	"context"
+	"referral-system/internal/i18n"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogsAreComplete(t *testing.T) {
+	placeholder := regexp.MustCompile(`\{[a-z_]+\}`)
+	placeholders := func(text string) []string {
+		found := placeholder.FindAllString(text, -1)
+		slices.Sort(found)
+		return found
+	}
+
+	// В каждом каталоге те же коды и подстановки, что и в каталоге по умолчанию
+	for _, locale := range i18n.Supported() {
+		for _, code := range i18n.Codes(i18n.DefaultLocale) {
+			assert.True(t, i18n.Has(locale, code), "%s: missing %s", locale, code)
+			assert.Equal(t, placeholders(i18n.T(i18n.DefaultLocale, code, nil)), placeholders(i18n.T(locale, code, nil)),
+				"%s: placeholders of %s", locale, code)
+		}
+		assert.Len(t, i18n.Codes(locale), len(i18n.Codes(i18n.DefaultLocale)), locale)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, []string{"en", "ru"}, i18n.Supported())
+	assert.Equal(t, "пользователь не найден", i18n.T("ru", "user_not_found", nil))
+	assert.Equal(t, "must be between 60 and 120 seconds", i18n.T("en", "validation_expires_in", i18n.Args{"min": 60, "max": 120}))
+	// Неизвестный язык заменяется языком по умолчанию, неизвестный код возвращается как есть
+	assert.Equal(t, "user not found", i18n.T("de", "user_not_found", nil))
+	assert.Equal(t, "no_such_code", i18n.T("ru", "no_such_code", nil))
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		locale string
+		ok     bool
+	}{
+		{header: "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", locale: "ru", ok: true},
+		{header: "en-GB", locale: "en", ok: true},
+		{header: "de-DE, ru;q=0.5, en;q=0.8", locale: "en", ok: true},
+		{header: "fr, *;q=0.1", ok: false},
+		{header: "ru;q=0", ok: false},
+		{header: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		locale, ok := i18n.Negotiate(tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.locale, locale, tt.header)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "Unauthorized", i18n.Translate(ctx, "unauthorized", nil))
+
+	ctx = i18n.WithLocale(ctx, "ru")
+	assert.Equal(t, "Требуется авторизация", i18n.Translate(ctx, "unauthorized", nil))
+}
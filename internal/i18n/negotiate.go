@@ -0,0 +1,50 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Negotiate выбирает поддерживаемый язык по заголовку Accept-Language с учетом весов q.
+// Для ru-RU подходит каталог ru. Возвращает false, если ни один язык не подошел
+func Negotiate(header string) (string, bool) {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(name, "q") {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+
+		locale, ok := Match(tag)
+		// при равных весах выигрывает язык, указанный раньше
+		if ok && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best, best != ""
+}
+
+// Match возвращает каталог для языкового тега: сам тег или его основной язык
+func Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if IsSupported(tag) {
+		return tag, true
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if IsSupported(base) {
+		return base, true
+	}
+	return "", false
+}
@@ -0,0 +1,48 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"sync"
+)
+
+// AllEvents - подписка на события любого типа
+const AllEvents = "*"
+
+// Handler обрабатывает событие. Событие может прийти повторно, поэтому обработчик должен
+// отсеивать повторы по ID события
+type Handler func(ctx context.Context, event *entities.Event) error
+
+// MemoryBus доставляет события обработчикам в том же процессе
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewMemoryBus создает новый MemoryBus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe добавляет обработчик событий типа eventType или всех событий для AllEvents
+func (b *MemoryBus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish по очереди вызывает обработчики события. Ошибка одного обработчика не мешает остальным
+func (b *MemoryBus) Publish(ctx context.Context, event *entities.Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[event.Type]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
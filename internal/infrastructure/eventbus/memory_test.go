@@ -0,0 +1,33 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/eventbus"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBus_Publish(t *testing.T) {
+	bus := eventbus.NewMemoryBus()
+
+	var created, all []string
+	bus.Subscribe(entities.EventReferralCreated, func(_ context.Context, event *entities.Event) error {
+		created = append(created, event.ID)
+		return errors.New("handler failed")
+	})
+	bus.Subscribe(eventbus.AllEvents, func(_ context.Context, event *entities.Event) error {
+		all = append(all, event.ID)
+		return nil
+	})
+
+	ctx := context.Background()
+	err := bus.Publish(ctx, &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated})
+	assert.ErrorContains(t, err, "handler failed")
+	assert.NoError(t, bus.Publish(ctx, &entities.Event{ID: "evt_2", Type: entities.EventReferralQualified}))
+
+	assert.Equal(t, []string{"evt_1"}, created)
+	assert.Equal(t, []string{"evt_1", "evt_2"}, all)
+}
@@ -0,0 +1,305 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNATSSubjectPrefix = "referrals"
+	defaultNATSTimeout       = 5 * time.Second
+	// natsMsgIDHeader - заголовок, по которому JetStream отбрасывает повторы
+	natsMsgIDHeader = "Nats-Msg-Id"
+)
+
+// NATSPublisher публикует события в NATS JetStream по текстовому протоколу NATS.
+// Событие уходит в subject <prefix>.<тип события> с заголовком Nats-Msg-Id = ID события,
+// поэтому повторная публикация в пределах окна дедупликации стрима не создает дубль.
+// Публикация считается успешной только после подтверждения от стрима
+type NATSPublisher struct {
+	addr    string
+	user    string
+	pass    string
+	token   string
+	prefix  string
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	inbox  string
+	seq    int
+}
+
+// natsAck - ответ JetStream на публикацию
+type natsAck struct {
+	Stream    string `json:"stream"`
+	Seq       uint64 `json:"seq"`
+	Duplicate bool   `json:"duplicate"`
+	Error     *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// NewNATSPublisher создает NATSPublisher. Подключение устанавливается при первой публикации
+func NewNATSPublisher(cfg config.NATS) (*NATSPublisher, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid nats url %q", cfg.URL)
+	}
+
+	p := &NATSPublisher{
+		addr:    u.Host,
+		prefix:  strings.TrimSuffix(cfg.SubjectPrefix, "."),
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+	}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		// Пользователь без пароля в адресе - это токен
+		if pass, ok := u.User.Password(); ok {
+			p.user, p.pass = u.User.Username(), pass
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+	if p.prefix == "" {
+		p.prefix = defaultNATSSubjectPrefix
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultNATSTimeout
+	}
+
+	return p, nil
+}
+
+// Publish отправляет событие и ждет подтверждения от стрима.
+// После любой ошибки соединение закрывается и открывается заново при следующей публикации
+func (p *NATSPublisher) Publish(ctx context.Context, event *entities.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return fmt.Errorf("nats: connect: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(p.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		p.close()
+		return err
+	}
+
+	if err := p.publish(p.prefix+"."+event.Type, event.ID, payload); err != nil {
+		p.close()
+		return fmt.Errorf("nats: publish %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Close закрывает соединение
+func (p *NATSPublisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.close()
+}
+
+func (p *NATSPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+	}
+}
+
+// connect подключается к серверу, представляется и подписывается на ответы JetStream
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(p.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	reader := bufio.NewReader(conn)
+
+	line, err := readLine(reader)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	var info struct {
+		Headers bool `json:"headers"`
+	}
+	if !strings.HasPrefix(line, "INFO ") || json.Unmarshal([]byte(line[len("INFO "):]), &info) != nil {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+	if !info.Headers {
+		conn.Close()
+		return errors.New("server does not support headers")
+	}
+
+	options, err := json.Marshal(map[string]any{
+		"verbose": false, "pedantic": false, "headers": true, "no_responders": true,
+		"name": "referral-system", "lang": "go", "protocol": 1,
+		"user": p.user, "pass": p.pass, "auth_token": p.token,
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		conn.Close()
+		return err
+	}
+	inbox := "_INBOX." + hex.EncodeToString(buf)
+
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nSUB %s.* 1\r\nPING\r\n", options, inbox); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// PONG подтверждает, что CONNECT и SUB приняты
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return errors.New(line)
+		}
+		if line == "PONG" {
+			break
+		}
+	}
+
+	p.conn, p.reader, p.inbox = conn, reader, inbox
+	return nil
+}
+
+// publish отправляет HPUB с адресом для ответа и читает ответ стрима
+func (p *NATSPublisher) publish(subject, msgID string, payload []byte) error {
+	p.seq++
+	replyTo := p.inbox + "." + strconv.Itoa(p.seq)
+	headers := "NATS/1.0\r\n" + natsMsgIDHeader + ": " + msgID + "\r\n\r\n"
+
+	_, err := fmt.Fprintf(p.conn, "HPUB %s %s %d %d\r\n%s%s\r\n",
+		subject, replyTo, len(headers), len(headers)+len(payload), headers, payload)
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := readLine(p.reader)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PING":
+			if _, err := io.WriteString(p.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(line)
+		case strings.HasPrefix(line, "MSG "), strings.HasPrefix(line, "HMSG "):
+			msgSubject, header, body, err := p.readMessage(line)
+			if err != nil {
+				return err
+			}
+			if msgSubject != replyTo {
+				// Ответ на публикацию, которую уже перестали ждать
+				continue
+			}
+			return parseAck(header, body)
+		}
+	}
+}
+
+// readMessage дочитывает MSG или HMSG после строки протокола и возвращает subject, заголовки и тело
+func (p *NATSPublisher) readMessage(line string) (string, string, []byte, error) {
+	fields := strings.Fields(line)
+	withHeaders := fields[0] == "HMSG"
+
+	// MSG <subject> <sid> [reply-to] <размер>, HMSG <subject> <sid> [reply-to] <размер заголовков> <размер>
+	minFields := 4
+	if withHeaders {
+		minFields = 5
+	}
+	if len(fields) < minFields || len(fields) > minFields+1 {
+		return "", "", nil, fmt.Errorf("malformed message %q", line)
+	}
+
+	total, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return "", "", nil, fmt.Errorf("malformed message %q", line)
+	}
+	headerLen := 0
+	if withHeaders {
+		if headerLen, err = strconv.Atoi(fields[len(fields)-2]); err != nil || headerLen > total {
+			return "", "", nil, fmt.Errorf("malformed message %q", line)
+		}
+	}
+
+	data := make([]byte, total+2)
+	if _, err := io.ReadFull(p.reader, data); err != nil {
+		return "", "", nil, err
+	}
+	return fields[1], string(data[:headerLen]), data[headerLen:total], nil
+}
+
+// parseAck проверяет ответ стрима. Ответ 503 без тела означает, что ни один стрим не слушает subject
+func parseAck(header string, body []byte) error {
+	if status, _, _ := strings.Cut(header, "\r\n"); strings.HasPrefix(status, "NATS/1.0 503") {
+		return errors.New("no stream is listening on the subject")
+	}
+
+	var ack natsAck
+	if err := json.Unmarshal(body, &ack); err != nil {
+		return fmt.Errorf("unexpected ack %q", body)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("jetstream error %d: %s", ack.Error.Code, ack.Error.Description)
+	}
+	return nil
+}
+
+// readLine читает строку протокола без \r\n
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
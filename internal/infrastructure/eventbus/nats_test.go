@@ -0,0 +1,148 @@
+package eventbus_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/eventbus"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// natsMessage - сообщение, принятое заглушкой сервера
+type natsMessage struct {
+	subject string
+	headers string
+	payload string
+}
+
+// stubNATS - заглушка сервера NATS с JetStream: принимает HPUB и отвечает на адрес reply
+// результатом ack, а на пустой результат - как сервер без стрима на subject
+type stubNATS struct {
+	listener net.Listener
+	ack      func(msg natsMessage) string
+	received chan natsMessage
+	conns    chan struct{}
+}
+
+func newStubNATS(t *testing.T, ack func(msg natsMessage) string) *stubNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	s := &stubNATS{listener: listener, ack: ack, received: make(chan natsMessage, 10), conns: make(chan struct{}, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- struct{}{}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *stubNATS) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *stubNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "INFO {\"server_id\":\"stub\",\"headers\":true}\r\n")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "HPUB":
+			// HPUB <subject> <reply> <размер заголовков> <размер>
+			headerLen, _ := strconv.Atoi(fields[3])
+			total, _ := strconv.Atoi(fields[4])
+			data := make([]byte, total+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			msg := natsMessage{subject: fields[1], headers: string(data[:headerLen]), payload: string(data[headerLen:total])}
+			s.received <- msg
+
+			if reply := s.ack(msg); reply != "" {
+				fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(reply), reply)
+			} else {
+				fmt.Fprintf(conn, "HMSG %s 1 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", fields[2])
+			}
+		}
+	}
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	server := newStubNATS(t, func(natsMessage) string { return `{"stream":"REFERRALS","seq":1}` })
+	publisher, err := eventbus.NewNATSPublisher(config.NATS{URL: server.url(), SubjectPrefix: "app"})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	ctx := context.Background()
+	event := &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated, Data: map[string]int{"id": 5}}
+	require.NoError(t, publisher.Publish(ctx, event))
+	require.NoError(t, publisher.Publish(ctx, &entities.Event{ID: "evt_2", Type: entities.EventReferralQualified}))
+
+	msg := <-server.received
+	assert.Equal(t, "app.referral.created", msg.subject)
+	assert.Contains(t, msg.headers, "Nats-Msg-Id: evt_1\r\n")
+	assert.Contains(t, msg.payload, `"data":{"id":5}`)
+
+	msg = <-server.received
+	assert.Equal(t, "app.referral.qualified", msg.subject)
+	// Публикации используют одно соединение
+	assert.Len(t, server.conns, 1)
+}
+
+func TestNATSPublisher_Publish_Errors(t *testing.T) {
+	server := newStubNATS(t, func(msg natsMessage) string {
+		switch {
+		case strings.Contains(msg.headers, "evt_err"):
+			return `{"error":{"code":400,"description":"bad request"}}`
+		case strings.Contains(msg.headers, "evt_none"):
+			return ""
+		}
+		return `{"stream":"REFERRALS","seq":2,"duplicate":true}`
+	})
+	publisher, err := eventbus.NewNATSPublisher(config.NATS{URL: server.url()})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	ctx := context.Background()
+	err = publisher.Publish(ctx, &entities.Event{ID: "evt_err", Type: entities.EventReferralCreated})
+	assert.ErrorContains(t, err, "bad request")
+
+	err = publisher.Publish(ctx, &entities.Event{ID: "evt_none", Type: entities.EventReferralCreated})
+	assert.ErrorContains(t, err, "no stream")
+
+	// После ошибки публикация идет через новое соединение, повтор считается успехом
+	require.NoError(t, publisher.Publish(ctx, &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated}))
+	assert.Len(t, server.conns, 3)
+}
+
+func TestNewNATSPublisher_InvalidURL(t *testing.T) {
+	_, err := eventbus.NewNATSPublisher(config.NATS{URL: "http://localhost:4222"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,249 @@
+// Package jwtkeys хранит ключи для асимметричной подписи JWT: выбирает ключ подписи
+// по расписанию ротации, проверяет токены по kid и публикует открытые ключи в формате JWKS
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"referral-system/internal/config"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown or retired signing key")
+)
+
+// Key - ключ подписи. У ключей только для проверки Private равен nil
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	Private    crypto.Signer
+	Public     crypto.PublicKey
+	ActiveFrom time.Time
+	RetireAt   time.Time // нулевое значение - ключ не выводится из оборота
+}
+
+// retired сообщает, что ключ больше не принимается для проверки
+func (k *Key) retired(at time.Time) bool {
+	return !k.RetireAt.IsZero() && !at.Before(k.RetireAt)
+}
+
+// KeySet - набор ключей с расписанием ротации
+type KeySet struct {
+	keys []*Key
+}
+
+// NewKeySet создает набор из готовых ключей
+func NewKeySet(keys ...*Key) (*KeySet, error) {
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("jwt key without kid")
+		}
+		if _, ok := seen[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key kid %q", key.ID)
+		}
+		seen[key.ID] = struct{}{}
+	}
+
+	// Сортируем по началу действия, чтобы новый ключ подписи находился последним
+	sorted := append([]*Key(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActiveFrom.Before(sorted[j].ActiveFrom)
+	})
+
+	return &KeySet{keys: sorted}, nil
+}
+
+// Load читает ключи из PEM файлов, перечисленных в конфиге
+func Load(cfg config.JWTConfig) (*KeySet, error) {
+	keys := make([]*Key, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		key, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyCfg.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys...)
+}
+
+// Generate создает набор из одного случайного Ed25519 ключа. Токены, подписанные им,
+// перестают проверяться после перезапуска, поэтому он подходит только для разработки и тестов
+func Generate(kid string) (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySet(&Key{ID: kid, Method: jwt.SigningMethodEdDSA, Private: private, Public: public})
+}
+
+func loadKey(cfg config.JWTKey) (*Key, error) {
+	key := &Key{ID: cfg.ID}
+
+	var err error
+	if cfg.ActiveFrom != "" {
+		if key.ActiveFrom, err = time.Parse(time.RFC3339, cfg.ActiveFrom); err != nil {
+			return nil, fmt.Errorf("invalid active_from: %w", err)
+		}
+	}
+	if cfg.RetireAt != "" {
+		if key.RetireAt, err = time.Parse(time.RFC3339, cfg.RetireAt); err != nil {
+			return nil, fmt.Errorf("invalid retire_at: %w", err)
+		}
+	}
+
+	switch {
+	case cfg.PrivateKeyPath != "":
+		if key.Private, err = readPrivateKey(cfg.PrivateKeyPath); err != nil {
+			return nil, err
+		}
+		key.Public = key.Private.Public()
+	case cfg.PublicKeyPath != "":
+		if key.Public, err = readPublicKey(cfg.PublicKeyPath); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("either private_key or public_key must be set")
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", key.Public)
+	}
+
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type %T", path, parsed)
+	}
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// SigningKeyAt возвращает ключ, которым подписываются токены в момент at:
+// ключ с закрытой частью и самым поздним наступившим active_from
+func (s *KeySet) SigningKeyAt(at time.Time) (*Key, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		key := s.keys[i]
+		if key.Private != nil && !key.ActiveFrom.After(at) && !key.retired(at) {
+			return key, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Sign подписывает claims текущим ключом и записывает его kid в заголовок
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key, err := s.SigningKeyAt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc находит открытый ключ по kid токена для jwt.Parse. Принимаются и ключи, чей
+// active_from еще не наступил, чтобы другой экземпляр сервиса мог начать ротацию раньше
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.ID != kid || key.retired(now) {
+			continue
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+		}
+		return key.Public, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// Algorithms возвращает алгоритмы подписи ключей набора, чтобы парсер отклонял остальные
+func (s *KeySet) Algorithms() []string {
+	algs := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !slices.Contains(algs, key.Method.Alg()) {
+			algs = append(algs, key.Method.Alg())
+		}
+	}
+	return algs
+}
+
+// PublishedKeys возвращает ключи, которые должны быть в JWKS: все ключи, еще не выведенные из оборота,
+// включая будущие, чтобы проверяющие сервисы получили их до начала ротации
+func (s *KeySet) PublishedKeys() []*Key {
+	now := time.Now()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !key.retired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
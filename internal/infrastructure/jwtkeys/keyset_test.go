@@ -0,0 +1,143 @@
+package jwtkeys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"referral-system/internal/config"
+	"referral-system/internal/infrastructure/jwtkeys"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func ed25519KeyFile(t *testing.T) string {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func rsaKeyFile(t *testing.T) (string, *rsa.PrivateKey) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)), private
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	rsaPath, _ := rsaKeyFile(t)
+
+	for name, path := range map[string]string{"RS256": rsaPath, "EdDSA": ed25519KeyFile(t)} {
+		t.Run(name, func(t *testing.T) {
+			keys, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{{ID: "k1", PrivateKeyPath: path}}})
+			require.NoError(t, err)
+
+			signed, err := keys.Sign(jwt.MapClaims{"user_id": 1})
+			require.NoError(t, err)
+
+			token, err := jwt.Parse(signed, keys.Keyfunc)
+			require.NoError(t, err)
+			assert.Equal(t, name, token.Method.Alg())
+			assert.Equal(t, "k1", token.Header["kid"])
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	now := time.Now()
+	oldPath, newPath := ed25519KeyFile(t), ed25519KeyFile(t)
+
+	keys, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{
+		{ID: "new", PrivateKeyPath: newPath, ActiveFrom: now.Add(time.Hour).Format(time.RFC3339)},
+		{ID: "old", PrivateKeyPath: oldPath, ActiveFrom: now.Add(-time.Hour).Format(time.RFC3339)},
+	}})
+	require.NoError(t, err)
+
+	current, err := keys.SigningKeyAt(now)
+	require.NoError(t, err)
+	assert.Equal(t, "old", current.ID)
+
+	next, err := keys.SigningKeyAt(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "new", next.ID)
+
+	// Будущий ключ публикуется заранее, чтобы проверяющие успели его получить
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+}
+
+func TestKeySet_RetiredKeyIsRejected(t *testing.T) {
+	path := ed25519KeyFile(t)
+	active, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{{ID: "k1", PrivateKeyPath: path}}})
+	require.NoError(t, err)
+
+	signed, err := active.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+
+	retired, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{
+		{ID: "k1", PrivateKeyPath: path, RetireAt: time.Now().Add(-time.Minute).Format(time.RFC3339)},
+	}})
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(signed, retired.Keyfunc)
+	assert.Error(t, err)
+	assert.Empty(t, retired.JWKS().Keys)
+
+	_, err = retired.SigningKeyAt(time.Now())
+	assert.ErrorIs(t, err, jwtkeys.ErrNoSigningKey)
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	keys, err := jwtkeys.Generate("k1")
+	require.NoError(t, err)
+
+	// Токен с тем же kid, но подписанный HMAC, не должен проходить проверку
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(signed, keys.Keyfunc)
+	assert.Error(t, err)
+}
+
+func TestKeySet_VerifyOnlyKey(t *testing.T) {
+	rsaPath, private := rsaKeyFile(t)
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+	publicPath := writePEM(t, "PUBLIC KEY", der)
+
+	signer, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{{ID: "k1", PrivateKeyPath: rsaPath}}})
+	require.NoError(t, err)
+	verifier, err := jwtkeys.Load(config.JWTConfig{Keys: []config.JWTKey{{ID: "k1", PublicKeyPath: publicPath}}})
+	require.NoError(t, err)
+
+	signed, err := signer.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(signed, verifier.Keyfunc)
+	require.NoError(t, err)
+
+	_, err = verifier.Sign(jwt.MapClaims{"user_id": 1})
+	assert.ErrorIs(t, err, jwtkeys.ErrNoSigningKey)
+
+	jwks := verifier.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"referral-system/internal/entities"
+)
+
+// LogMailer пишет письма в лог вместо реальной отправки.
+// Используется в окружениях, где почтовый сервер не настроен
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer создает новый LogMailer
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// SendEmail логирует получателя и тему письма. Текст письма не логируется: в нем бывают коды подтверждения
+func (m *LogMailer) SendEmail(ctx context.Context, msg *entities.EmailMessage) error {
+	m.logger.InfoContext(ctx, "email sent", slog.String("email", msg.To), slog.String("subject", msg.Subject))
+	return nil
+}
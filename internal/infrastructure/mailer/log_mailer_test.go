@@ -0,0 +1,26 @@
+package mailer_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/mailer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer_DoesNotLogBody(t *testing.T) {
+	var buf bytes.Buffer
+	logMailer := mailer.NewLogMailer(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	err := logMailer.SendEmail(context.Background(), &entities.EmailMessage{
+		To: "new@mail.com", Subject: "Confirm your new email address", HTML: "<strong>verification-token</strong>",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "new@mail.com")
+	assert.NotContains(t, buf.String(), "verification-token")
+}
@@ -0,0 +1,195 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSMTPPort    = 587
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+// SMTPSender отправляет письма через SMTP сервер. На каждое письмо открывается новое соединение
+type SMTPSender struct {
+	addr     string
+	host     string
+	from     *mail.Address
+	auth     smtp.Auth
+	startTLS bool
+	timeout  time.Duration
+}
+
+// NewSMTPSender создает новый SMTPSender
+func NewSMTPSender(cfg config.SMTP) (*SMTPSender, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+
+	port := cfg.Port
+	if port <= 0 {
+		port = defaultSMTPPort
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	s := &SMTPSender{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		host:     cfg.Host,
+		from:     from,
+		startTLS: cfg.StartTLS,
+		timeout:  timeout,
+	}
+	// PlainAuth отказывается передавать пароль без TLS, кроме подключений к localhost
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return s, nil
+}
+
+// SendEmail отправляет HTML письмо
+func (s *SMTPSender) SendEmail(ctx context.Context, msg *entities.EmailMessage) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	body, err := s.buildMessage(to, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if err := s.send(client, to.Address, body); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
+
+// send проводит SMTP диалог на открытом соединении
+func (s *SMTPSender) send(client *smtp.Client, to string, body []byte) error {
+	if s.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage собирает письмо с заголовками и телом в quoted-printable
+func (s *SMTPSender) buildMessage(to *mail.Address, msg *entities.EmailMessage) ([]byte, error) {
+	id, err := messageID(s.from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"From":                      s.from.String(),
+		"To":                        to.String(),
+		"Subject":                   mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":                      time.Now().Format(time.RFC1123Z),
+		"Message-ID":                id,
+		"MIME-Version":              "1.0",
+		"Content-Type":              `text/html; charset="utf-8"`,
+		"Content-Transfer-Encoding": "quoted-printable",
+	}
+	for name, value := range msg.Headers {
+		if strings.ContainsAny(name+value, "\r\n") {
+			return nil, fmt.Errorf("invalid header %q", name)
+		}
+		headers[name] = value
+	}
+
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(msg.HTML)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID создает уникальный Message-ID в домене отправителя
+func messageID(from string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	domain := from[strings.LastIndex(from, "@")+1:]
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">", nil
+}
@@ -0,0 +1,179 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/mailer"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession - письмо, принятое заглушкой сервера
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP - заглушка SMTP сервера: принимает AUTH PLAIN и письма, а получателей из reject отклоняет
+type fakeSMTP struct {
+	listener net.Listener
+	reject   string
+	sessions chan smtpSession
+}
+
+func newFakeSMTP(t *testing.T, reject string) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSMTP{listener: listener, reject: reject, sessions: make(chan smtpSession, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) config() config.SMTP {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.SMTP{Host: host, Port: portNumber, Username: "mailer", Password: "secret", From: "Referrals <no-reply@example.com>"}
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 fake ESMTP\r\n")
+
+	var session smtpSession
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			fmt.Fprint(conn, "250-fake\r\n250 AUTH PLAIN\r\n")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.Fields(line)[2])
+			session.auth = string(credentials)
+			fmt.Fprint(conn, "235 ok\r\n")
+		case "MAIL":
+			session.from = line
+			fmt.Fprint(conn, "250 ok\r\n")
+		case "RCPT":
+			if s.reject != "" && strings.Contains(line, s.reject) {
+				fmt.Fprint(conn, "550 no such user\r\n")
+				continue
+			}
+			session.to = append(session.to, line)
+			fmt.Fprint(conn, "250 ok\r\n")
+		case "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			session.data = data.String()
+			fmt.Fprint(conn, "250 queued\r\n")
+		case "QUIT":
+			s.sessions <- session
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 ok\r\n")
+		}
+	}
+}
+
+func TestSMTPSender_SendEmail(t *testing.T) {
+	server := newFakeSMTP(t, "")
+	sender, err := mailer.NewSMTPSender(server.config())
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), &entities.EmailMessage{
+		To:      "alice@mail.com",
+		Subject: "Награда начислена",
+		HTML:    "<p>Здравствуйте, Alice!</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://ref.example.com/notifications/unsubscribe?token=abc>"},
+	})
+	require.NoError(t, err)
+
+	session := <-server.sessions
+	assert.Equal(t, "\x00mailer\x00secret", session.auth)
+	assert.True(t, strings.HasPrefix(session.from, "MAIL FROM:<no-reply@example.com>"))
+	assert.Equal(t, []string{"RCPT TO:<alice@mail.com>"}, session.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Награда начислена", subject)
+	assert.Equal(t, "<https://ref.example.com/notifications/unsubscribe?token=abc>", msg.Header.Get("List-Unsubscribe"))
+	assert.Contains(t, msg.Header.Get("Message-ID"), "@example.com>")
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Здравствуйте, Alice!</p>", strings.TrimSpace(string(body)))
+}
+
+func TestSMTPSender_SendEmail_RejectedRecipient(t *testing.T) {
+	server := newFakeSMTP(t, "ghost@mail.com")
+	sender, err := mailer.NewSMTPSender(server.config())
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), &entities.EmailMessage{To: "ghost@mail.com", Subject: "Hi", HTML: "<p>Hi</p>"})
+	assert.ErrorContains(t, err, "no such user")
+}
+
+func TestSMTPSender_SendEmail_RequiresStartTLS(t *testing.T) {
+	server := newFakeSMTP(t, "")
+	cfg := server.config()
+	cfg.StartTLS = true
+	sender, err := mailer.NewSMTPSender(cfg)
+	require.NoError(t, err)
+
+	// Сервер не предлагает STARTTLS, и пароль не должен уйти открытым текстом
+	err = sender.SendEmail(context.Background(), &entities.EmailMessage{To: "alice@mail.com", Subject: "Hi", HTML: "<p>Hi</p>"})
+	assert.ErrorContains(t, err, "STARTTLS")
+}
+
+func TestSMTPSender_SendEmail_RejectsHeaderInjection(t *testing.T) {
+	server := newFakeSMTP(t, "")
+	sender, err := mailer.NewSMTPSender(server.config())
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), &entities.EmailMessage{To: "alice@mail.com", Subject: "Hi", HTML: "<p>Hi</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://x>\r\nBcc: victim@mail.com"}})
+	assert.ErrorContains(t, err, "invalid header")
+}
@@ -0,0 +1,87 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Templates - шаблоны писем на нескольких языках. Каталог языка содержит layout.html с общей
+// разметкой письма и по файлу на каждое письмо, в котором определены блоки subject и content
+type Templates struct {
+	defaultLocale string
+	locales       map[string]map[string]*template.Template
+}
+
+// NewTemplates загружает встроенные шаблоны. Письма на неизвестном языке отправляются на defaultLocale
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	t := &Templates{defaultLocale: defaultLocale, locales: make(map[string]map[string]*template.Template)}
+
+	dirs, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		locale := dir.Name()
+		layout := path.Join("templates", locale, "layout.html")
+
+		files, err := fs.Glob(templateFS, path.Join("templates", locale, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+
+		t.locales[locale] = make(map[string]*template.Template)
+		for _, file := range files {
+			if file == layout {
+				continue
+			}
+			tmpl, err := template.ParseFS(templateFS, layout, file)
+			if err != nil {
+				return nil, fmt.Errorf("parse template %s: %w", file, err)
+			}
+			t.locales[locale][strings.TrimSuffix(path.Base(file), ".html")] = tmpl
+		}
+	}
+
+	if _, ok := t.locales[defaultLocale]; !ok {
+		return nil, fmt.Errorf("no templates for default locale %q", defaultLocale)
+	}
+	return t, nil
+}
+
+// Render возвращает тему и HTML письма name на языке locale. Для ru-RU подходят шаблоны ru
+func (t *Templates) Render(locale, name string, data any) (string, string, error) {
+	tmpl, ok := t.lookup(locale, name)
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "layout.html", data); err != nil {
+		return "", "", err
+	}
+	// тема экранируется как HTML текст, а в заголовок письма нужна исходная строка
+	return strings.TrimSpace(html.UnescapeString(subject.String())), body.String(), nil
+}
+
+// lookup ищет шаблон на языке locale, его основном языке и языке по умолчанию
+func (t *Templates) lookup(locale, name string) (*template.Template, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	for _, candidate := range []string{strings.ToLower(locale), base, t.defaultLocale} {
+		if tmpl, ok := t.locales[candidate][name]; ok {
+			return tmpl, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,44 @@
+package mailer_test
+
+import (
+	"referral-system/internal/infrastructure/mailer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateData - данные шаблонов, как их передает сервис уведомлений
+type templateData struct {
+	Name           string
+	Reward         int
+	UnsubscribeURL string
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := mailer.NewTemplates("en")
+	require.NoError(t, err)
+
+	data := templateData{Name: "<Alice>", Reward: 100, UnsubscribeURL: "https://ref.example.com/notifications/unsubscribe?token=1.all.sig"}
+
+	subject, html, err := templates.Render("ru-RU", "reward_granted", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Вам начислена реферальная награда", subject)
+	assert.Contains(t, html, `<html lang="ru">`)
+	// Данные пользователя экранируются
+	assert.Contains(t, html, "Здравствуйте, &lt;Alice&gt;!")
+	assert.Contains(t, html, `<a href="https://ref.example.com/notifications/unsubscribe?token=1.all.sig">`)
+
+	// Неизвестный язык заменяется языком по умолчанию
+	subject, _, err = templates.Render("de", "reward_granted", data)
+	require.NoError(t, err)
+	assert.Equal(t, "You've earned a referral reward", subject)
+
+	_, _, err = templates.Render("en", "newsletter", data)
+	assert.Error(t, err)
+}
+
+func TestNewTemplates_UnknownDefaultLocale(t *testing.T) {
+	_, err := mailer.NewTemplates("de")
+	assert.Error(t, err)
+}
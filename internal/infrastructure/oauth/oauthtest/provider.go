@@ -0,0 +1,154 @@
+// Package oauthtest поднимает локальный OIDC провайдер для тестов и ручной проверки входа
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/infrastructure/jwtkeys"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User - пользователь, от имени которого провайдер выдает id_token
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type grant struct {
+	user          User
+	nonce         string
+	codeChallenge string
+}
+
+// Provider - OIDC провайдер с discovery, JWKS и token endpoint. Коды авторизации
+// выдаются методом Authorize вместо страницы входа
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+
+	keys   *jwtkeys.KeySet
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+// NewProvider запускает провайдер. Его нужно остановить через Close
+func NewProvider(clientID string) (*Provider, error) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := jwtkeys.NewKeySet(&jwtkeys.Key{ID: "mock", Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{ClientID: clientID, keys: keys, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+
+	return p, nil
+}
+
+// Issuer возвращает адрес провайдера для issuer_url
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Close останавливает провайдер
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Authorize имитирует успешный вход пользователя на странице провайдера. Принимает
+// параметры из адреса AuthCodeURL и возвращает код авторизации для callback
+func (p *Provider) Authorize(user User, nonce, codeChallenge string) string {
+	code := randomString()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants[code] = grant{user: user, nonce: nonce, codeChallenge: codeChallenge}
+
+	return code
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, p.keys.JWKS())
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	code := r.PostFormValue("code")
+
+	p.mu.Lock()
+	g, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	// Код одноразовый и выдается только тому, кто знает verifier от переданного challenge
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := p.keys.Sign(jwt.MapClaims{
+		"iss":            p.Issuer(),
+		"aud":            p.ClientID,
+		"sub":            g.user.Subject,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"name":           g.user.Name,
+		"nonce":          g.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package oauth_test
+
+import (
+	"context"
+	"net/url"
+	"referral-system/internal/config"
+	"referral-system/internal/infrastructure/oauth"
+	"referral-system/internal/infrastructure/oauth/oauthtest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDCProvider(t *testing.T) (*oauth.OIDCProvider, *oauthtest.Provider) {
+	mock, err := oauthtest.NewProvider("client")
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	provider, err := oauth.NewOIDCProvider(context.Background(), config.OAuthProvider{
+		IssuerURL:    mock.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/auth/oauth/mock/callback",
+	})
+	require.NoError(t, err)
+
+	return provider, mock
+}
+
+// authorize проходит страницу входа провайдера и возвращает код авторизации
+func authorize(t *testing.T, provider *oauth.OIDCProvider, mock *oauthtest.Provider, user oauthtest.User, verifier string) string {
+	authURL, err := url.Parse(provider.AuthCodeURL("state", "nonce", verifier))
+	require.NoError(t, err)
+
+	query := authURL.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.NotContains(t, authURL.String(), verifier)
+
+	return mock.Authorize(user, query.Get("nonce"), query.Get("code_challenge"))
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	provider, mock := newOIDCProvider(t)
+	verifier := "0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	code := authorize(t, provider, mock, oauthtest.User{Subject: "42", Email: "user@mail.com", EmailVerified: true, Name: "John"}, verifier)
+
+	identity, err := provider.Exchange(context.Background(), code, "nonce", verifier)
+	require.NoError(t, err)
+	assert.Equal(t, "42", identity.Subject)
+	assert.Equal(t, "user@mail.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "John", identity.Name)
+}
+
+func TestOIDCProvider_Exchange_WrongVerifier(t *testing.T) {
+	provider, mock := newOIDCProvider(t)
+	verifier := "0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	code := authorize(t, provider, mock, oauthtest.User{Subject: "42"}, verifier)
+
+	_, err := provider.Exchange(context.Background(), code, "nonce", "another-verifier-another-verifier-another-verifier")
+	assert.Error(t, err)
+}
+
+func TestOIDCProvider_Exchange_NonceMismatch(t *testing.T) {
+	provider, mock := newOIDCProvider(t)
+	verifier := "0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	code := authorize(t, provider, mock, oauthtest.User{Subject: "42"}, verifier)
+
+	_, err := provider.Exchange(context.Background(), code, "other-nonce", verifier)
+	assert.ErrorIs(t, err, oauth.ErrNonceMismatch)
+}
@@ -0,0 +1,78 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixLength - длина префикса SHA-1, по которому хеши разбиты на корзины
+// (как в k-anonymity API Have I Been Pwned)
+const prefixLength = 5
+
+// BreachedList - офлайн список SHA-1 хешей скомпрометированных паролей,
+// сгруппированных по префиксу хеша
+type BreachedList struct {
+	buckets map[string]map[string]struct{}
+}
+
+// LoadBreachedList читает список из файла. Каждая строка - SHA-1 хеш пароля в hex,
+// за которым может идти ":<количество утечек>". Пустые строки и строки с # пропускаются
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached passwords list: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedList{buckets: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached passwords list %s:%d: invalid SHA-1 hash", path, line)
+		}
+
+		list.add(hash)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached passwords list: %w", err)
+	}
+
+	return list, nil
+}
+
+func (l *BreachedList) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	bucket, ok := l.buckets[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.buckets[prefix] = bucket
+	}
+	bucket[suffix] = struct{}{}
+}
+
+// Contains сообщает, встречается ли пароль в списке утечек
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := l.buckets[hash[:prefixLength]][hash[prefixLength:]]
+	return found
+}
@@ -0,0 +1,110 @@
+package passwords
+
+import (
+	"fmt"
+	"referral-system/internal/config"
+	"referral-system/internal/i18n"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BcryptMaxLength - bcrypt игнорирует все байты пароля после 72-го
+const BcryptMaxLength = 72
+
+const defaultMinLength = 8
+
+// PolicyError перечисляет требования политики, которым не удовлетворяет пароль
+type PolicyError struct {
+	Violations []string // нарушения на языке по умолчанию
+	// Messages - те же нарушения в виде сообщений каталога для перевода на язык клиента
+	Messages []i18n.Message
+}
+
+func (e *PolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+// Policy проверяет пароли на соответствие настройкам и списку утечек
+type Policy struct {
+	cfg      config.PasswordPolicy
+	breached *BreachedList
+}
+
+// NewPolicy создает Policy, подставляя значения по умолчанию и загружая список утечек
+func NewPolicy(cfg config.PasswordPolicy) (*Policy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultMinLength
+	}
+	if cfg.MaxLength <= 0 || cfg.MaxLength > BcryptMaxLength {
+		cfg.MaxLength = BcryptMaxLength
+	}
+	if cfg.MinLength > cfg.MaxLength {
+		return nil, fmt.Errorf("password policy: min_length %d exceeds max_length %d", cfg.MinLength, cfg.MaxLength)
+	}
+
+	policy := &Policy{cfg: cfg}
+
+	if cfg.BreachedListPath != "" {
+		list, err := LoadBreachedList(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = list
+	}
+
+	return policy, nil
+}
+
+// Validate возвращает *PolicyError, если пароль нарушает политику
+func (p *Policy) Validate(password string) error {
+	var violations []i18n.Message
+
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		violations = append(violations, i18n.Message{Code: "password_too_short", Args: i18n.Args{"min": p.cfg.MinLength}})
+	}
+	if len(password) > p.cfg.MaxLength {
+		violations = append(violations, i18n.Message{Code: "password_too_long", Args: i18n.Args{"max": p.cfg.MaxLength}})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.cfg.RequireUpper && !hasUpper {
+		violations = append(violations, i18n.Message{Code: "password_no_upper"})
+	}
+	if p.cfg.RequireLower && !hasLower {
+		violations = append(violations, i18n.Message{Code: "password_no_lower"})
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		violations = append(violations, i18n.Message{Code: "password_no_digit"})
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, i18n.Message{Code: "password_no_symbol"})
+	}
+
+	if len(violations) == 0 && p.breached.Contains(password) {
+		violations = append(violations, i18n.Message{Code: "password_breached"})
+	}
+
+	if len(violations) > 0 {
+		policyErr := &PolicyError{Messages: violations}
+		for _, violation := range violations {
+			policyErr.Violations = append(policyErr.Violations, violation.Translate(i18n.DefaultLocale))
+		}
+		return policyErr
+	}
+
+	return nil
+}
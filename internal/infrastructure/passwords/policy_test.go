@@ -0,0 +1,89 @@
+package passwords_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"referral-system/internal/config"
+	"referral-system/internal/infrastructure/passwords"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBreachedList(t *testing.T, passwordsList ...string) string {
+	t.Helper()
+
+	var b strings.Builder
+	b.WriteString("# test breached list\n")
+	for _, password := range passwordsList {
+		sum := sha1.Sum([]byte(password))
+		b.WriteString(strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o600))
+
+	return path
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy, err := passwords.NewPolicy(config.PasswordPolicy{
+		MinLength:        10,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		BreachedListPath: writeBreachedList(t, "Password123!"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		password  string
+		violation string
+	}{
+		{name: "valid", password: "Correct-Horse-42"},
+		{name: "too short", password: "Ab1!", violation: "at least 10 characters"},
+		{name: "too long for bcrypt", password: "Aa1!" + strings.Repeat("x", 70), violation: "at most 72 bytes"},
+		{name: "no uppercase", password: "correct-horse-42", violation: "uppercase"},
+		{name: "no lowercase", password: "CORRECT-HORSE-42", violation: "lowercase"},
+		{name: "no digit", password: "Correct-Horse-Battery", violation: "digit"},
+		{name: "no symbol", password: "CorrectHorse42", violation: "special character"},
+		{name: "breached", password: "Password123!", violation: "data breach"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.violation == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *passwords.PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Contains(t, strings.Join(policyErr.Violations, "; "), tt.violation)
+		})
+	}
+}
+
+func TestNewPolicy_Defaults(t *testing.T) {
+	policy, err := passwords.NewPolicy(config.PasswordPolicy{MaxLength: 1000})
+	require.NoError(t, err)
+
+	assert.Error(t, policy.Validate("short"))
+	assert.NoError(t, policy.Validate("long enough"))
+	assert.Error(t, policy.Validate(strings.Repeat("a", passwords.BcryptMaxLength+1)))
+}
+
+func TestLoadBreachedList_InvalidHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+
+	_, err := passwords.LoadBreachedList(path)
+	assert.ErrorContains(t, err, ":1")
+}
@@ -0,0 +1,193 @@
+// Package qr рисует QR коды в PNG и SVG, при необходимости с логотипом в центре
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // логотип может быть в JPEG
+	"image/png"
+	"os"
+	"referral-system/internal/config"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Ограничения на сторону изображения в пикселях
+const (
+	DefaultSize = 256
+	MinSize     = 64
+	MaxSize     = 1024
+)
+
+const (
+	defaultLogoScale = 0.2
+	// maxLogoScale - при уровне коррекции High код читается, пока закрыто не больше ~30% модулей
+	maxLogoScale = 0.3
+)
+
+// Форматы изображения
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported qr format")
+
+// Renderer рисует QR коды с общим для всех кодов логотипом
+type Renderer struct {
+	logo      image.Image
+	logoPNG   []byte // логотип для встраивания в SVG
+	logoScale float64
+}
+
+// NewRenderer создает Renderer и загружает логотип из конфига, если он задан
+func NewRenderer(cfg config.QRConfig) (*Renderer, error) {
+	r := &Renderer{logoScale: cfg.LogoScale}
+	if r.logoScale <= 0 {
+		r.logoScale = defaultLogoScale
+	}
+	if r.logoScale > maxLogoScale {
+		return nil, fmt.Errorf("qr: logo_scale %.2f exceeds %.2f", r.logoScale, maxLogoScale)
+	}
+
+	if cfg.LogoPath == "" {
+		return r, nil
+	}
+
+	file, err := os.Open(cfg.LogoPath)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %w", err)
+	}
+	defer file.Close()
+
+	logo, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("qr: decode logo: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		return nil, fmt.Errorf("qr: encode logo: %w", err)
+	}
+	r.logo, r.logoPNG = logo, buf.Bytes()
+
+	return r, nil
+}
+
+// Render рисует content в заданном формате со стороной size пикселей. Возвращает данные и Content-Type
+func (r *Renderer) Render(content, format string, size int) ([]byte, string, error) {
+	size = min(max(size, MinSize), MaxSize)
+
+	// С логотипом часть модулей закрыта, поэтому нужен максимальный уровень коррекции ошибок
+	level := qrcode.Medium
+	if r.logo != nil {
+		level = qrcode.Highest
+	}
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case FormatPNG:
+		data, err := r.png(code, size)
+		return data, "image/png", err
+	case FormatSVG:
+		return r.svg(code, size), "image/svg+xml", nil
+	default:
+		return nil, "", ErrUnsupportedFormat
+	}
+}
+
+// logoRect возвращает область логотипа по центру изображения со стороной size
+func (r *Renderer) logoRect(size int) image.Rectangle {
+	side := int(float64(size) * r.logoScale)
+	offset := (size - side) / 2
+	return image.Rect(offset, offset, offset+side, offset+side)
+}
+
+func (r *Renderer) png(code *qrcode.QRCode, size int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), code.Image(size), image.Point{}, draw.Src)
+
+	if r.logo != nil {
+		area := r.logoRect(size)
+		// Белая подложка отделяет логотип от модулей кода
+		pad := max(area.Dx()/10, 1)
+		draw.Draw(img, area.Inset(-pad), image.NewUniform(color.White), image.Point{}, draw.Src)
+		drawScaled(img, area, r.logo)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Renderer) svg(code *qrcode.QRCode, size int) []byte {
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+
+	// Соседние темные модули строки объединяются в один прямоугольник
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if r.logo != nil {
+		// Логотип задается в координатах модулей, как и сам код
+		side := float64(modules) * r.logoScale
+		offset := (float64(modules) - side) / 2
+		pad := side / 10
+		fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#fff"/>`,
+			offset-pad, offset-pad, side+2*pad, side+2*pad)
+		fmt.Fprintf(&b, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`,
+			offset, offset, side, side, base64.StdEncoding.EncodeToString(r.logoPNG))
+	}
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// drawScaled вписывает src в область dst с сохранением пропорций (ближайший сосед)
+func drawScaled(dst draw.Image, area image.Rectangle, src image.Image) {
+	bounds := src.Bounds()
+	scale := min(float64(area.Dx())/float64(bounds.Dx()), float64(area.Dy())/float64(bounds.Dy()))
+	width, height := int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale)
+	target := image.Rect(0, 0, width, height).Add(area.Min).
+		Add(image.Pt((area.Dx()-width)/2, (area.Dy()-height)/2))
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := bounds.Min.X + int(float64(x)/scale)
+			sy := bounds.Min.Y + int(float64(y)/scale)
+			scaled.Set(x, y, src.At(sx, sy))
+		}
+	}
+
+	draw.Draw(dst, target, scaled, image.Point{}, draw.Over)
+}
@@ -0,0 +1,93 @@
+package qr_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"referral-system/internal/config"
+	"referral-system/internal/infrastructure/qr"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const link = "https://example.com/r/REFCODE"
+
+func TestRenderer_PNG(t *testing.T) {
+	renderer, err := qr.NewRenderer(config.QRConfig{})
+	require.NoError(t, err)
+
+	data, contentType, err := renderer.Render(link, qr.FormatPNG, 300)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+}
+
+func TestRenderer_ClampsSize(t *testing.T) {
+	renderer, err := qr.NewRenderer(config.QRConfig{})
+	require.NoError(t, err)
+
+	data, _, err := renderer.Render(link, qr.FormatPNG, 10000)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, qr.MaxSize, img.Bounds().Dx())
+}
+
+func TestRenderer_SVGWithLogo(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			logo.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "logo.png")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, logo))
+	require.NoError(t, file.Close())
+
+	renderer, err := qr.NewRenderer(config.QRConfig{LogoPath: path})
+	require.NoError(t, err)
+
+	data, contentType, err := renderer.Render(link, qr.FormatSVG, 256)
+	require.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", contentType)
+
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, "<svg"), svg[:min(len(svg), 40)])
+	assert.Contains(t, svg, "<path")
+	assert.Contains(t, svg, "data:image/png;base64,")
+
+	// В PNG центр закрыт логотипом
+	data, _, err = renderer.Render(link, qr.FormatPNG, 256)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	r, g, _, _ := img.At(128, 128).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	assert.Equal(t, uint32(0), g)
+}
+
+func TestRenderer_Rejects(t *testing.T) {
+	_, err := qr.NewRenderer(config.QRConfig{LogoScale: 0.5})
+	assert.Error(t, err)
+
+	_, err = qr.NewRenderer(config.QRConfig{LogoPath: filepath.Join(t.TempDir(), "missing.png")})
+	assert.Error(t, err)
+
+	renderer, err := qr.NewRenderer(config.QRConfig{})
+	require.NoError(t, err)
+	_, _, err = renderer.Render(link, "gif", 256)
+	assert.ErrorIs(t, err, qr.ErrUnsupportedFormat)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто MemoryStore удаляет наполненные корзины
+const sweepInterval = time.Minute
+
+// MemoryStore хранит корзины в памяти процесса. Лимиты считаются отдельно на каждом экземпляре сервиса
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]time.Time // время полного наполнения корзины
+	lastSweep time.Time
+}
+
+// NewMemoryStore создает новый MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Take сдвигает время наполнения корзины на один интервал, если оно не уходит дальше емкости корзины
+func (s *MemoryStore) Take(_ context.Context, key string, limit entities.RateLimit) (*entities.RateLimitResult, error) {
+	now := time.Now()
+	interval := limit.Interval()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	tat := s.buckets[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	if next.Sub(now) > time.Duration(limit.Burst)*interval {
+		return limit.Result(tat.Sub(now), false), nil
+	}
+
+	s.buckets[key] = next
+	return limit.Result(next.Sub(now), true), nil
+}
+
+// PurgeRateLimits удаляет корзины, время наполнения которых уже прошло
+func (s *MemoryStore) PurgeRateLimits(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sweep(time.Now()), nil
+}
+
+// sweep удаляет наполненные корзины. Вызывается под блокировкой
+func (s *MemoryStore) sweep(now time.Time) int {
+	purged := 0
+	for key, tat := range s.buckets {
+		if tat.Before(now) {
+			delete(s.buckets, key)
+			purged++
+		}
+	}
+	s.lastSweep = now
+	return purged
+}
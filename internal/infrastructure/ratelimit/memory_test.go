@@ -0,0 +1,61 @@
+package ratelimit_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+
+	limit := entities.RateLimit{Requests: 1, Period: time.Hour, Burst: 2}
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := store.Take(ctx, "auth:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "auth:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+	assert.InDelta(t, time.Hour.Seconds(), result.RetryAfter.Seconds(), 1)
+	assert.InDelta(t, (2 * time.Hour).Seconds(), result.Reset.Seconds(), 1)
+
+	// Корзины разных ключей независимы
+	result, err = store.Take(ctx, "auth:ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+
+	limit := entities.RateLimit{Requests: 1, Period: 20 * time.Millisecond, Burst: 1}
+	result, err := store.Take(ctx, "codes:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(ctx, "codes:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// Маркер восполняется, а наполненная корзина удаляется
+	time.Sleep(30 * time.Millisecond)
+	purged, err := store.PurgeRateLimits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	result, err = store.Take(ctx, "codes:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
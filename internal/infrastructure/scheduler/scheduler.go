@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"referral-system/internal/infrastructure/logger/sl"
+	"sync"
+	"time"
+)
+
+// Locker не дает экземплярам сервиса выполнить один запуск задачи дважды
+type Locker interface {
+	// AcquireJob захватывает задачу для запуска на время scheduledAt. Если задачу сейчас выполняет
+	// другой экземпляр или этот запуск уже выполнен, возвращает acquired = false.
+	// release сохраняет результат запуска и освобождает задачу
+	AcquireJob(ctx context.Context, name string, scheduledAt time.Time) (release func(runErr error), acquired bool, err error)
+}
+
+// job - задача с расписанием
+type job struct {
+	name     string
+	schedule Schedule
+	run      func(ctx context.Context) error
+}
+
+// Scheduler запускает задачи по расписанию в том же процессе
+type Scheduler struct {
+	locker Locker
+	logger *slog.Logger
+	jobs   []job
+}
+
+// New создает новый Scheduler
+func New(locker Locker, logger *slog.Logger) *Scheduler {
+	return &Scheduler{locker: locker, logger: logger}
+}
+
+// Add добавляет задачу с расписанием в формате Parse
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", name, err)
+	}
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run запускает задачи по расписанию, пока не отменен ctx, и дожидается завершения начатых.
+// Пропущенные, пока сервис не работал, запуски не наверстываются
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.jobs) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	next := make([]time.Time, len(s.jobs))
+	for i, j := range s.jobs {
+		next[i] = j.schedule.Next(time.Now())
+	}
+
+	for {
+		earliest := next[0]
+		for _, at := range next[1:] {
+			if at.Before(earliest) {
+				earliest = at
+			}
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for i, j := range s.jobs {
+			if next[i].After(now) {
+				continue
+			}
+
+			scheduledAt := next[i]
+			next[i] = j.schedule.Next(now)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.runJob(ctx, j, scheduledAt)
+			}()
+		}
+	}
+}
+
+// runJob выполняет запуск, если его не захватил другой экземпляр
+func (s *Scheduler) runJob(ctx context.Context, j job, scheduledAt time.Time) {
+	logger := s.logger.With(slog.String("job", j.name), slog.Time("scheduled_at", scheduledAt))
+
+	release, acquired, err := s.locker.AcquireJob(ctx, j.name, scheduledAt)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("failed to acquire job", sl.Err(err))
+		}
+		return
+	}
+	if !acquired {
+		logger.Debug("job is run by another instance")
+		return
+	}
+
+	start := time.Now()
+	err = j.run(ctx)
+	release(err)
+
+	if err != nil {
+		logger.Error("job failed", sl.Err(err))
+		return
+	}
+	logger.Info("job finished", slog.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,78 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/infrastructure/scheduler"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocker выдает каждый запуск только один раз, как блокировка в базе для нескольких экземпляров
+type fakeLocker struct {
+	mu       sync.Mutex
+	runs     map[string]time.Time
+	released []error
+}
+
+func (l *fakeLocker) AcquireJob(_ context.Context, name string, scheduledAt time.Time) (func(error), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.runs[name].Before(scheduledAt) {
+		return nil, false, nil
+	}
+	l.runs[name] = scheduledAt
+	return func(runErr error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.released = append(l.released, runErr)
+	}, true, nil
+}
+
+func TestScheduler_Run(t *testing.T) {
+	locker := &fakeLocker{runs: make(map[string]time.Time)}
+	failure := errors.New("job failed")
+
+	// Два экземпляра с общей блокировкой
+	var mu sync.Mutex
+	runs := 0
+	instances := make([]*scheduler.Scheduler, 2)
+	for i := range instances {
+		instances[i] = scheduler.New(locker, slogdiscard.NewDiscardLogger())
+		require.NoError(t, instances[i].Add("tick", "@every 1s", func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs++
+			return failure
+		}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance.Run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	// За 2.5 секунды наступает 2 или 3 запуска, и каждый выполнен одним экземпляром
+	assert.GreaterOrEqual(t, runs, 2)
+	assert.LessOrEqual(t, runs, 3)
+	require.Len(t, locker.released, runs)
+	assert.ErrorIs(t, locker.released[0], failure)
+}
+
+func TestScheduler_Add_InvalidSpec(t *testing.T) {
+	s := scheduler.New(&fakeLocker{}, slogdiscard.NewDiscardLogger())
+	assert.ErrorContains(t, s.Add("broken", "* *", func(context.Context) error { return nil }), `job "broken"`)
+}
@@ -0,0 +1,59 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"referral-system/internal/infrastructure/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Секрет и ожидаемые значения из приложения B RFC 6238 (SHA-1), последние 6 цифр
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range vectors {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	step, ok := totp.Validate(rfcSecret, "005924", now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	// Код предыдущего шага принимается в пределах допуска
+	_, ok = totp.Validate(rfcSecret, "005924", now.Add(totp.Period), 1)
+	assert.True(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "005924", now.Add(2*totp.Period), 1)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "000000", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	uri := totp.URI("Referral System", "user@mail.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Referral%20System:user@mail.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Referral+System")
+}
@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"referral-system/internal/auth"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader - заголовок, в котором передается API ключ
+const apiKeyHeader = "X-API-Key"
+
+// SessionValidator проверяет, что токен пользователя не был отозван, и возвращает текущую роль пользователя
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userID, tokenVersion int) (string, error)
+}
+
+// TokenParser проверяет подпись, срок действия, издателя и audience токена доступа
+type TokenParser interface {
+	Parse(tokenString string) (*auth.Claims, error)
+}
+
+// APIKeyAuthenticator проверяет API ключ
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (auth.User, error)
+}
+
+// AuthMiddleware принимает JWT в заголовке Authorization или API ключ в X-API-Key и кладет
+// пользователя в контекст, откуда его достает auth.UserFromContext
+func AuthMiddleware(tokens TokenParser, sessions SessionValidator, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rawKey := c.GetHeader(apiKeyHeader); rawKey != "" {
+			user, err := apiKeys.Authenticate(c.Request.Context(), rawKey)
+			if err != nil {
+				abortWithError(c, http.StatusUnauthorized, "invalid_api_key")
+				return
+			}
+
+			auth.SetUser(c, user)
+			c.Next()
+			return
+		}
+
+		// Получаем заголовок Authorization
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			abortWithError(c, http.StatusUnauthorized, "authorization_required")
+			return
+		}
+
+		// Проверяем, что заголовок начинается с "Bearer "
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			abortWithError(c, http.StatusUnauthorized, "invalid_token_format")
+			return
+		}
+
+		// Парсим и проверяем токен
+		claims, err := tokens.Parse(tokenString)
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, "invalid_token_claims")
+			return
+		}
+
+		// Проверяем, что токен не отозван сменой пароля
+		role, err := sessions.ValidateSession(c.Request.Context(), userID, claims.TokenVersion)
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, "session_revoked")
+			return
+		}
+
+		// Права определяются текущей ролью, а не ролями в токене: пользователь, лишенный роли,
+		// теряет ее права сразу, а не когда истечет токен
+		roles := []string{role}
+		if claims.IssuedAt != nil {
+			c.Request = c.Request.WithContext(auth.WithAuthenticatedAt(c.Request.Context(), claims.IssuedAt.Time))
+		}
+		auth.SetUser(c, auth.User{
+			ID:           userID,
+			TokenVersion: claims.TokenVersion,
+			Roles:        roles,
+			Scopes:       auth.ScopesForRoles(roles),
+		})
+
+		// Пропускаем запрос дальше
+		c.Next()
+	}
+}
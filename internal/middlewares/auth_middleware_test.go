@@ -0,0 +1,99 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/middlewares"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticTokens принимает любой токен и возвращает заданные claims
+type staticTokens struct {
+	claims *auth.Claims
+}
+
+func (p staticTokens) Parse(string) (*auth.Claims, error) {
+	return p.claims, nil
+}
+
+// sessionsByRole считает действующей любую сессию и возвращает заданную роль
+type sessionsByRole struct {
+	role string
+	err  error
+}
+
+func (s sessionsByRole) ValidateSession(context.Context, int, int) (string, error) {
+	return s.role, s.err
+}
+
+func authenticate(t *testing.T, claims *auth.Claims, sessions middlewares.SessionValidator) (*httptest.ResponseRecorder, auth.User) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	var user auth.User
+	router.GET("/me", middlewares.AuthMiddleware(staticTokens{claims}, sessions, nil), func(c *gin.Context) {
+		user, _ = auth.UserFromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, user
+}
+
+func TestAuthMiddleware_ScopesFollowCurrentRole(t *testing.T) {
+	claims := auth.NewClaims(5, 2, entities.RoleAdmin)
+
+	// Токен выдан администратору, но роль у него уже отозвали
+	w, user := authenticate(t, claims, sessionsByRole{role: entities.RoleUser})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, user.ID)
+	assert.Equal(t, []string{entities.RoleUser}, user.Roles)
+	assert.False(t, user.HasScope(auth.ScopeAdminUsers))
+	assert.True(t, user.HasScope(auth.ScopeProfileRead))
+
+	// И наоборот, назначенная роль действует без нового входа
+	claims.Roles = nil
+	_, user = authenticate(t, claims, sessionsByRole{role: entities.RoleAdmin})
+	assert.True(t, user.HasScope(auth.ScopeAdminUsers))
+}
+
+func TestAuthMiddleware_RevokedSession(t *testing.T) {
+	w, _ := authenticate(t, auth.NewClaims(5, 1), sessionsByRole{err: errors.New("session has been revoked")})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_PassesLoginTimeToServices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	claims := auth.NewClaims(5, 1)
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+
+	var authenticatedAt time.Time
+	router.GET("/me", middlewares.AuthMiddleware(staticTokens{claims}, sessionsByRole{role: entities.RoleUser}, nil), func(c *gin.Context) {
+		authenticatedAt, _ = auth.AuthenticatedAtFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, issuedAt.Equal(authenticatedAt))
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"referral-system/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware выбирает язык ответа по заголовку Accept-Language и кладет его в контекст запроса,
+// откуда его берет i18n.FromContext. Без подходящего языка ответ приходит на языке по умолчанию
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Language")
+
+		locale, ok := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		if ok {
+			c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		}
+		c.Header("Content-Language", i18n.FromContext(c.Request.Context()))
+
+		c.Next()
+	}
+}
+
+// abortWithError прерывает запрос ошибкой из каталога сообщений на языке запроса
+func abortWithError(c *gin.Context, status int, code string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": i18n.Translate(c.Request.Context(), code, nil),
+		"code":  code,
+	})
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/sl"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitStore хранит корзины лимитов. Реализации - ratelimit.MemoryStore и PostgresRateLimitRepository
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit entities.RateLimit) (*entities.RateLimitResult, error)
+}
+
+// RateLimiter ограничивает частоту запросов к группам маршрутов
+type RateLimiter struct {
+	store  RateLimitStore
+	limits map[string]entities.RateLimit
+	logger *slog.Logger
+}
+
+// NewRateLimiter создает новый RateLimiter. Группы без лимита в limits не ограничиваются
+func NewRateLimiter(store RateLimitStore, limits map[string]entities.RateLimit, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{store: store, limits: limits, logger: logger}
+}
+
+// Limit возвращает middleware с лимитом группы. Для ключей user и api_key middleware должен стоять
+// после AuthMiddleware. Заголовки RateLimit-* соответствуют черновику IETF httpapi-ratelimit-headers.
+// Если хранилище недоступно, запрос пропускается
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	limit, ok := l.limits[group]
+	if !ok {
+		return func(c *gin.Context) { c.Next() }
+	}
+	policy := fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, int(limit.Period.Seconds()), limit.Burst)
+
+	return func(c *gin.Context) {
+		result, err := l.store.Take(c.Request.Context(), group+":"+rateLimitKey(c, limit.Key), limit)
+		if err != nil {
+			l.logger.Error("failed to check rate limit", slog.String("group", group), sl.Err(err))
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policy)
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			abortWithError(c, http.StatusTooManyRequests, "rate_limited")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey выбирает, по чему считать запросы. Анонимные запросы считаются по адресу клиента
+func rateLimitKey(c *gin.Context, key string) string {
+	user, authenticated := auth.UserFromContext(c)
+	switch {
+	case key == entities.RateLimitKeyAPIKey && user.APIKeyID != 0:
+		return "api_key:" + strconv.Itoa(user.APIKeyID)
+	case key != entities.RateLimitKeyIP && authenticated:
+		return "user:" + strconv.Itoa(user.ID)
+	default:
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
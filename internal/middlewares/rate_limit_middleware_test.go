@@ -0,0 +1,93 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/logger/handlers/slogdiscard"
+	"referral-system/internal/infrastructure/ratelimit"
+	"referral-system/internal/middlewares"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore - недоступное хранилище лимитов
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, entities.RateLimit) (*entities.RateLimitResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+func newRateLimitedRouter(store middlewares.RateLimitStore, limit entities.RateLimit, user *auth.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := middlewares.NewRateLimiter(store, map[string]entities.RateLimit{"auth": limit}, slogdiscard.NewDiscardLogger())
+
+	handlers := []gin.HandlerFunc{limiter.Limit("auth"), func(c *gin.Context) { c.Status(http.StatusOK) }}
+	if user != nil {
+		handlers = append([]gin.HandlerFunc{func(c *gin.Context) { auth.SetUser(c, *user) }}, handlers...)
+	}
+	router.POST("/auth/login", handlers...)
+	router.GET("/unlimited", limiter.Limit("codes"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func request(router *gin.Engine, method, path, remoteAddr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(),
+		entities.RateLimit{Requests: 10, Period: time.Minute, Burst: 2, Key: entities.RateLimitKeyIP}, nil)
+
+	w := request(router, "POST", "/auth/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "10;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "6", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, request(router, "POST", "/auth/login", "10.0.0.1:1234").Code)
+
+	w = request(router, "POST", "/auth/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "6", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"too many requests, try again later","code":"rate_limited"}`, w.Body.String())
+
+	// Другой адрес и группа без лимита не ограничиваются
+	assert.Equal(t, http.StatusOK, request(router, "POST", "/auth/login", "10.0.0.2:1234").Code)
+	w = request(router, "GET", "/unlimited", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimiter_Limit_ByAPIKey(t *testing.T) {
+	limit := entities.RateLimit{Requests: 1, Period: time.Minute, Burst: 1, Key: entities.RateLimitKeyAPIKey}
+	store := ratelimit.NewMemoryStore()
+
+	// Ключи одного пользователя считаются отдельно, а адрес клиента не важен
+	first := newRateLimitedRouter(store, limit, &auth.User{ID: 1, APIKeyID: 7})
+	second := newRateLimitedRouter(store, limit, &auth.User{ID: 1, APIKeyID: 8})
+	assert.Equal(t, http.StatusOK, request(first, "POST", "/auth/login", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(first, "POST", "/auth/login", "10.0.0.2:1234").Code)
+	assert.Equal(t, http.StatusOK, request(second, "POST", "/auth/login", "10.0.0.1:1234").Code)
+}
+
+func TestRateLimiter_Limit_StoreUnavailable(t *testing.T) {
+	router := newRateLimitedRouter(failingStore{}, entities.RateLimit{Requests: 1, Period: time.Minute, Burst: 1}, nil)
+
+	w := request(router, "POST", "/auth/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Remaining"))
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"referral-system/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope пропускает только пользователей с указанным правом.
+// Должна стоять после AuthMiddleware
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := auth.UserFromContext(c)
+		if !ok || !user.HasScope(scope) {
+			abortWithError(c, http.StatusForbidden, "forbidden")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession не пускает запросы с API ключом. Ставится на смену пароля, удаление аккаунта,
+// второй фактор и управление самими ключами
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := auth.UserFromContext(c)
+		if !ok || !user.IsSession() {
+			abortWithError(c, http.StatusForbidden, "session_required")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"time"
+)
+
+// APIKeyRepository интерфейс для работы с API ключами. userID равный nil означает сервисные ключи
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *entities.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID *int) ([]*entities.APIKey, error)
+	// RevokeAPIKey отзывает действующий ключ владельца или возвращает ErrNotFound
+	RevokeAPIKey(ctx context.Context, id int, userID *int) error
+	TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// CampaignRepository интерфейс для работы с реферальными кампаниями
+type CampaignRepository interface {
+	CreateCampaign(ctx context.Context, campaign *entities.Campaign) error
+	GetCampaignByID(ctx context.Context, id int) (*entities.Campaign, error)
+	// LockCampaign блокирует кампанию до конца транзакции из контекста и возвращает ее актуальное состояние
+	LockCampaign(ctx context.Context, id int) (*entities.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]*entities.Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign *entities.Campaign) error
+	// DeleteCampaign удаляет кампанию или возвращает ErrConflict, если по ней уже есть коды
+	DeleteCampaign(ctx context.Context, id int) error
+	GetCampaignStats(ctx context.Context, id int) (*entities.CampaignStats, error)
+}
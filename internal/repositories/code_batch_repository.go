@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// CodeBatchRepository интерфейс для работы с пакетами реферальных кодов
+type CodeBatchRepository interface {
+	CreateBatch(ctx context.Context, batch *entities.CodeBatch) error
+	GetBatchByID(ctx context.Context, id int) (*entities.CodeBatch, error)
+	ListBatchesByCampaign(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error)
+	// InsertBatchCodes сохраняет коды пакета, пропуская уже занятые, и возвращает число сохраненных
+	InsertBatchCodes(ctx context.Context, codes []*entities.ReferralCode) (int, error)
+	// FinishBatch переводит пакет в итоговый статус
+	FinishBatch(ctx context.Context, batch *entities.CodeBatch) error
+	// StreamBatchCodes построчно передает коды пакета в fn, не загружая их в память целиком
+	StreamBatchCodes(ctx context.Context, batchID int, fn func(code *entities.ReferralCode) error) error
+}
@@ -0,0 +1,10 @@
+package repositories
+
+import "errors"
+
+var (
+	// ErrNotFound возвращается, когда запрошенная запись отсутствует
+	ErrNotFound = errors.New("not found")
+	// ErrConflict возвращается при нарушении ограничения уникальности
+	ErrConflict = errors.New("conflict")
+)
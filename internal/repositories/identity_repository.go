@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// UserIdentityRepository интерфейс для работы с привязками внешних аккаунтов
+type UserIdentityRepository interface {
+	CreateIdentity(ctx context.Context, identity *entities.UserIdentity) error
+	GetIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error)
+	ListIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error)
+}
+
+// OAuthStateRepository интерфейс для хранения состояний входа через провайдера
+type OAuthStateRepository interface {
+	CreateState(ctx context.Context, state *entities.OAuthState) error
+	// ConsumeState удаляет и возвращает состояние, так что каждое можно использовать один раз
+	ConsumeState(ctx context.Context, stateHash string) (*entities.OAuthState, error)
+}
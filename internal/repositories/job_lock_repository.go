@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// JobLockRepository интерфейс для блокировок задач планировщика между экземплярами сервиса
+type JobLockRepository interface {
+	// AcquireJob захватывает задачу для запуска на время scheduledAt. Если задачу сейчас выполняет
+	// другой экземпляр или этот запуск уже выполнен, возвращает acquired = false.
+	// release сохраняет результат запуска и освобождает задачу
+	AcquireJob(ctx context.Context, name string, scheduledAt time.Time) (release func(runErr error), acquired bool, err error)
+}
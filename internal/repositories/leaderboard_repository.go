@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// LeaderboardRepository интерфейс для работы со снимком рейтинга рефереров
+type LeaderboardRepository interface {
+	// RefreshLeaderboard пересчитывает снимок, не блокируя чтение
+	RefreshLeaderboard(ctx context.Context) error
+	// GetTopReferrers возвращает первые limit мест за период без скрывших себя пользователей
+	GetTopReferrers(ctx context.Context, period string, limit int) ([]*entities.LeaderboardEntry, error)
+	GetLeaderboardEntry(ctx context.Context, period string, userID int) (*entities.LeaderboardEntry, error)
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"time"
+)
+
+// LoginAttemptRepository интерфейс для учета неудачных попыток входа
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, key string) (*entities.LoginAttempt, error)
+	// RecordFailedLogin увеличивает счетчик ошибок. Если последняя ошибка и окончание блокировки
+	// были раньше windowStart, счетчик начинается заново
+	RecordFailedLogin(ctx context.Context, key string, windowStart time.Time) (*entities.LoginAttempt, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
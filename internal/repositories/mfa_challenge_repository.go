@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// MFAChallengeRepository интерфейс для учета использованных токенов второго шага входа
+type MFAChallengeRepository interface {
+	// UseMFAChallenge отмечает токен использованным или возвращает ErrConflict, если он уже использован
+	UseMFAChallenge(ctx context.Context, jti string, expiresAt time.Time) error
+	// PurgeMFAChallenges удаляет отметки истекших токенов
+	PurgeMFAChallenges(ctx context.Context) (int, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// NotificationRepository интерфейс для настроек уведомлений и журнала отправленных уведомлений
+type NotificationRepository interface {
+	// GetPreferences возвращает сохраненные настройки пользователя. Типов без настройки в ответе нет
+	GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error)
+	// SetPreferences сохраняет настройки переданных типов, остальные не меняются
+	SetPreferences(ctx context.Context, userID int, preferences entities.NotificationPreferences) error
+
+	// ClaimNotification отмечает уведомление отправленным и возвращает false, если оно уже было отправлено
+	ClaimNotification(ctx context.Context, notification *entities.SentNotification) (bool, error)
+	// ReleaseNotification снимает отметку, если отправить уведомление не удалось
+	ReleaseNotification(ctx context.Context, key string) error
+}
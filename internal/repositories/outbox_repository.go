@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"time"
+)
+
+// OutboxRepository интерфейс для работы с очередью исходящих событий
+type OutboxRepository interface {
+	// AddEvent сохраняет событие в транзакции из контекста, если она есть
+	AddEvent(ctx context.Context, event *entities.OutboxEvent) error
+	// ClaimPendingEvents забирает неопубликованные и не отброшенные события, чье время наступило, в порядке создания
+	// и откладывает их на lease, чтобы другие экземпляры сервиса не опубликовали их одновременно
+	ClaimPendingEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.OutboxEvent, error)
+	// UpdateEvent сохраняет результат попытки публикации и список принявших событие издателей
+	UpdateEvent(ctx context.Context, event *entities.OutboxEvent) error
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, last_used_at, expires_at, revoked_at, created_at`
+
+// PostgresAPIKeyRepository реализация APIKeyRepository для PostgreSQL
+type PostgresAPIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresAPIKeyRepository создает новый PostgresAPIKeyRepository
+func NewPostgresAPIKeyRepository(db *pgxpool.Pool) repositories.APIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+func scanAPIKey(row pgx.Row) (*entities.APIKey, error) {
+	var key entities.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes,
+		&key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateAPIKey сохраняет новый ключ
+func (r *PostgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *entities.APIKey) error {
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	key.CreatedAt = time.Now()
+	err := r.db.QueryRow(ctx, query, key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt, key.CreatedAt).
+		Scan(&key.ID)
+	return mapError(err)
+}
+
+// GetAPIKeyByPrefix находит ключ по открытой части
+func (r *PostgresAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix=$1`
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys возвращает ключи пользователя или сервисные ключи, если userID равен nil
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context, userID *int) ([]*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id IS NOT DISTINCT FROM $1 ORDER BY id`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	keys := []*entities.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, mapError(rows.Err())
+}
+
+// RevokeAPIKey отзывает ключ, если он принадлежит владельцу и еще не отозван
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int, userID *int) error {
+	query := `UPDATE api_keys SET revoked_at=$3 WHERE id=$1 AND user_id IS NOT DISTINCT FROM $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, id, userID, time.Now())
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE api_keys SET last_used_at=$2 WHERE id=$1`, id, usedAt)
+	return mapError(err)
+}
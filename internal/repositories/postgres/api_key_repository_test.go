@@ -0,0 +1,51 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRepository_UserAndServiceKeys(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresAPIKeyRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	userKey := &entities.APIKey{UserID: &user.ID, Name: "backend", Prefix: "aaaa", KeyHash: "hash", Scopes: []string{"referrals:read"}}
+	serviceKey := &entities.APIKey{Name: "billing", Prefix: "bbbb", KeyHash: "hash", Scopes: []string{"admin:users"}}
+	require.NoError(t, repo.CreateAPIKey(ctx, userKey))
+	require.NoError(t, repo.CreateAPIKey(ctx, serviceKey))
+
+	found, err := repo.GetAPIKeyByPrefix(ctx, "aaaa")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"referrals:read"}, found.Scopes)
+	require.NotNil(t, found.UserID)
+	assert.Equal(t, user.ID, *found.UserID)
+
+	userKeys, err := repo.ListAPIKeys(ctx, &user.ID)
+	require.NoError(t, err)
+	require.Len(t, userKeys, 1)
+	assert.Equal(t, userKey.ID, userKeys[0].ID)
+
+	serviceKeys, err := repo.ListAPIKeys(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, serviceKeys, 1)
+	assert.Equal(t, serviceKey.ID, serviceKeys[0].ID)
+
+	// Пользователь не может отозвать сервисный ключ
+	assert.ErrorIs(t, repo.RevokeAPIKey(ctx, serviceKey.ID, &user.ID), repositories.ErrNotFound)
+	require.NoError(t, repo.RevokeAPIKey(ctx, userKey.ID, &user.ID))
+	assert.ErrorIs(t, repo.RevokeAPIKey(ctx, userKey.ID, &user.ID), repositories.ErrNotFound)
+
+	require.NoError(t, repo.TouchAPIKey(ctx, serviceKey.ID, time.Now()))
+	found, err = repo.GetAPIKeyByPrefix(ctx, "bbbb")
+	require.NoError(t, err)
+	assert.NotNil(t, found.LastUsedAt)
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// campaignColumns - столбцы, которые читаются в entities.Campaign через scanCampaign
+const campaignColumns = `id, name, description, starts_at, ends_at, code_ttl, referrer_reward, referee_reward,
+	max_uses_per_code, max_referrals, created_at, updated_at`
+
+// PostgresCampaignRepository реализация CampaignRepository для PostgreSQL
+type PostgresCampaignRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresCampaignRepository создает новый PostgresCampaignRepository
+func NewPostgresCampaignRepository(db *pgxpool.Pool) repositories.CampaignRepository {
+	return &PostgresCampaignRepository{db: db}
+}
+
+// scanCampaign читает кампанию из строки с campaignColumns
+func scanCampaign(row pgx.Row) (*entities.Campaign, error) {
+	campaign := &entities.Campaign{}
+	err := row.Scan(&campaign.ID, &campaign.Name, &campaign.Description, &campaign.StartsAt, &campaign.EndsAt,
+		&campaign.CodeTTL, &campaign.ReferrerReward, &campaign.RefereeReward, &campaign.MaxUsesPerCode,
+		&campaign.MaxReferrals, &campaign.CreatedAt, &campaign.UpdatedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return campaign, nil
+}
+
+// CreateCampaign создает новую кампанию
+func (r *PostgresCampaignRepository) CreateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	query := `INSERT INTO campaigns (name, description, starts_at, ends_at, code_ttl, referrer_reward, referee_reward,
+                                     max_uses_per_code, max_referrals, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10) RETURNING id`
+	now := time.Now()
+	err := r.db.QueryRow(ctx, query, campaign.Name, campaign.Description, campaign.StartsAt, campaign.EndsAt,
+		campaign.CodeTTL, campaign.ReferrerReward, campaign.RefereeReward, campaign.MaxUsesPerCode,
+		campaign.MaxReferrals, now).Scan(&campaign.ID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	campaign.CreatedAt, campaign.UpdatedAt = now, now
+	return nil
+}
+
+// GetCampaignByID находит кампанию по ID
+func (r *PostgresCampaignRepository) GetCampaignByID(ctx context.Context, id int) (*entities.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id=$1`
+	return scanCampaign(r.db.QueryRow(ctx, query, id))
+}
+
+// LockCampaign блокирует строку кампании FOR UPDATE. Вне транзакции блокировка снимается сразу
+func (r *PostgresCampaignRepository) LockCampaign(ctx context.Context, id int) (*entities.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id=$1 FOR UPDATE`
+	return scanCampaign(conn(ctx, r.db).QueryRow(ctx, query, id))
+}
+
+// ListCampaigns возвращает все кампании, начиная с последних
+func (r *PostgresCampaignRepository) ListCampaigns(ctx context.Context) ([]*entities.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns ORDER BY starts_at DESC, id DESC`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	campaigns := []*entities.Campaign{}
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, mapError(rows.Err())
+}
+
+// UpdateCampaign сохраняет изменяемые поля кампании и обновляет updated_at
+func (r *PostgresCampaignRepository) UpdateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	query := `UPDATE campaigns
+              SET name=$2, description=$3, starts_at=$4, ends_at=$5, code_ttl=$6, referrer_reward=$7,
+                  referee_reward=$8, max_uses_per_code=$9, max_referrals=$10, updated_at=$11
+              WHERE id=$1`
+	now := time.Now()
+	tag, err := r.db.Exec(ctx, query, campaign.ID, campaign.Name, campaign.Description, campaign.StartsAt,
+		campaign.EndsAt, campaign.CodeTTL, campaign.ReferrerReward, campaign.RefereeReward,
+		campaign.MaxUsesPerCode, campaign.MaxReferrals, now)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+
+	campaign.UpdatedAt = now
+	return nil
+}
+
+// DeleteCampaign удаляет кампанию. Кампанию, по которой уже выпущены коды, удалить нельзя
+func (r *PostgresCampaignRepository) DeleteCampaign(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM campaigns WHERE id=$1`, id)
+
+	// Здесь нарушение внешнего ключа означает, что на кампанию ссылаются, а не что ее нет
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return fmt.Errorf("%w: %s", repositories.ErrConflict, pgErr.ConstraintName)
+	}
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// GetCampaignStats считает коды, переходы, регистрации и начисленные награды кампании
+func (r *PostgresCampaignRepository) GetCampaignStats(ctx context.Context, id int) (*entities.CampaignStats, error) {
+	query := `SELECT c.id,
+                     (SELECT COUNT(*) FROM referral_codes rc WHERE rc.campaign_id = c.id),
+                     (SELECT COUNT(*) FROM referral_clicks cl WHERE cl.campaign_id = c.id),
+                     COUNT(r.id),
+                     COALESCE(SUM(r.referrer_reward), 0),
+                     COALESCE(SUM(r.referee_reward), 0)
+              FROM campaigns c
+              LEFT JOIN referrals r ON r.campaign_id = c.id
+              WHERE c.id = $1
+              GROUP BY c.id`
+	stats := &entities.CampaignStats{}
+	err := r.db.QueryRow(ctx, query, id).Scan(&stats.CampaignID, &stats.Codes, &stats.Clicks, &stats.Referrals,
+		&stats.ReferrerRewards, &stats.RefereeRewards)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCampaign() *entities.Campaign {
+	now := time.Now().Truncate(time.Second)
+	maxUses := 2
+	return &entities.Campaign{
+		Name:           "Spring",
+		StartsAt:       now.Add(-time.Hour),
+		EndsAt:         now.Add(24 * time.Hour),
+		CodeTTL:        3600,
+		ReferrerReward: 100,
+		RefereeReward:  50,
+		MaxUsesPerCode: &maxUses,
+	}
+}
+
+func TestCampaignRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresCampaignRepository(db)
+
+	campaign := newCampaign()
+	require.NoError(t, repo.CreateCampaign(ctx, campaign))
+
+	found, err := repo.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Spring", found.Name)
+	require.NotNil(t, found.MaxUsesPerCode)
+	assert.Equal(t, 2, *found.MaxUsesPerCode)
+	assert.Nil(t, found.MaxReferrals)
+
+	campaign.Name = "Summer"
+	require.NoError(t, repo.UpdateCampaign(ctx, campaign))
+
+	campaigns, err := repo.ListCampaigns(ctx)
+	require.NoError(t, err)
+	require.Len(t, campaigns, 1)
+	assert.Equal(t, "Summer", campaigns[0].Name)
+
+	require.NoError(t, repo.DeleteCampaign(ctx, campaign.ID))
+	_, err = repo.GetCampaignByID(ctx, campaign.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	assert.ErrorIs(t, repo.DeleteCampaign(ctx, campaign.ID), repositories.ErrNotFound)
+}
+
+func TestCampaignRepository_DeleteCampaign_ConflictWhenCodesExist(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresCampaignRepository(db)
+
+	campaign := newCampaign()
+	require.NoError(t, repo.CreateCampaign(ctx, campaign))
+
+	user := createUser(t, db, "referrer@mail.com")
+	code := &entities.ReferralCode{UserID: user.ID, Code: "CAMPAIGN", ExpiresAt: campaign.EndsAt, CampaignID: &campaign.ID}
+	require.NoError(t, postgres.NewPostgresReferralCodeRepository(db).CreateReferralCode(ctx, code))
+
+	assert.ErrorIs(t, repo.DeleteCampaign(ctx, campaign.ID), repositories.ErrConflict)
+}
+
+func TestCampaignRepository_GetCampaignStats(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresCampaignRepository(db)
+	referralRepo := postgres.NewPostgresReferralRepository(db)
+
+	campaign := newCampaign()
+	require.NoError(t, repo.CreateCampaign(ctx, campaign))
+
+	stats, err := repo.GetCampaignStats(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.CampaignStats{CampaignID: campaign.ID}, *stats)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	code := &entities.ReferralCode{UserID: referrer.ID, Code: "CAMPAIGN", ExpiresAt: campaign.EndsAt, CampaignID: &campaign.ID}
+	require.NoError(t, postgres.NewPostgresReferralCodeRepository(db).CreateReferralCode(ctx, code))
+
+	click := &entities.ReferralClick{ReferralCodeID: &code.ID, ReferrerID: &referrer.ID, CampaignID: &campaign.ID,
+		UTMSource: "flyer", IPHash: "hash"}
+	require.NoError(t, postgres.NewPostgresReferralClickRepository(db).CreateClick(ctx, click))
+	assert.NotZero(t, click.ID)
+
+	for _, email := range []string{"first@mail.com", "second@mail.com"} {
+		referee := createUser(t, db, email)
+		require.NoError(t, referralRepo.CreateReferralLink(ctx, &entities.Referral{
+			ReferrerID: referrer.ID, RefereeID: referee.ID, ReferralCodeID: &code.ID, CampaignID: &campaign.ID,
+			ReferrerReward: campaign.ReferrerReward, RefereeReward: campaign.RefereeReward,
+		}))
+	}
+
+	count, err := referralRepo.CountReferralsByCode(ctx, code.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	stats, err = repo.GetCampaignStats(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.CampaignStats{
+		CampaignID: campaign.ID, Codes: 1, Clicks: 1, Referrals: 2, ReferrerRewards: 200, RefereeRewards: 100,
+	}, *stats)
+
+	_, err = repo.GetCampaignStats(ctx, campaign.ID+1)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const codeBatchColumns = `id, campaign_id, partner_id, size, status, error, created_by, created_at, completed_at`
+
+// PostgresCodeBatchRepository реализация CodeBatchRepository для PostgreSQL
+type PostgresCodeBatchRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresCodeBatchRepository создает новый PostgresCodeBatchRepository
+func NewPostgresCodeBatchRepository(db *pgxpool.Pool) repositories.CodeBatchRepository {
+	return &PostgresCodeBatchRepository{db: db}
+}
+
+// scanCodeBatch читает пакет из строки с codeBatchColumns
+func scanCodeBatch(row pgx.Row) (*entities.CodeBatch, error) {
+	batch := &entities.CodeBatch{}
+	err := row.Scan(&batch.ID, &batch.CampaignID, &batch.PartnerID, &batch.Size, &batch.Status, &batch.Error,
+		&batch.CreatedBy, &batch.CreatedAt, &batch.CompletedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return batch, nil
+}
+
+// CreateBatch сохраняет новый пакет в статусе pending
+func (r *PostgresCodeBatchRepository) CreateBatch(ctx context.Context, batch *entities.CodeBatch) error {
+	query := `INSERT INTO code_batches (campaign_id, partner_id, size, status, created_by, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	batch.Status = entities.BatchStatusPending
+	batch.CreatedAt = time.Now()
+	err := r.db.QueryRow(ctx, query, batch.CampaignID, batch.PartnerID, batch.Size, batch.Status, batch.CreatedBy,
+		batch.CreatedAt).Scan(&batch.ID)
+	return mapError(err)
+}
+
+// GetBatchByID находит пакет по ID
+func (r *PostgresCodeBatchRepository) GetBatchByID(ctx context.Context, id int) (*entities.CodeBatch, error) {
+	query := `SELECT ` + codeBatchColumns + ` FROM code_batches WHERE id=$1`
+	return scanCodeBatch(r.db.QueryRow(ctx, query, id))
+}
+
+// ListBatchesByCampaign возвращает пакеты кампании, начиная с последних
+func (r *PostgresCodeBatchRepository) ListBatchesByCampaign(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error) {
+	query := `SELECT ` + codeBatchColumns + ` FROM code_batches WHERE campaign_id=$1 ORDER BY id DESC`
+	rows, err := r.db.Query(ctx, query, campaignID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	batches := []*entities.CodeBatch{}
+	for rows.Next() {
+		batch, err := scanCodeBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, mapError(rows.Err())
+}
+
+// InsertBatchCodes загружает коды через COPY во временную таблицу и переносит в referral_codes,
+// пропуская коды, которые уже заняты
+func (r *PostgresCodeBatchRepository) InsertBatchCodes(ctx context.Context, codes []*entities.ReferralCode) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `CREATE TEMP TABLE referral_codes_staging (
+                               user_id INT, code VARCHAR(255), expires_at TIMESTAMP, campaign_id INT, max_uses INT, batch_id INT
+                           ) ON COMMIT DROP`)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	columns := []string{"user_id", "code", "expires_at", "campaign_id", "max_uses", "batch_id"}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"referral_codes_staging"}, columns,
+		pgx.CopyFromSlice(len(codes), func(i int) ([]interface{}, error) {
+			code := codes[i]
+			return []interface{}{code.UserID, code.Code, code.ExpiresAt, code.CampaignID, code.MaxUses, code.BatchID}, nil
+		}))
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	tag, err := tx.Exec(ctx, `INSERT INTO referral_codes (user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at)
+                              SELECT user_id, code, expires_at, campaign_id, max_uses, batch_id, $1 FROM referral_codes_staging
+                              ON CONFLICT (code) DO NOTHING`, time.Now())
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// FinishBatch сохраняет итоговый статус пакета и время завершения
+func (r *PostgresCodeBatchRepository) FinishBatch(ctx context.Context, batch *entities.CodeBatch) error {
+	now := time.Now()
+	tag, err := r.db.Exec(ctx, `UPDATE code_batches SET status=$2, error=$3, completed_at=$4 WHERE id=$1`,
+		batch.ID, batch.Status, batch.Error, now)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+
+	batch.CompletedAt = &now
+	return nil
+}
+
+// StreamBatchCodes читает коды пакета по мере передачи их в fn
+func (r *PostgresCodeBatchRepository) StreamBatchCodes(ctx context.Context, batchID int, fn func(code *entities.ReferralCode) error) error {
+	query := `SELECT ` + referralCodeColumns + ` FROM referral_codes WHERE batch_id=$1 ORDER BY id`
+	rows, err := r.db.Query(ctx, query, batchID)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		code, err := scanReferralCode(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(code); err != nil {
+			return err
+		}
+	}
+
+	return mapError(rows.Err())
+}
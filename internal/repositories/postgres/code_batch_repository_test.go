@@ -0,0 +1,60 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeBatchRepository_InsertAndStreamCodes(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresCodeBatchRepository(db)
+	codeRepo := postgres.NewPostgresReferralCodeRepository(db)
+
+	campaign := newCampaign()
+	require.NoError(t, postgres.NewPostgresCampaignRepository(db).CreateCampaign(ctx, campaign))
+	partner := createUser(t, db, "partner@mail.com")
+
+	batch := &entities.CodeBatch{CampaignID: campaign.ID, PartnerID: partner.ID, Size: 3}
+	require.NoError(t, repo.CreateBatch(ctx, batch))
+	assert.Equal(t, entities.BatchStatusPending, batch.Status)
+
+	// Личный код партнера занимает одно из значений пакета
+	require.NoError(t, codeRepo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: partner.ID, Code: "TAKEN", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	maxUses := 1
+	codes := make([]*entities.ReferralCode, 0, 3)
+	for _, value := range []string{"FIRST", "TAKEN", "SECOND"} {
+		codes = append(codes, &entities.ReferralCode{UserID: partner.ID, Code: value, ExpiresAt: campaign.EndsAt,
+			CampaignID: &campaign.ID, MaxUses: &maxUses, BatchID: &batch.ID})
+	}
+	inserted, err := repo.InsertBatchCodes(ctx, codes)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inserted)
+
+	batch.Status = entities.BatchStatusCompleted
+	require.NoError(t, repo.FinishBatch(ctx, batch))
+
+	var streamed []string
+	require.NoError(t, repo.StreamBatchCodes(ctx, batch.ID, func(code *entities.ReferralCode) error {
+		streamed = append(streamed, code.Code)
+		return nil
+	}))
+	assert.Equal(t, []string{"FIRST", "SECOND"}, streamed)
+
+	// Коды пакета не считаются личным кодом партнера
+	personal, err := codeRepo.GetReferralCodeByUserID(ctx, partner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "TAKEN", personal.Code)
+
+	found, err := repo.GetBatchByID(ctx, batch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.BatchStatusCompleted, found.Status)
+	assert.NotNil(t, found.CompletedAt)
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"referral-system/internal/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Коды ошибок PostgreSQL (SQLSTATE), которые мы транслируем в ошибки репозиториев
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// mapError приводит ошибки pgx к ошибкам уровня репозиториев
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return fmt.Errorf("%w: %s", repositories.ErrConflict, pgErr.ConstraintName)
+		case foreignKeyViolation:
+			return fmt.Errorf("%w: %s", repositories.ErrNotFound, pgErr.ConstraintName)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"referral-system/internal/repositories"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "no rows", err: pgx.ErrNoRows, want: repositories.ErrNotFound},
+		{name: "wrapped no rows", err: fmt.Errorf("scan: %w", pgx.ErrNoRows), want: repositories.ErrNotFound},
+		{name: "unique violation", err: &pgconn.PgError{Code: uniqueViolation}, want: repositories.ErrConflict},
+		{name: "foreign key violation", err: &pgconn.PgError{Code: foreignKeyViolation}, want: repositories.ErrNotFound},
+		{name: "other error", err: other, want: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, mapError(tt.err), tt.want)
+		})
+	}
+
+	assert.NoError(t, mapError(nil))
+}
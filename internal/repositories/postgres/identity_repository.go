@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresUserIdentityRepository реализация UserIdentityRepository для PostgreSQL
+type PostgresUserIdentityRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresUserIdentityRepository создает новый PostgresUserIdentityRepository
+func NewPostgresUserIdentityRepository(db *pgxpool.Pool) repositories.UserIdentityRepository {
+	return &PostgresUserIdentityRepository{db: db}
+}
+
+// CreateIdentity привязывает внешний аккаунт к пользователю
+func (r *PostgresUserIdentityRepository) CreateIdentity(ctx context.Context, identity *entities.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	identity.CreatedAt = time.Now()
+	err := r.db.QueryRow(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt).
+		Scan(&identity.ID)
+	return mapError(err)
+}
+
+// GetIdentity возвращает привязку по провайдеру и ID пользователя у провайдера
+func (r *PostgresUserIdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error) {
+	query := `SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE provider=$1 AND subject=$2`
+	var identity entities.UserIdentity
+	err := r.db.QueryRow(ctx, query, provider, subject).
+		Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &identity, nil
+}
+
+// ListIdentities возвращает все внешние аккаунты, привязанные к пользователю
+func (r *PostgresUserIdentityRepository) ListIdentities(ctx context.Context, userID int) ([]*entities.UserIdentity, error) {
+	query := `SELECT id, user_id, provider, subject, email, created_at FROM user_identities WHERE user_id=$1 ORDER BY id`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	identities := []*entities.UserIdentity{}
+	for rows.Next() {
+		var identity entities.UserIdentity
+		err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		identities = append(identities, &identity)
+	}
+
+	return identities, mapError(rows.Err())
+}
+
+// PostgresOAuthStateRepository реализация OAuthStateRepository для PostgreSQL
+type PostgresOAuthStateRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresOAuthStateRepository создает новый PostgresOAuthStateRepository
+func NewPostgresOAuthStateRepository(db *pgxpool.Pool) repositories.OAuthStateRepository {
+	return &PostgresOAuthStateRepository{db: db}
+}
+
+// CreateState сохраняет состояние входа и заодно удаляет просроченные
+func (r *PostgresOAuthStateRepository) CreateState(ctx context.Context, state *entities.OAuthState) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM oauth_states WHERE expires_at < $1`, time.Now()); err != nil {
+		return mapError(err)
+	}
+
+	query := `INSERT INTO oauth_states (state_hash, provider, code_verifier, nonce, referral_code, user_id, expires_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(ctx, query, state.StateHash, state.Provider, state.CodeVerifier, state.Nonce, state.ReferralCode, state.UserID, state.ExpiresAt)
+	return mapError(err)
+}
+
+// ConsumeState удаляет состояние и возвращает его. Срок действия проверяет вызывающий
+func (r *PostgresOAuthStateRepository) ConsumeState(ctx context.Context, stateHash string) (*entities.OAuthState, error) {
+	query := `DELETE FROM oauth_states WHERE state_hash=$1
+			  RETURNING state_hash, provider, code_verifier, nonce, referral_code, user_id, expires_at`
+	var state entities.OAuthState
+	err := r.db.QueryRow(ctx, query, stateHash).
+		Scan(&state.StateHash, &state.Provider, &state.CodeVerifier, &state.Nonce, &state.ReferralCode, &state.UserID, &state.ExpiresAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,87 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserIdentityRepository_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserIdentityRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	identity := &entities.UserIdentity{UserID: user.ID, Provider: "google", Subject: "1234", Email: user.Email}
+	require.NoError(t, repo.CreateIdentity(ctx, identity))
+
+	found, err := repo.GetIdentity(ctx, "google", "1234")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.UserID)
+
+	_, err = repo.GetIdentity(ctx, "github", "1234")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	// Один внешний аккаунт нельзя привязать дважды
+	err = repo.CreateIdentity(ctx, &entities.UserIdentity{UserID: user.ID, Provider: "google", Subject: "1234", Email: user.Email})
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+}
+
+func TestUserIdentityRepository_ListIdentities(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserIdentityRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	other := createUser(t, db, "other@mail.com")
+	for _, identity := range []*entities.UserIdentity{
+		{UserID: user.ID, Provider: "google", Subject: "1", Email: user.Email},
+		{UserID: user.ID, Provider: "github", Subject: "2", Email: user.Email},
+		{UserID: other.ID, Provider: "google", Subject: "3", Email: other.Email},
+	} {
+		require.NoError(t, repo.CreateIdentity(ctx, identity))
+	}
+
+	identities, err := repo.ListIdentities(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, "google", identities[0].Provider)
+	assert.Equal(t, "github", identities[1].Provider)
+
+	identities, err = repo.ListIdentities(ctx, other.ID+1)
+	require.NoError(t, err)
+	assert.Empty(t, identities)
+}
+
+func TestOAuthStateRepository_ConsumeOnce(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresOAuthStateRepository(db)
+
+	code := "REFCODE"
+	state := &entities.OAuthState{
+		StateHash:    "hash",
+		Provider:     "google",
+		CodeVerifier: "verifier",
+		Nonce:        "nonce",
+		ReferralCode: &code,
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+	require.NoError(t, repo.CreateState(ctx, state))
+
+	found, err := repo.ConsumeState(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, "verifier", found.CodeVerifier)
+	require.NotNil(t, found.ReferralCode)
+	assert.Equal(t, code, *found.ReferralCode)
+	assert.Nil(t, found.UserID)
+
+	_, err = repo.ConsumeState(ctx, "hash")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresJobLockRepository реализация JobLockRepository для PostgreSQL
+type PostgresJobLockRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresJobLockRepository создает новый PostgresJobLockRepository
+func NewPostgresJobLockRepository(db *pgxpool.Pool) repositories.JobLockRepository {
+	return &PostgresJobLockRepository{db: db}
+}
+
+// AcquireJob берет сессионную advisory блокировку задачи на отдельном соединении, которое удерживается
+// до release, и отмечает запуск в job_runs, если он еще не выполнялся
+func (r *PostgresJobLockRepository) AcquireJob(ctx context.Context, name string, scheduledAt time.Time) (func(error), bool, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, mapError(err)
+	}
+
+	key := "job:" + name
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, mapError(err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Если разблокировать не удалось, соединение закрывается, и блокировка снимается вместе с сессией
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+
+	query := `INSERT INTO job_runs (name, scheduled_at, started_at) VALUES ($1, $2, $3)
+              ON CONFLICT (name) DO UPDATE
+              SET scheduled_at = EXCLUDED.scheduled_at, started_at = EXCLUDED.started_at, finished_at = NULL, error = ''
+              WHERE job_runs.scheduled_at < EXCLUDED.scheduled_at`
+	tag, err := conn.Exec(ctx, query, name, scheduledAt, time.Now())
+	if err != nil {
+		unlock()
+		return nil, false, mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		unlock()
+		return nil, false, nil
+	}
+
+	release := func(runErr error) {
+		message := ""
+		if runErr != nil {
+			message = runErr.Error()
+		}
+		// Результат сохраняется, даже если запуск прерван отменой контекста
+		_, _ = conn.Exec(context.Background(), `UPDATE job_runs SET finished_at=$2, error=$3 WHERE name=$1`,
+			name, time.Now(), message)
+		unlock()
+	}
+	return release, true, nil
+}
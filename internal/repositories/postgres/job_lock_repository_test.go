@@ -0,0 +1,44 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLockRepository_AcquireJob(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresJobLockRepository(db)
+
+	slot := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	release, acquired, err := repo.AcquireJob(ctx, "archive_expired_codes", slot)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// Пока задача выполняется, следующий запуск ее не получает
+	_, acquired, err = repo.AcquireJob(ctx, "archive_expired_codes", slot.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	release(errors.New("boom"))
+
+	var message string
+	require.NoError(t, db.QueryRow(ctx, `SELECT error FROM job_runs WHERE name=$1`, "archive_expired_codes").Scan(&message))
+	assert.Equal(t, "boom", message)
+
+	// Выполненный запуск не повторяется
+	_, acquired, err = repo.AcquireJob(ctx, "archive_expired_codes", slot)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	release, acquired, err = repo.AcquireJob(ctx, "archive_expired_codes", slot.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, acquired)
+	release(nil)
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// leaderboardColumns - столбцы, которые читаются в entities.LeaderboardEntry через scanLeaderboardEntry
+const leaderboardColumns = `l.rank, l.user_id, u.name, l.qualified, l.refreshed_at`
+
+// PostgresLeaderboardRepository реализация LeaderboardRepository для PostgreSQL
+type PostgresLeaderboardRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresLeaderboardRepository создает новый PostgresLeaderboardRepository
+func NewPostgresLeaderboardRepository(db *pgxpool.Pool) repositories.LeaderboardRepository {
+	return &PostgresLeaderboardRepository{db: db}
+}
+
+// scanLeaderboardEntry читает место в рейтинге из строки с leaderboardColumns
+func scanLeaderboardEntry(row pgx.Row) (*entities.LeaderboardEntry, error) {
+	entry := &entities.LeaderboardEntry{}
+	if err := row.Scan(&entry.Rank, &entry.UserID, &entry.Name, &entry.Qualified, &entry.RefreshedAt); err != nil {
+		return nil, mapError(err)
+	}
+	return entry, nil
+}
+
+// RefreshLeaderboard пересчитывает материализованное представление referral_leaderboard
+func (r *PostgresLeaderboardRepository) RefreshLeaderboard(ctx context.Context) error {
+	_, err := r.db.Exec(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY referral_leaderboard`)
+	return mapError(err)
+}
+
+// GetTopReferrers возвращает верх рейтинга. Отказ от участия учитывается сразу, не дожидаясь обновления снимка
+func (r *PostgresLeaderboardRepository) GetTopReferrers(ctx context.Context, period string, limit int) ([]*entities.LeaderboardEntry, error) {
+	query := `SELECT ` + leaderboardColumns + `
+              FROM referral_leaderboard l
+              JOIN users u ON u.id = l.user_id
+              WHERE l.period = $1 AND NOT u.leaderboard_opt_out AND u.deleted_at IS NULL
+              ORDER BY l.rank, l.user_id
+              LIMIT $2`
+	rows, err := r.db.Query(ctx, query, period, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var entries []*entities.LeaderboardEntry
+	for rows.Next() {
+		entry, err := scanLeaderboardEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, mapError(rows.Err())
+}
+
+// GetLeaderboardEntry возвращает место пользователя за период
+func (r *PostgresLeaderboardRepository) GetLeaderboardEntry(ctx context.Context, period string, userID int) (*entities.LeaderboardEntry, error) {
+	query := `SELECT ` + leaderboardColumns + `
+              FROM referral_leaderboard l
+              JOIN users u ON u.id = l.user_id
+              WHERE l.period = $1 AND l.user_id = $2`
+	return scanLeaderboardEntry(r.db.QueryRow(ctx, query, period, userID))
+}
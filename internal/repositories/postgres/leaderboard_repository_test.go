@@ -0,0 +1,60 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardRepository_RanksQualifiedReferrals(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	referralRepo := postgres.NewPostgresReferralRepository(db)
+	userRepo := postgres.NewPostgresUserRepository(db)
+	repo := postgres.NewPostgresLeaderboardRepository(db)
+
+	first := createUser(t, db, "first@mail.com")
+	second := createUser(t, db, "second@mail.com")
+
+	// first приводит двух квалифицированных рефералов, second - одного
+	for i, referrer := range []*entities.User{first, first, second} {
+		referee := createUser(t, db, "referee"+string(rune('a'+i))+"@mail.com")
+		referral := &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}
+		require.NoError(t, referralRepo.CreateReferralLink(ctx, referral))
+		_, _, err := referralRepo.QualifyReferral(ctx, referral.ID, time.Now())
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.RefreshLeaderboard(ctx))
+
+	entries, err := repo.GetTopReferrers(ctx, entities.LeaderboardAllTime, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, first.ID, entries[0].UserID)
+	assert.Equal(t, 1, entries[0].Rank)
+	assert.Equal(t, 2, entries[0].Qualified)
+
+	entry, err := repo.GetLeaderboardEntry(ctx, entities.LeaderboardWeek, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.Rank)
+
+	// Отказ от участия скрывает пользователя из списка сразу
+	optOut := true
+	_, err = userRepo.UpdateProfile(ctx, first.ID, &entities.ProfileUpdate{LeaderboardOptOut: &optOut})
+	require.NoError(t, err)
+
+	entries, err = repo.GetTopReferrers(ctx, entities.LeaderboardAllTime, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, second.ID, entries[0].UserID)
+
+	// а после обновления снимка - и из рейтинга
+	require.NoError(t, repo.RefreshLeaderboard(ctx))
+	_, err = repo.GetLeaderboardEntry(ctx, entities.LeaderboardAllTime, first.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
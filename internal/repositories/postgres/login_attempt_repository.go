@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresLoginAttemptRepository реализация LoginAttemptRepository для PostgreSQL
+type PostgresLoginAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresLoginAttemptRepository создает новый PostgresLoginAttemptRepository
+func NewPostgresLoginAttemptRepository(db *pgxpool.Pool) repositories.LoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db}
+}
+
+// GetLoginAttempt получает счетчик попыток по ключу
+func (r *PostgresLoginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	attempt := &entities.LoginAttempt{}
+	query := `SELECT key, failures, last_failure_at, locked_until FROM login_attempts WHERE key=$1`
+	err := r.db.QueryRow(ctx, query, key).Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailureAt, &attempt.LockedUntil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return attempt, nil
+}
+
+// RecordFailedLogin атомарно увеличивает счетчик неудачных попыток
+func (r *PostgresLoginAttemptRepository) RecordFailedLogin(ctx context.Context, key string, windowStart time.Time) (*entities.LoginAttempt, error) {
+	attempt := &entities.LoginAttempt{}
+	query := `INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $3)
+              ON CONFLICT (key) DO UPDATE
+              SET failures = CASE
+                      WHEN GREATEST(login_attempts.last_failure_at, login_attempts.locked_until) < $2 THEN 1
+                      ELSE login_attempts.failures + 1
+                  END,
+                  last_failure_at = EXCLUDED.last_failure_at
+              RETURNING key, failures, last_failure_at, locked_until`
+	err := r.db.QueryRow(ctx, query, key, windowStart, time.Now()).
+		Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailureAt, &attempt.LockedUntil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return attempt, nil
+}
+
+// LockLogin блокирует вход по ключу до указанного времени
+func (r *PostgresLoginAttemptRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until=$2 WHERE key=$1`
+	tag, err := r.db.Exec(ctx, query, key, until)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счетчик и блокировку по ключу
+func (r *PostgresLoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE key=$1`
+	_, err := r.db.Exec(ctx, query, key)
+	return mapError(err)
+}
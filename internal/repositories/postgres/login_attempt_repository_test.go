@@ -0,0 +1,54 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptRepository_RecordFailedLogin(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresLoginAttemptRepository(db)
+
+	_, err := repo.GetLoginAttempt(ctx, "email:example@mail.com")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	windowStart := time.Now().Add(-time.Minute)
+	for i := 1; i <= 3; i++ {
+		attempt, err := repo.RecordFailedLogin(ctx, "email:example@mail.com", windowStart)
+		require.NoError(t, err)
+		assert.Equal(t, i, attempt.Failures)
+	}
+
+	// Ошибки за пределами окна не учитываются
+	attempt, err := repo.RecordFailedLogin(ctx, "email:example@mail.com", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+}
+
+func TestLoginAttemptRepository_LockAndReset(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresLoginAttemptRepository(db)
+
+	assert.ErrorIs(t, repo.LockLogin(ctx, "ip:10.0.0.1", time.Now().Add(time.Minute)), repositories.ErrNotFound)
+
+	_, err := repo.RecordFailedLogin(ctx, "ip:10.0.0.1", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, repo.LockLogin(ctx, "ip:10.0.0.1", time.Now().Add(time.Minute)))
+
+	attempt, err := repo.GetLoginAttempt(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.NotNil(t, attempt.LockedUntil)
+
+	require.NoError(t, repo.ResetLoginAttempts(ctx, "ip:10.0.0.1"))
+
+	_, err = repo.GetLoginAttempt(ctx, "ip:10.0.0.1")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresMFAChallengeRepository реализация MFAChallengeRepository для PostgreSQL
+type PostgresMFAChallengeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresMFAChallengeRepository создает новый PostgresMFAChallengeRepository
+func NewPostgresMFAChallengeRepository(db *pgxpool.Pool) repositories.MFAChallengeRepository {
+	return &PostgresMFAChallengeRepository{db: db}
+}
+
+// UseMFAChallenge сохраняет jti токена. Повторная вставка того же jti возвращает ErrConflict
+func (r *PostgresMFAChallengeRepository) UseMFAChallenge(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `INSERT INTO used_mfa_challenges (jti, expires_at) VALUES ($1, $2)`
+	_, err := r.db.Exec(ctx, query, jti, expiresAt)
+	return mapError(err)
+}
+
+// PurgeMFAChallenges удаляет отметки токенов, срок которых истек: такие токены и так не пройдут проверку
+func (r *PostgresMFAChallengeRepository) PurgeMFAChallenges(ctx context.Context) (int, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM used_mfa_challenges WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,26 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMFAChallengeRepository_UseMFAChallenge(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresMFAChallengeRepository(db)
+
+	require.NoError(t, repo.UseMFAChallenge(ctx, "jti-1", time.Now().Add(time.Minute)))
+	assert.ErrorIs(t, repo.UseMFAChallenge(ctx, "jti-1", time.Now().Add(time.Minute)), repositories.ErrConflict)
+
+	require.NoError(t, repo.UseMFAChallenge(ctx, "jti-2", time.Now().Add(-time.Minute)))
+	purged, err := repo.PurgeMFAChallenges(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresNotificationRepository реализация NotificationRepository для PostgreSQL
+type PostgresNotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresNotificationRepository создает новый PostgresNotificationRepository
+func NewPostgresNotificationRepository(db *pgxpool.Pool) repositories.NotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+// GetPreferences возвращает настройки уведомлений пользователя
+func (r *PostgresNotificationRepository) GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error) {
+	rows, err := r.db.Query(ctx, `SELECT type, email FROM notification_preferences WHERE user_id=$1`, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	preferences := make(entities.NotificationPreferences)
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+		if err := rows.Scan(&notificationType, &enabled); err != nil {
+			return nil, mapError(err)
+		}
+		preferences[notificationType] = enabled
+	}
+	return preferences, mapError(rows.Err())
+}
+
+// SetPreferences сохраняет настройки одной пачкой
+func (r *PostgresNotificationRepository) SetPreferences(ctx context.Context, userID int, preferences entities.NotificationPreferences) error {
+	query := `INSERT INTO notification_preferences (user_id, type, email, updated_at) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (user_id, type) DO UPDATE SET email = EXCLUDED.email, updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for notificationType, enabled := range preferences {
+		batch.Queue(query, userID, notificationType, enabled, now)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+	for range preferences {
+		if _, err := results.Exec(); err != nil {
+			return mapError(err)
+		}
+	}
+	return nil
+}
+
+// ClaimNotification вставляет отметку, если уведомления с таким ключом еще нет
+func (r *PostgresNotificationRepository) ClaimNotification(ctx context.Context, notification *entities.SentNotification) (bool, error) {
+	query := `INSERT INTO sent_notifications (key, user_id, type, sent_at) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (key) DO NOTHING`
+	tag, err := r.db.Exec(ctx, query, notification.Key, notification.UserID, notification.Type, notification.SentAt)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseNotification удаляет отметку об уведомлении
+func (r *PostgresNotificationRepository) ReleaseNotification(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM sent_notifications WHERE key=$1`, key)
+	return mapError(err)
+}
@@ -0,0 +1,65 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRepository_Preferences(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresNotificationRepository(db)
+	user := createUser(t, db, "alice@mail.com")
+
+	preferences, err := repo.GetPreferences(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, preferences)
+
+	require.NoError(t, repo.SetPreferences(ctx, user.ID, entities.NotificationPreferences{
+		entities.NotificationReferralSignup: false,
+		entities.NotificationCodeExpiring:   false,
+	}))
+	// Повторное сохранение меняет только переданный тип
+	require.NoError(t, repo.SetPreferences(ctx, user.ID, entities.NotificationPreferences{entities.NotificationCodeExpiring: true}))
+
+	preferences, err = repo.GetPreferences(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.NotificationPreferences{
+		entities.NotificationReferralSignup: false,
+		entities.NotificationCodeExpiring:   true,
+	}, preferences)
+
+	err = repo.SetPreferences(ctx, user.ID+100, entities.NotificationPreferences{entities.NotificationCodeExpiring: false})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestNotificationRepository_ClaimNotification(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresNotificationRepository(db)
+	user := createUser(t, db, "alice@mail.com")
+
+	notification := &entities.SentNotification{Key: "evt_1:referral_signup:1", UserID: user.ID,
+		Type: entities.NotificationReferralSignup, SentAt: time.Now()}
+
+	claimed, err := repo.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// После снятия отметки уведомление можно отправить снова
+	require.NoError(t, repo.ReleaseNotification(ctx, notification.Key))
+	claimed, err = repo.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
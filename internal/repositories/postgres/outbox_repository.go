@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// outboxColumns - столбцы, которые читаются в entities.OutboxEvent
+const outboxColumns = `id, event_id, event_type, payload, attempts, last_error, next_attempt_at, created_at, published_at,
+    published_to, failed_at`
+
+// PostgresOutboxRepository реализация OutboxRepository для PostgreSQL
+type PostgresOutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresOutboxRepository создает новый PostgresOutboxRepository
+func NewPostgresOutboxRepository(db *pgxpool.Pool) repositories.OutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+// AddEvent сохраняет событие. Повторное сохранение события с тем же ID возвращает ErrConflict
+func (r *PostgresOutboxRepository) AddEvent(ctx context.Context, event *entities.OutboxEvent) error {
+	query := `INSERT INTO outbox (event_id, event_type, payload, next_attempt_at, created_at)
+              VALUES ($1, $2, $3, $4, $4) RETURNING id`
+	now := time.Now()
+	err := conn(ctx, r.db).QueryRow(ctx, query, event.EventID, event.EventType, event.Payload, now).Scan(&event.ID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	event.NextAttemptAt = now
+	event.CreatedAt = now
+	return nil
+}
+
+// ClaimPendingEvents забирает события с SKIP LOCKED, поэтому параллельные обработчики получают разные строки
+func (r *PostgresOutboxRepository) ClaimPendingEvents(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.OutboxEvent, error) {
+	query := `UPDATE outbox SET next_attempt_at = $2
+              WHERE id IN (
+                  SELECT id FROM outbox
+                  WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $1
+                  ORDER BY id
+                  LIMIT $3
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING ` + outboxColumns
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var events []*entities.OutboxEvent
+	for rows.Next() {
+		event := &entities.OutboxEvent{}
+		err := rows.Scan(&event.ID, &event.EventID, &event.EventType, &event.Payload, &event.Attempts, &event.LastError,
+			&event.NextAttemptAt, &event.CreatedAt, &event.PublishedAt, &event.PublishedTo, &event.FailedAt)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+
+	// UPDATE ... RETURNING не сохраняет порядок подзапроса
+	slices.SortFunc(events, func(a, b *entities.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+// UpdateEvent сохраняет число попыток, ошибку, время следующей попытки, время публикации или отказа
+// и принявших событие издателей
+func (r *PostgresOutboxRepository) UpdateEvent(ctx context.Context, event *entities.OutboxEvent) error {
+	// pgx записывает nil срез как NULL, а столбец NOT NULL
+	publishedTo := event.PublishedTo
+	if publishedTo == nil {
+		publishedTo = []string{}
+	}
+
+	query := `UPDATE outbox SET attempts=$2, last_error=$3, next_attempt_at=$4, published_at=$5, published_to=$6, failed_at=$7
+              WHERE id=$1`
+	tag, err := r.db.Exec(ctx, query, event.ID, event.Attempts, event.LastError, event.NextAttemptAt, event.PublishedAt,
+		publishedTo, event.FailedAt)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
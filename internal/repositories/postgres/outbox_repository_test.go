@@ -0,0 +1,81 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository_AddEvent_WithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresOutboxRepository(db)
+	transactor := postgres.NewPostgresTransactor(db)
+
+	// Событие откатывается вместе с транзакцией
+	rollback := errors.New("rollback")
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		event := &entities.OutboxEvent{EventID: "evt_1", EventType: entities.EventReferralCreated, Payload: []byte(`{}`)}
+		require.NoError(t, repo.AddEvent(ctx, event))
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback)
+
+	events, err := repo.ClaimPendingEvents(ctx, time.Now(), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return repo.AddEvent(ctx, &entities.OutboxEvent{EventID: "evt_1", EventType: entities.EventReferralCreated, Payload: []byte(`{}`)})
+	})
+	require.NoError(t, err)
+
+	err = repo.AddEvent(ctx, &entities.OutboxEvent{EventID: "evt_1", EventType: entities.EventReferralCreated, Payload: []byte(`{}`)})
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+}
+
+func TestOutboxRepository_ClaimPendingEvents(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresOutboxRepository(db)
+
+	for _, id := range []string{"evt_1", "evt_2", "evt_3"} {
+		require.NoError(t, repo.AddEvent(ctx, &entities.OutboxEvent{EventID: id, EventType: entities.EventReferralCreated,
+			Payload: []byte(`{"id":"` + id + `"}`)}))
+	}
+
+	now := time.Now()
+	claimed, err := repo.ClaimPendingEvents(ctx, now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, "evt_1", claimed[0].EventID)
+	assert.Equal(t, "evt_2", claimed[1].EventID)
+
+	// Отмеченное опубликованным событие больше не забирается
+	published := time.Now()
+	claimed[0].Attempts = 1
+	claimed[0].NextAttemptAt = now
+	claimed[0].PublishedAt = &published
+	require.NoError(t, repo.UpdateEvent(ctx, claimed[0]))
+
+	// Отброшенное после последней попытки событие тоже не забирается, а принявшие его издатели сохраняются
+	failed := time.Now()
+	claimed[1].Attempts = 50
+	claimed[1].NextAttemptAt = now
+	claimed[1].PublishedTo = []string{"webhook"}
+	claimed[1].FailedAt = &failed
+	require.NoError(t, repo.UpdateEvent(ctx, claimed[1]))
+
+	claimed, err = repo.ClaimPendingEvents(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "evt_3", claimed[0].EventID)
+	assert.Empty(t, claimed[0].PublishedTo)
+}
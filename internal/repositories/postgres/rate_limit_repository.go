@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresRateLimitRepository реализация RateLimitRepository для PostgreSQL. Время берется из базы,
+// поэтому корзины общие для всех экземпляров сервиса независимо от их часов
+type PostgresRateLimitRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRateLimitRepository создает новый PostgresRateLimitRepository
+func NewPostgresRateLimitRepository(db *pgxpool.Pool) repositories.RateLimitRepository {
+	return &PostgresRateLimitRepository{db: db}
+}
+
+// Take сдвигает время наполнения корзины на один интервал, если оно не уходит дальше емкости корзины.
+// Для отклоненного запроса условие не выполняется, строка не возвращается, и задержка читается отдельно
+func (r *PostgresRateLimitRepository) Take(ctx context.Context, key string, limit entities.RateLimit) (*entities.RateLimitResult, error) {
+	interval := limit.Interval().Seconds()
+	capacity := float64(limit.Burst) * interval
+
+	var delay float64
+	query := `INSERT INTO rate_limits AS r (key, tat) VALUES ($1, NOW() + make_interval(secs => $2))
+              ON CONFLICT (key) DO UPDATE
+              SET tat = GREATEST(r.tat, NOW()) + make_interval(secs => $2)
+              WHERE GREATEST(r.tat, NOW()) + make_interval(secs => $2) <= NOW() + make_interval(secs => $3)
+              RETURNING EXTRACT(EPOCH FROM r.tat - NOW())::float8`
+	err := mapError(r.db.QueryRow(ctx, query, key, interval, capacity).Scan(&delay))
+	if err == nil {
+		return limit.Result(seconds(delay), true), nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	query = `SELECT EXTRACT(EPOCH FROM tat - NOW())::float8 FROM rate_limits WHERE key=$1`
+	if err := r.db.QueryRow(ctx, query, key).Scan(&delay); err != nil {
+		return nil, mapError(err)
+	}
+	return limit.Result(seconds(delay), false), nil
+}
+
+// PurgeRateLimits удаляет корзины, время наполнения которых уже прошло
+func (r *PostgresRateLimitRepository) PurgeRateLimits(ctx context.Context) (int, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limits WHERE tat < NOW()`)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// seconds переводит секунды из EXTRACT(EPOCH ...) в time.Duration
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,52 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRepository_Take(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresRateLimitRepository(db)
+
+	limit := entities.RateLimit{Requests: 1, Period: time.Hour, Burst: 2}
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := repo.Take(ctx, "auth:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err := repo.Take(ctx, "auth:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, time.Hour.Seconds(), result.RetryAfter.Seconds(), 5)
+	assert.InDelta(t, (2 * time.Hour).Seconds(), result.Reset.Seconds(), 5)
+
+	// Корзины разных ключей независимы
+	result, err = repo.Take(ctx, "auth:ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimitRepository_PurgeRateLimits(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresRateLimitRepository(db)
+
+	_, err := repo.Take(ctx, "api:user:1", entities.RateLimit{Requests: 1, Period: time.Hour, Burst: 1})
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO rate_limits (key, tat) VALUES ('api:user:2', NOW() - INTERVAL '1 minute')`)
+	require.NoError(t, err)
+
+	purged, err := repo.PurgeRateLimits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
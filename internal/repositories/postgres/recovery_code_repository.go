@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresRecoveryCodeRepository реализация RecoveryCodeRepository для PostgreSQL
+type PostgresRecoveryCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRecoveryCodeRepository создает новый PostgresRecoveryCodeRepository
+func NewPostgresRecoveryCodeRepository(db *pgxpool.Pool) repositories.RecoveryCodeRepository {
+	return &PostgresRecoveryCodeRepository{db: db}
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя в одной транзакции
+func (r *PostgresRecoveryCodeRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return mapError(err)
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(ctx, query, userID, hash); err != nil {
+			return mapError(err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseRecoveryCode помечает код использованным
+func (r *PostgresRecoveryCodeRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	query := `UPDATE mfa_recovery_codes SET used_at=$3 WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID, codeHash, time.Now())
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteRecoveryCodes удаляет все коды восстановления пользователя
+func (r *PostgresRecoveryCodeRepository) DeleteRecoveryCodes(ctx context.Context, userID int) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID)
+	return mapError(err)
+}
@@ -0,0 +1,31 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryCodeRepository_UseRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresRecoveryCodeRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, user.ID, []string{"first", "second"}))
+
+	require.NoError(t, repo.UseRecoveryCode(ctx, user.ID, "first"))
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, user.ID, "first"), repositories.ErrNotFound)
+
+	// После перевыпуска старые коды больше не действуют
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, user.ID, []string{"third"}))
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, user.ID, "second"), repositories.ErrNotFound)
+	assert.NoError(t, repo.UseRecoveryCode(ctx, user.ID, "third"))
+
+	require.NoError(t, repo.DeleteRecoveryCodes(ctx, user.ID))
+	assert.ErrorIs(t, repo.UseRecoveryCode(ctx, user.ID, "third"), repositories.ErrNotFound)
+}
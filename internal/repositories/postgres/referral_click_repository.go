@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresReferralClickRepository реализация ReferralClickRepository для PostgreSQL
+type PostgresReferralClickRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresReferralClickRepository создает новый PostgresReferralClickRepository
+func NewPostgresReferralClickRepository(db *pgxpool.Pool) repositories.ReferralClickRepository {
+	return &PostgresReferralClickRepository{db: db}
+}
+
+// CreateClick сохраняет переход по ссылке
+func (r *PostgresReferralClickRepository) CreateClick(ctx context.Context, click *entities.ReferralClick) error {
+	query := `INSERT INTO referral_clicks (referral_code_id, referrer_id, campaign_id, referrer_url, utm_source, utm_medium,
+                                           utm_campaign, utm_term, utm_content, ip_hash, clicked_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	click.ClickedAt = time.Now()
+	err := r.db.QueryRow(ctx, query, click.ReferralCodeID, click.ReferrerID, click.CampaignID, click.ReferrerURL,
+		click.UTMSource, click.UTMMedium, click.UTMCampaign, click.UTMTerm, click.UTMContent, click.IPHash,
+		click.ClickedAt).Scan(&click.ID)
+	return mapError(err)
+}
+
+// ListClicksByUserID возвращает переходы по кодам пользователя от новых к старым, включая удаленные коды
+func (r *PostgresReferralClickRepository) ListClicksByUserID(ctx context.Context, userID int) ([]*entities.ReferralClick, error) {
+	query := `SELECT id, referral_code_id, referrer_id, campaign_id, referrer_url, utm_source, utm_medium, utm_campaign,
+                     utm_term, utm_content, ip_hash, clicked_at
+              FROM referral_clicks
+              WHERE referrer_id=$1
+              ORDER BY clicked_at DESC, id DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	clicks := []*entities.ReferralClick{}
+	for rows.Next() {
+		var click entities.ReferralClick
+		err := rows.Scan(&click.ID, &click.ReferralCodeID, &click.ReferrerID, &click.CampaignID, &click.ReferrerURL,
+			&click.UTMSource, &click.UTMMedium, &click.UTMCampaign, &click.UTMTerm, &click.UTMContent, &click.IPHash, &click.ClickedAt)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		clicks = append(clicks, &click)
+	}
+
+	return clicks, mapError(rows.Err())
+}
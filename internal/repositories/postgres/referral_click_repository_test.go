@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralClickRepository_ListClicksByUserID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	codeRepo := postgres.NewPostgresReferralCodeRepository(db)
+	repo := postgres.NewPostgresReferralClickRepository(db)
+
+	user := createUser(t, db, "referrer@mail.com")
+	other := createUser(t, db, "other@mail.com")
+	code := &entities.ReferralCode{UserID: user.ID, Code: "MINE", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, codeRepo.CreateReferralCode(ctx, code))
+	otherCode := &entities.ReferralCode{UserID: other.ID, Code: "OTHER", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, codeRepo.CreateReferralCode(ctx, otherCode))
+
+	for _, click := range []*entities.ReferralClick{
+		{ReferralCodeID: &code.ID, ReferrerID: &user.ID, UTMSource: "flyer", IPHash: "hash"},
+		{ReferralCodeID: &code.ID, ReferrerID: &user.ID, UTMSource: "email", IPHash: "hash"},
+		{ReferralCodeID: &otherCode.ID, ReferrerID: &other.ID, UTMSource: "flyer", IPHash: "hash"},
+	} {
+		require.NoError(t, repo.CreateClick(ctx, click))
+	}
+
+	clicks, err := repo.ListClicksByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, clicks, 2)
+	assert.Equal(t, "email", clicks[0].UTMSource, "newest first")
+	assert.Equal(t, code.ID, *clicks[1].ReferralCodeID)
+
+	// Удаление кода не стирает историю переходов
+	require.NoError(t, codeRepo.DeleteReferralCodeByUserID(ctx, user.ID))
+	clicks, err = repo.ListClicksByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, clicks, 2)
+	assert.Nil(t, clicks[0].ReferralCodeID)
+	assert.Equal(t, user.ID, *clicks[0].ReferrerID)
+}
@@ -2,15 +2,17 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"referral-system/internal/entities"
 	"referral-system/internal/repositories"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// referralCodeColumns - столбцы, которые читаются в entities.ReferralCode через scanReferralCode
+const referralCodeColumns = `id, user_id, code, expires_at, campaign_id, max_uses, batch_id`
+
 // PostgresReferralCodeRepository реализация ReferralRepository для PostgreSQL
 type PostgresReferralCodeRepository struct {
 	db *pgxpool.Pool
@@ -21,39 +23,154 @@ func NewPostgresReferralCodeRepository(db *pgxpool.Pool) repositories.ReferralCo
 	return &PostgresReferralCodeRepository{db: db}
 }
 
+// scanReferralCode читает реферальный код из строки с referralCodeColumns
+func scanReferralCode(row pgx.Row) (*entities.ReferralCode, error) {
+	referral := &entities.ReferralCode{}
+	err := row.Scan(&referral.ID, &referral.UserID, &referral.Code, &referral.ExpiresAt, &referral.CampaignID,
+		&referral.MaxUses, &referral.BatchID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return referral, nil
+}
+
 // CreateReferralCode создает новый реферальный код
-func (r *PostgresReferralCodeRepository) CreateReferralCode(referral *entities.ReferralCode) error {
-	query := `INSERT INTO referral_codes (user_id, code, expires_at, created_at) 
-              VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.db.QueryRow(context.Background(), query, referral.UserID, referral.Code, referral.ExpiresAt, time.Now()).Scan(&referral.ID)
-	return err
+func (r *PostgresReferralCodeRepository) CreateReferralCode(ctx context.Context, referral *entities.ReferralCode) error {
+	query := `INSERT INTO referral_codes (user_id, code, expires_at, campaign_id, max_uses, created_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := r.db.QueryRow(ctx, query, referral.UserID, referral.Code, referral.ExpiresAt, referral.CampaignID,
+		referral.MaxUses, time.Now()).Scan(&referral.ID)
+	return mapError(err)
 }
 
-// GetReferralCodeByUserID получает реферальный код по ID пользователя
-func (r *PostgresReferralCodeRepository) GetReferralCodeByUserID(userID int) (*entities.ReferralCode, error) {
-	referral := &entities.ReferralCode{}
-	query := `SELECT id, user_id, code, expires_at FROM referral_codes WHERE user_id=$1`
-	err := r.db.QueryRow(context.Background(), query, userID).Scan(&referral.ID, &referral.UserID, &referral.Code, &referral.ExpiresAt)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("referral code not found")
+// GetReferralCodeByUserID получает действующий личный реферальный код пользователя. Коды из партнерских пакетов
+// не учитываются, а личный код у пользователя один благодаря уникальному индексу
+func (r *PostgresReferralCodeRepository) GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error) {
+	query := `SELECT ` + referralCodeColumns + ` FROM referral_codes WHERE user_id=$1 AND batch_id IS NULL AND expires_at > $2`
+	return scanReferralCode(r.db.QueryRow(ctx, query, userID, time.Now()))
+}
+
+// ArchivePersonalCodeExpiredBefore переносит истекший личный код в referral_codes_archive. В отличие от
+// ArchiveExpiredCodes код архивируется, даже если по нему были переходы и регистрации: они хранят реферера
+// и кампанию сами, а связь с кодом обнуляется
+func (r *PostgresReferralCodeRepository) ArchivePersonalCodeExpiredBefore(ctx context.Context, userID int, before time.Time) error {
+	query := `WITH archived AS (
+                  DELETE FROM referral_codes
+                  WHERE user_id=$1 AND batch_id IS NULL AND expires_at <= $2
+                  RETURNING id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at
+              )
+              INSERT INTO referral_codes_archive (id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, archived_at)
+              SELECT id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, $3 FROM archived`
+	_, err := r.db.Exec(ctx, query, userID, before, time.Now())
+	return mapError(err)
+}
+
+// ListReferralCodesByUserID возвращает личный код и коды пакетов пользователя
+func (r *PostgresReferralCodeRepository) ListReferralCodesByUserID(ctx context.Context, userID int) ([]*entities.ReferralCode, error) {
+	query := `SELECT ` + referralCodeColumns + ` FROM referral_codes WHERE user_id=$1 ORDER BY id`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, mapError(err)
 	}
-	return referral, err
+	defer rows.Close()
+
+	codes := []*entities.ReferralCode{}
+	for rows.Next() {
+		code, err := scanReferralCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, mapError(rows.Err())
 }
 
-// DeleteReferralCodeByUserID удаляет реферальный код по ID пользователя
-func (r *PostgresReferralCodeRepository) DeleteReferralCodeByUserID(userID int) error {
-	query := `DELETE FROM referral_codes WHERE user_id=$1`
-	_, err := r.db.Exec(context.Background(), query, userID)
-	return err
+// DeleteReferralCodeByUserID удаляет личный реферальный код пользователя
+func (r *PostgresReferralCodeRepository) DeleteReferralCodeByUserID(ctx context.Context, userID int) error {
+	query := `DELETE FROM referral_codes WHERE user_id=$1 AND batch_id IS NULL`
+	tag, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// GetReferralByReferralCode получает реферальный код по его значению
+func (r *PostgresReferralCodeRepository) GetReferralByReferralCode(ctx context.Context, referralCode string) (*entities.ReferralCode, error) {
+	query := `SELECT ` + referralCodeColumns + ` FROM referral_codes WHERE code=$1`
+	return scanReferralCode(r.db.QueryRow(ctx, query, referralCode))
 }
 
-func (r *PostgresReferralCodeRepository) GetReferralByReferralCode(referralCode string) (*entities.ReferralCode, error) {
-	var referral = &entities.ReferralCode{}
-	query := `SELECT user_id, expires_at FROM referral_codes WHERE code=$1`
-	err := r.db.QueryRow(context.Background(), query, referralCode).Scan(&referral.UserID, &referral.ExpiresAt)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+// LockReferralCode блокирует строку кода FOR UPDATE. Вне транзакции блокировка снимается сразу
+func (r *PostgresReferralCodeRepository) LockReferralCode(ctx context.Context, id int) (*entities.ReferralCode, error) {
+	query := `SELECT ` + referralCodeColumns + ` FROM referral_codes WHERE id=$1 FOR UPDATE`
+	return scanReferralCode(conn(ctx, r.db).QueryRow(ctx, query, id))
+}
+
+// ArchiveExpiredCodes переносит коды в referral_codes_archive одним запросом. Коды с переходами
+// и регистрациями остаются: на них ссылается история и статистика
+func (r *PostgresReferralCodeRepository) ArchiveExpiredCodes(ctx context.Context, before time.Time, limit int) (int, error) {
+	query := `WITH archived AS (
+                  DELETE FROM referral_codes
+                  WHERE id IN (
+                      SELECT rc.id FROM referral_codes rc
+                      WHERE rc.expires_at < $1
+                        AND NOT EXISTS (SELECT 1 FROM referrals r WHERE r.referral_code_id = rc.id)
+                        AND NOT EXISTS (SELECT 1 FROM referral_clicks c WHERE c.referral_code_id = rc.id)
+                      ORDER BY rc.id
+                      LIMIT $2
+                      FOR UPDATE SKIP LOCKED
+                  )
+                  RETURNING id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at
+              )
+              INSERT INTO referral_codes_archive (id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, archived_at)
+              SELECT id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, $3 FROM archived`
+	tag, err := r.db.Exec(ctx, query, before, limit, time.Now())
+	if err != nil {
+		return 0, mapError(err)
 	}
+	return int(tag.RowsAffected()), nil
+}
 
-	return referral, err
+// ListExpiringCodes возвращает коды неудаленных пользователей, начиная с ближайших к истечению
+func (r *PostgresReferralCodeRepository) ListExpiringCodes(ctx context.Context, from, to time.Time, limit int) ([]*entities.ExpiringReferralCode, error) {
+	query := `SELECT rc.id, rc.code, rc.expires_at, u.id, u.email, u.name
+              FROM referral_codes rc
+              JOIN users u ON u.id = rc.user_id
+              WHERE rc.batch_id IS NULL AND rc.expiry_notified_at IS NULL
+                AND rc.expires_at > $1 AND rc.expires_at <= $2
+                AND u.deleted_at IS NULL
+              ORDER BY rc.expires_at
+              LIMIT $3`
+	rows, err := r.db.Query(ctx, query, from, to, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var codes []*entities.ExpiringReferralCode
+	for rows.Next() {
+		code := &entities.ExpiringReferralCode{}
+		if err := rows.Scan(&code.CodeID, &code.Code, &code.ExpiresAt, &code.UserID, &code.Email, &code.Name); err != nil {
+			return nil, mapError(err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, mapError(rows.Err())
+}
+
+// MarkExpiryNotified сохраняет время предупреждения об истечении кода
+func (r *PostgresReferralCodeRepository) MarkExpiryNotified(ctx context.Context, codeID int, at time.Time) error {
+	tag, err := r.db.Exec(ctx, `UPDATE referral_codes SET expiry_notified_at=$2 WHERE id=$1`, codeID, at)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
 }
@@ -0,0 +1,265 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralCodeRepository_CreateReferralCode_Conflict(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	first := createUser(t, db, "first@mail.com")
+	second := createUser(t, db, "second@mail.com")
+
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: first.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	err := repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: second.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+}
+
+func TestReferralCodeRepository_CreateReferralCode_UserNotFound(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	err := repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: 42, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestReferralCodeRepository_GetReferralCodeByUserID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+
+	code, err := repo.GetReferralCodeByUserID(ctx, user.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	assert.Nil(t, code)
+
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: user.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	code, err = repo.GetReferralCodeByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "CODE", code.Code)
+
+	_, err = db.Exec(ctx, `UPDATE referral_codes SET expires_at=$1 WHERE id=$2`, time.Now().Add(-time.Minute), code.ID)
+	require.NoError(t, err)
+	_, err = repo.GetReferralCodeByUserID(ctx, user.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestReferralCodeRepository_OnePersonalCodePerUser(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: user.ID, Code: "FIRST", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	err := repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: user.ID, Code: "SECOND", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+}
+
+func TestReferralCodeRepository_ArchivePersonalCodeExpiredBefore(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+	referralRepo := postgres.NewPostgresReferralRepository(db)
+
+	owner := createUser(t, db, "owner@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+
+	expired := &entities.ReferralCode{UserID: owner.ID, Code: "EXPIRED", ExpiresAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, repo.CreateReferralCode(ctx, expired))
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: referee.ID, Code: "ACTIVE", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, referralRepo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: owner.ID, RefereeID: referee.ID, ReferralCodeID: &expired.ID}))
+
+	require.NoError(t, repo.ArchivePersonalCodeExpiredBefore(ctx, owner.ID, time.Now()))
+	require.NoError(t, repo.ArchivePersonalCodeExpiredBefore(ctx, referee.ID, time.Now()))
+
+	_, err := repo.GetReferralByReferralCode(ctx, "EXPIRED")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	_, err = repo.GetReferralByReferralCode(ctx, "ACTIVE")
+	assert.NoError(t, err)
+
+	var code string
+	require.NoError(t, db.QueryRow(ctx, `SELECT code FROM referral_codes_archive WHERE id=$1`, expired.ID).Scan(&code))
+	assert.Equal(t, "EXPIRED", code)
+
+	referrals, err := referralRepo.GetReferralsByReferrerID(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, referrals, 1)
+
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: owner.ID, Code: "FRESH", ExpiresAt: time.Now().Add(time.Hour)}))
+}
+
+func TestReferralCodeRepository_DeleteReferralCodeByUserID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: user.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	require.NoError(t, repo.DeleteReferralCodeByUserID(ctx, user.ID))
+	assert.ErrorIs(t, repo.DeleteReferralCodeByUserID(ctx, user.ID), repositories.ErrNotFound)
+}
+
+func TestReferralCodeRepository_GetReferralByReferralCode(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: user.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	code, err := repo.GetReferralByReferralCode(ctx, "CODE")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, code.UserID)
+
+	code, err = repo.GetReferralByReferralCode(ctx, "MISSING")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	assert.Nil(t, code)
+}
+
+func TestReferralCodeRepository_LockReferralCode_WaitsForOtherTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+	transactor := postgres.NewPostgresTransactor(db)
+
+	user := createUser(t, db, "example@mail.com")
+	code := &entities.ReferralCode{UserID: user.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.CreateReferralCode(ctx, code))
+
+	_, err := repo.LockReferralCode(ctx, code.ID+1)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	locked, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.LockReferralCode(ctx, code.ID)
+			close(locked)
+			<-release
+			return err
+		})
+	}()
+	<-locked
+
+	go func() {
+		defer close(done)
+		_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.LockReferralCode(ctx, code.ID)
+			return err
+		})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second transaction must wait for the lock")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not released on commit")
+	}
+}
+
+func TestReferralCodeRepository_ArchiveExpiredCodes(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+	referralRepo := postgres.NewPostgresReferralRepository(db)
+
+	owner := createUser(t, db, "owner@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+	other := createUser(t, db, "other@mail.com")
+
+	expired := time.Now().Add(-48 * time.Hour)
+	unused := &entities.ReferralCode{UserID: owner.ID, Code: "UNUSED", ExpiresAt: expired}
+	used := &entities.ReferralCode{UserID: other.ID, Code: "USED", ExpiresAt: expired}
+	require.NoError(t, repo.CreateReferralCode(ctx, unused))
+	require.NoError(t, repo.CreateReferralCode(ctx, used))
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: referee.ID, Code: "ACTIVE", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, referralRepo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: other.ID, RefereeID: referee.ID, ReferralCodeID: &used.ID}))
+
+	archived, err := repo.ArchiveExpiredCodes(ctx, time.Now().Add(-24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	_, err = repo.GetReferralByReferralCode(ctx, "UNUSED")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	_, err = repo.GetReferralByReferralCode(ctx, "USED")
+	assert.NoError(t, err)
+
+	var code string
+	require.NoError(t, db.QueryRow(ctx, `SELECT code FROM referral_codes_archive WHERE id=$1`, unused.ID).Scan(&code))
+	assert.Equal(t, "UNUSED", code)
+}
+
+func TestReferralCodeRepository_ListExpiringCodes(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+
+	soon := createUser(t, db, "soon@mail.com")
+	later := createUser(t, db, "later@mail.com")
+
+	now := time.Now()
+	expiring := &entities.ReferralCode{UserID: soon.ID, Code: "SOON", ExpiresAt: now.Add(48 * time.Hour)}
+	require.NoError(t, repo.CreateReferralCode(ctx, expiring))
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: later.ID, Code: "LATER", ExpiresAt: now.Add(10 * 24 * time.Hour)}))
+
+	codes, err := repo.ListExpiringCodes(ctx, now, now.Add(72*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, codes, 1)
+	assert.Equal(t, "SOON", codes[0].Code)
+	assert.Equal(t, "soon@mail.com", codes[0].Email)
+
+	require.NoError(t, repo.MarkExpiryNotified(ctx, expiring.ID, now))
+	codes, err = repo.ListExpiringCodes(ctx, now, now.Add(72*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, codes)
+}
+
+func TestReferralCodeRepository_ListReferralCodesByUserID_IncludesBatchCodes(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralCodeRepository(db)
+	batchRepo := postgres.NewPostgresCodeBatchRepository(db)
+
+	campaign := newCampaign()
+	require.NoError(t, postgres.NewPostgresCampaignRepository(db).CreateCampaign(ctx, campaign))
+	partner := createUser(t, db, "partner@mail.com")
+	other := createUser(t, db, "other@mail.com")
+
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: partner.ID, Code: "PERSONAL", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, repo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: other.ID, Code: "OTHER", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	batch := &entities.CodeBatch{CampaignID: campaign.ID, PartnerID: partner.ID, Size: 1}
+	require.NoError(t, batchRepo.CreateBatch(ctx, batch))
+	_, err := batchRepo.InsertBatchCodes(ctx, []*entities.ReferralCode{
+		{UserID: partner.ID, Code: "PARTNER1", ExpiresAt: campaign.EndsAt, CampaignID: &campaign.ID, BatchID: &batch.ID},
+	})
+	require.NoError(t, err)
+
+	codes, err := repo.ListReferralCodesByUserID(ctx, partner.ID)
+	require.NoError(t, err)
+	require.Len(t, codes, 2)
+	assert.Equal(t, "PERSONAL", codes[0].Code)
+	assert.Equal(t, "PARTNER1", codes[1].Code)
+	require.NotNil(t, codes[1].BatchID)
+}
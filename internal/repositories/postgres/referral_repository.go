@@ -2,12 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"referral-system/internal/entities"
 	"referral-system/internal/repositories"
+	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// referralColumns - столбцы, которые читаются в entities.Referral через scanReferral
+const referralColumns = `id, referrer_id, referee_id, referral_code_id, campaign_id, referrer_reward, referee_reward, created_at, qualified_at`
+
 // PostgresReferralRepository реализация ReferralRepository для PostgreSQL
 type PostgresReferralRepository struct {
 	db *pgxpool.Pool
@@ -18,31 +24,148 @@ func NewPostgresReferralRepository(db *pgxpool.Pool) repositories.ReferralReposi
 	return &PostgresReferralRepository{db: db}
 }
 
-// CreateReferralLink создает связь между реферером и рефералом
-func (r *PostgresReferralRepository) CreateReferralLink(referrerID, refereeID int) error {
-	query := `INSERT INTO referrals (referrer_id, referee_id) VALUES ($1, $2)`
-	_, err := r.db.Exec(context.Background(), query, referrerID, refereeID)
-	return err
+// scanReferral читает связь из строки с referralColumns
+func scanReferral(row pgx.Row) (*entities.Referral, error) {
+	referral := &entities.Referral{}
+	err := row.Scan(&referral.ID, &referral.ReferrerID, &referral.RefereeID, &referral.ReferralCodeID,
+		&referral.CampaignID, &referral.ReferrerReward, &referral.RefereeReward, &referral.CreatedAt, &referral.QualifiedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return referral, nil
+}
+
+// CreateReferralLink создает связь между реферером и рефералом. Участвует в транзакции из контекста
+func (r *PostgresReferralRepository) CreateReferralLink(ctx context.Context, referral *entities.Referral) error {
+	query := `INSERT INTO referrals (referrer_id, referee_id, referral_code_id, campaign_id, referrer_reward, referee_reward, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	now := time.Now()
+	err := conn(ctx, r.db).QueryRow(ctx, query, referral.ReferrerID, referral.RefereeID, referral.ReferralCodeID, referral.CampaignID,
+		referral.ReferrerReward, referral.RefereeReward, now).Scan(&referral.ID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	referral.CreatedAt = now
+	return nil
+}
+
+// CountReferralsByCode считает регистрации по реферальному коду. Участвует в транзакции из контекста
+func (r *PostgresReferralRepository) CountReferralsByCode(ctx context.Context, referralCodeID int) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM referrals WHERE referral_code_id = $1`, referralCodeID).Scan(&count)
+	return count, mapError(err)
+}
+
+// CountReferralsByCampaign считает регистрации в рамках кампании. Участвует в транзакции из контекста
+func (r *PostgresReferralRepository) CountReferralsByCampaign(ctx context.Context, campaignID int) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM referrals WHERE campaign_id = $1`, campaignID).Scan(&count)
+	return count, mapError(err)
+}
+
+// CountQualifiedReferrals считает квалифицированных рефералов реферера. Участвует в транзакции из контекста
+func (r *PostgresReferralRepository) CountQualifiedReferrals(ctx context.Context, referrerID int) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM referrals WHERE referrer_id = $1 AND qualified_at IS NOT NULL`, referrerID).Scan(&count)
+	return count, mapError(err)
 }
 
 // GetReferralsByReferrerID получает список рефералов по ID реферера
-func (r *PostgresReferralRepository) GetReferralsByReferrerID(referrerID int) ([]*entities.Referral, error) {
-	query := `SELECT id, referrer_id, referee_id FROM referrals WHERE referrer_id = $1`
-	rows, err := r.db.Query(context.Background(), query, referrerID)
+func (r *PostgresReferralRepository) GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE referrer_id = $1`
+	rows, err := r.db.Query(ctx, query, referrerID)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer rows.Close()
 
 	var referrals []*entities.Referral
 	for rows.Next() {
-		referral := &entities.Referral{}
-		err := rows.Scan(&referral.ID, &referral.ReferrerID, &referral.RefereeID)
+		referral, err := scanReferral(rows)
 		if err != nil {
 			return nil, err
 		}
 		referrals = append(referrals, referral)
 	}
 
-	return referrals, nil
+	return referrals, mapError(rows.Err())
+}
+
+// GetReferralByRefereeID получает связь, по которой пользователь был приглашен
+func (r *PostgresReferralRepository) GetReferralByRefereeID(ctx context.Context, refereeID int) (*entities.Referral, error) {
+	query := `SELECT ` + referralColumns + ` FROM referrals WHERE referee_id = $1`
+	return scanReferral(r.db.QueryRow(ctx, query, refereeID))
+}
+
+// QualifyReferral отмечает реферала квалифицированным и возвращает связь. Участвует в транзакции из контекста
+func (r *PostgresReferralRepository) QualifyReferral(ctx context.Context, id int, at time.Time) (*entities.Referral, bool, error) {
+	query := `UPDATE referrals SET qualified_at = $2 WHERE id = $1 AND qualified_at IS NULL RETURNING ` + referralColumns
+	db := conn(ctx, r.db)
+	referral, err := scanReferral(db.QueryRow(ctx, query, id, at))
+	if err == nil {
+		return referral, true, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, false, err
+	}
+
+	// Связь уже квалифицирована или не существует
+	referral, err = scanReferral(db.QueryRow(ctx, `SELECT `+referralColumns+` FROM referrals WHERE id = $1`, id))
+	return referral, false, err
+}
+
+// referralStatsQuery строит шаги периода и присоединяет к ним агрегаты по каждому событию воронки.
+// Переходы хранят реферера и кампанию сами, поэтому удаление кода не меняет статистику
+const referralStatsQuery = `
+WITH buckets AS (
+    SELECT start FROM generate_series(date_trunc($1, $2::timestamp), $3::timestamp, ('1 ' || $1)::interval) AS start
+    WHERE start < $3
+),
+clicks AS (
+    SELECT date_trunc($1, c.clicked_at) AS start, COUNT(*) AS n
+    FROM referral_clicks c
+    WHERE c.clicked_at >= $2 AND c.clicked_at < $3
+      AND ($4::int IS NULL OR c.referrer_id = $4) AND ($5::int IS NULL OR c.campaign_id = $5)
+    GROUP BY 1
+),
+signups AS (
+    SELECT date_trunc($1, created_at) AS start, COUNT(*) AS n
+    FROM referrals
+    WHERE created_at >= $2 AND created_at < $3
+      AND ($4::int IS NULL OR referrer_id = $4) AND ($5::int IS NULL OR campaign_id = $5)
+    GROUP BY 1
+),
+qualified AS (
+    SELECT date_trunc($1, qualified_at) AS start, COUNT(*) AS n
+    FROM referrals
+    WHERE qualified_at >= $2 AND qualified_at < $3
+      AND ($4::int IS NULL OR referrer_id = $4) AND ($5::int IS NULL OR campaign_id = $5)
+    GROUP BY 1
+)
+SELECT b.start, COALESCE(c.n, 0), COALESCE(s.n, 0), COALESCE(q.n, 0)
+FROM buckets b
+LEFT JOIN clicks c ON c.start = b.start
+LEFT JOIN signups s ON s.start = b.start
+LEFT JOIN qualified q ON q.start = b.start
+ORDER BY b.start`
+
+// GetReferralStatsSeries считает статистику одним запросом
+func (r *PostgresReferralRepository) GetReferralStatsSeries(ctx context.Context, filter entities.ReferralStatsFilter) ([]*entities.ReferralStatsBucket, error) {
+	rows, err := r.db.Query(ctx, referralStatsQuery, filter.Interval, filter.From, filter.To, filter.ReferrerID, filter.CampaignID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var series []*entities.ReferralStatsBucket
+	for rows.Next() {
+		bucket := &entities.ReferralStatsBucket{}
+		if err := rows.Scan(&bucket.Start, &bucket.Clicks, &bucket.Signups, &bucket.Qualified); err != nil {
+			return nil, mapError(err)
+		}
+		series = append(series, bucket)
+	}
+
+	return series, mapError(rows.Err())
 }
@@ -0,0 +1,138 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralRepository_CreateReferralLink_Conflict(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+
+	require.NoError(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}))
+	assert.ErrorIs(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}), repositories.ErrConflict)
+}
+
+func TestReferralRepository_CreateReferralLink_UserNotFound(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referee := createUser(t, db, "referee@mail.com")
+
+	assert.ErrorIs(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referee.ID + 1, RefereeID: referee.ID}), repositories.ErrNotFound)
+}
+
+func TestReferralRepository_GetReferralsByReferrerID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+
+	referrals, err := repo.GetReferralsByReferrerID(ctx, referrer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, referrals)
+
+	require.NoError(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}))
+
+	referrals, err = repo.GetReferralsByReferrerID(ctx, referrer.ID)
+	require.NoError(t, err)
+	require.Len(t, referrals, 1)
+	assert.Equal(t, referee.ID, referrals[0].RefereeID)
+}
+
+func TestReferralRepository_GetReferralByRefereeID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+
+	_, err := repo.GetReferralByRefereeID(ctx, referee.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	require.NoError(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}))
+
+	referral, err := repo.GetReferralByRefereeID(ctx, referee.ID)
+	require.NoError(t, err)
+	assert.Equal(t, referrer.ID, referral.ReferrerID)
+}
+
+func TestReferralRepository_QualifyReferral(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+
+	referral := &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}
+	require.NoError(t, repo.CreateReferralLink(ctx, referral))
+
+	first := time.Now().Truncate(time.Second)
+	qualified, updated, err := repo.QualifyReferral(ctx, referral.ID, first)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	require.NotNil(t, qualified.QualifiedAt)
+	assert.True(t, first.Equal(*qualified.QualifiedAt))
+
+	// Повторная квалификация сохраняет первое время
+	qualified, updated, err = repo.QualifyReferral(ctx, referral.ID, first.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.True(t, first.Equal(*qualified.QualifiedAt))
+
+	_, _, err = repo.QualifyReferral(ctx, referral.ID+1, first)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestReferralRepository_GetReferralStatsSeries(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	other := createUser(t, db, "other@mail.com")
+
+	today := time.Now().Truncate(24 * time.Hour)
+	for i, email := range []string{"a@mail.com", "b@mail.com"} {
+		referee := createUser(t, db, email)
+		referral := &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}
+		require.NoError(t, repo.CreateReferralLink(ctx, referral))
+		_, err := db.Exec(ctx, `UPDATE referrals SET created_at=$2 WHERE id=$1`, referral.ID, today.Add(-time.Duration(i)*24*time.Hour))
+		require.NoError(t, err)
+		if i == 0 {
+			_, _, err = repo.QualifyReferral(ctx, referral.ID, today.Add(time.Hour))
+			require.NoError(t, err)
+		}
+	}
+	referee := createUser(t, db, "c@mail.com")
+	require.NoError(t, repo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: other.ID, RefereeID: referee.ID}))
+
+	series, err := repo.GetReferralStatsSeries(ctx, entities.ReferralStatsFilter{
+		ReferrerID: &referrer.ID,
+		From:       today.Add(-2 * 24 * time.Hour),
+		To:         today.Add(24 * time.Hour),
+		Interval:   entities.StatsIntervalDay,
+	})
+	require.NoError(t, err)
+	require.Len(t, series, 3)
+	assert.Equal(t, 0, series[0].Signups)
+	assert.Equal(t, 1, series[1].Signups)
+	assert.Equal(t, 1, series[2].Signups)
+	assert.Equal(t, 1, series[2].Qualified)
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresTierRepository реализация TierRepository для PostgreSQL
+type PostgresTierRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresTierRepository создает новый PostgresTierRepository
+func NewPostgresTierRepository(db *pgxpool.Pool) repositories.TierRepository {
+	return &PostgresTierRepository{db: db}
+}
+
+// LockUserTier блокирует строку пользователя FOR UPDATE, чтобы одновременные пересчеты не записали смену дважды
+func (r *PostgresTierRepository) LockUserTier(ctx context.Context, userID int) (*string, error) {
+	var tier *string
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT tier FROM users WHERE id=$1 FOR UPDATE`, userID).Scan(&tier)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return tier, nil
+}
+
+// SaveTierChange обновляет уровень пользователя и добавляет запись в tier_changes
+func (r *PostgresTierRepository) SaveTierChange(ctx context.Context, change *entities.TierChange) error {
+	db := conn(ctx, r.db)
+	tag, err := db.Exec(ctx, `UPDATE users SET tier=$2 WHERE id=$1`, change.UserID, change.ToTier)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+
+	query := `INSERT INTO tier_changes (user_id, from_tier, to_tier, qualified, changed_at)
+              VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err = db.QueryRow(ctx, query, change.UserID, change.FromTier, change.ToTier, change.Qualified, change.ChangedAt).Scan(&change.ID)
+	return mapError(err)
+}
+
+// ListTierChanges возвращает историю уровней пользователя, начиная с последней смены
+func (r *PostgresTierRepository) ListTierChanges(ctx context.Context, userID int) ([]*entities.TierChange, error) {
+	query := `SELECT id, user_id, from_tier, to_tier, qualified, changed_at
+              FROM tier_changes WHERE user_id=$1 ORDER BY changed_at DESC, id DESC`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var changes []*entities.TierChange
+	for rows.Next() {
+		change := &entities.TierChange{}
+		if err := rows.Scan(&change.ID, &change.UserID, &change.FromTier, &change.ToTier, &change.Qualified, &change.ChangedAt); err != nil {
+			return nil, mapError(err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, mapError(rows.Err())
+}
@@ -0,0 +1,92 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTierRepository_SaveTierChange_RecordsChanges(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresTierRepository(db)
+
+	user := createUser(t, db, "referrer@mail.com")
+	bronze, silver := "bronze", "silver"
+
+	tier, err := repo.LockUserTier(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, tier)
+
+	change := &entities.TierChange{UserID: user.ID, ToTier: &bronze, Qualified: 5, ChangedAt: time.Now()}
+	require.NoError(t, repo.SaveTierChange(ctx, change))
+	assert.NotZero(t, change.ID)
+
+	change = &entities.TierChange{UserID: user.ID, FromTier: &bronze, ToTier: &silver, Qualified: 20, ChangedAt: time.Now()}
+	require.NoError(t, repo.SaveTierChange(ctx, change))
+
+	tier, err = repo.LockUserTier(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, tier)
+	assert.Equal(t, silver, *tier)
+
+	changes, err := repo.ListTierChanges(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, silver, *changes[0].ToTier)
+	assert.Equal(t, bronze, *changes[0].FromTier)
+	assert.Equal(t, 20, changes[0].Qualified)
+
+	err = repo.SaveTierChange(ctx, &entities.TierChange{UserID: user.ID + 1, ToTier: &bronze, ChangedAt: time.Now()})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestTierRepository_LockUserTier_WaitsForOtherTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresTierRepository(db)
+	transactor := postgres.NewPostgresTransactor(db)
+
+	user := createUser(t, db, "referrer@mail.com")
+
+	_, err := repo.LockUserTier(ctx, user.ID+1)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	locked, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.LockUserTier(ctx, user.ID)
+			close(locked)
+			<-release
+			return err
+		})
+	}()
+	<-locked
+
+	go func() {
+		defer close(done)
+		_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.LockUserTier(ctx, user.ID)
+			return err
+		})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second transaction must wait for the lock")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not released on commit")
+	}
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"referral-system/internal/repositories"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type txKey struct{}
+
+// querier - общие методы pgxpool.Pool и pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn возвращает транзакцию из контекста, а без нее - пул
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// PostgresTransactor реализация Transactor для PostgreSQL
+type PostgresTransactor struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresTransactor создает новый PostgresTransactor
+func NewPostgresTransactor(db *pgxpool.Pool) repositories.Transactor {
+	return &PostgresTransactor{db: db}
+}
+
+// WithinTransaction выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку.
+// Вложенный вызов использует транзакцию внешнего
+func (t *PostgresTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return mapError(tx.Commit(ctx))
+}
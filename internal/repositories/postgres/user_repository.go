@@ -2,15 +2,20 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"referral-system/internal/entities"
 	"referral-system/internal/repositories"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// userColumns - столбцы, которые читаются в entities.User через scanUser
+const userColumns = `id, name, email, password, role, pending_email, email_verification_token,
+	email_verification_expires_at, token_version, totp_secret, totp_enabled, totp_last_step,
+	leaderboard_opt_out, locale, created_at, updated_at, deleted_at`
+
 // PostgresUserRepository реализация UserRepository для PostgreSQL
 type PostgresUserRepository struct {
 	db *pgxpool.Pool
@@ -21,32 +26,174 @@ func NewPostgresUserRepository(db *pgxpool.Pool) repositories.UserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
+// scanUser читает пользователя из строки с userColumns
+func scanUser(row pgx.Row) (*entities.User, error) {
+	user := &entities.User{}
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.HashedPassword, &user.Role, &user.PendingEmail,
+		&user.EmailVerificationToken, &user.EmailVerificationExpiresAt, &user.TokenVersion,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, &user.LeaderboardOptOut, &user.Locale, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return user, nil
+}
+
 // CreateUser создает нового пользователя в базе данных
-func (r *PostgresUserRepository) CreateUser(user *entities.User) error {
-	query := `INSERT INTO users (name, email, password, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	err := r.db.QueryRow(context.Background(), query, user.Name, user.Email, user.HashedPassword, time.Now(), time.Now()).Scan(&user.ID)
-	return err
+func (r *PostgresUserRepository) CreateUser(ctx context.Context, user *entities.User) error {
+	now := time.Now()
+	query := `INSERT INTO users (name, email, password, locale, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, role`
+	err := conn(ctx, r.db).QueryRow(ctx, query, user.Name, user.Email, user.HashedPassword, user.Locale, now, now).Scan(&user.ID, &user.Role)
+	if err != nil {
+		return mapError(err)
+	}
+
+	user.CreatedAt, user.UpdatedAt = now, now
+	return nil
 }
 
 // GetUserByEmail находит пользователя по email
-func (r *PostgresUserRepository) GetUserByEmail(email string) (*entities.User, error) {
-	user := &entities.User{}
-	query := `SELECT id, name, email, password FROM users WHERE email=$1`
-	err := r.db.QueryRow(context.Background(), query, email).Scan(&user.ID, &user.Name, &user.Email, &user.HashedPassword)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
-	}
-	return user, err
+func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email=$1`
+	return scanUser(r.db.QueryRow(ctx, query, email))
 }
 
 // GetUserByID находит пользователя по ID
-func (r *PostgresUserRepository) GetUserByID(id int) (*entities.User, error) {
-	user := &entities.User{}
-	query := `SELECT id, name, email, password FROM users WHERE id=$1`
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&user.ID, &user.Name, &user.Email, &user.HashedPassword)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+func (r *PostgresUserRepository) GetUserByID(ctx context.Context, id int) (*entities.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id=$1`
+	return scanUser(r.db.QueryRow(ctx, query, id))
+}
+
+// UpdateProfile меняет только переданные поля профиля
+func (r *PostgresUserRepository) UpdateProfile(ctx context.Context, userID int, update *entities.ProfileUpdate) (*entities.User, error) {
+	query := `UPDATE users
+              SET name=COALESCE($2, name), leaderboard_opt_out=COALESCE($3, leaderboard_opt_out),
+                  locale=COALESCE($4, locale), updated_at=$5
+              WHERE id=$1 AND deleted_at IS NULL
+              RETURNING ` + userColumns
+	return scanUser(r.db.QueryRow(ctx, query, userID, update.Name, update.LeaderboardOptOut, update.Locale, time.Now()))
+}
+
+// SetPendingEmail сохраняет email, ожидающий подтверждения
+func (r *PostgresUserRepository) SetPendingEmail(ctx context.Context, userID int, email, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE users
+              SET pending_email=$2, email_verification_token=$3, email_verification_expires_at=$4, updated_at=$5
+              WHERE id=$1 AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, email, tokenHash, expiresAt, time.Now())
+}
+
+// ConfirmEmail применяет ожидающий email. Если токен успели заменить или он истек, строка не меняется
+func (r *PostgresUserRepository) ConfirmEmail(ctx context.Context, userID int, tokenHash string) error {
+	query := `UPDATE users
+              SET email=pending_email, pending_email=NULL, email_verification_token=NULL,
+                  email_verification_expires_at=NULL, updated_at=$3
+              WHERE id=$1 AND email_verification_token=$2 AND email_verification_expires_at > $3
+                  AND pending_email IS NOT NULL AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, tokenHash, time.Now())
+}
+
+// UpdatePassword меняет пароль и отзывает выданные токены увеличением token_version
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, userID int, hashedPassword string) (int, error) {
+	query := `UPDATE users SET password=$2, token_version=token_version + 1, updated_at=$3
+              WHERE id=$1 AND deleted_at IS NULL
+              RETURNING token_version`
+	var tokenVersion int
+	if err := r.db.QueryRow(ctx, query, userID, hashedPassword, time.Now()).Scan(&tokenVersion); err != nil {
+		return 0, mapError(err)
 	}
-	return user, err
+	return tokenVersion, nil
+}
+
+// SetTOTPSecret заменяет секрет еще не включенного второго фактора
+func (r *PostgresUserRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `UPDATE users SET totp_secret=$2, totp_last_step=0, updated_at=$3
+              WHERE id=$1 AND NOT totp_enabled AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, secret, time.Now())
+}
+
+// EnableTOTP включает второй фактор с тем секретом, по которому проверен код
+func (r *PostgresUserRepository) EnableTOTP(ctx context.Context, userID int, secret string, step int64) error {
+	query := `UPDATE users SET totp_enabled=TRUE, totp_last_step=$3, updated_at=$4
+              WHERE id=$1 AND totp_secret=$2 AND NOT totp_enabled AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, secret, step, time.Now())
+}
+
+// DisableTOTP отключает второй фактор
+func (r *PostgresUserRepository) DisableTOTP(ctx context.Context, userID int) error {
+	query := `UPDATE users SET totp_enabled=FALSE, totp_secret=NULL, totp_last_step=0, updated_at=$2
+              WHERE id=$1 AND totp_enabled AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, time.Now())
+}
+
+// exec выполняет обновление одного пользователя. Если строка не изменилась, возвращается ErrNotFound
+func (r *PostgresUserRepository) exec(ctx context.Context, query string, args ...any) error {
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// UseTOTPStep сдвигает totp_last_step одним условным обновлением
+func (r *PostgresUserRepository) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	query := `UPDATE users SET totp_last_step=$2, updated_at=$3
+              WHERE id=$1 AND totp_enabled AND totp_last_step < $2 AND deleted_at IS NULL`
+	return r.exec(ctx, query, userID, step, time.Now())
+}
+
+// AnonymizeUser стирает персональные данные пользователя и удаляет его реферальные коды, коды восстановления,
+// счетчики входа по email и настройки уведомлений, сохраняя строку users, чтобы статистика рефералов не пострадала.
+// Все изменения выполняются в одной транзакции, вложенной в транзакцию из контекста, если она есть
+func (r *PostgresUserRepository) AnonymizeUser(ctx context.Context, id int) error {
+	return NewPostgresTransactor(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		db := conn(ctx, r.db)
+		now := time.Now()
+
+		// Прежние адреса нужны, чтобы удалить счетчики входа, ключ которых содержит email
+		var emails []string
+		query := `SELECT ARRAY_REMOVE(ARRAY[email, pending_email], NULL) FROM users WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`
+		if err := db.QueryRow(ctx, query, id).Scan(&emails); err != nil {
+			return mapError(err)
+		}
+
+		query = `UPDATE users
+                 SET name='Deleted user', email='deleted-' || id || '@deleted.invalid', password='',
+                     pending_email=NULL, email_verification_token=NULL, email_verification_expires_at=NULL,
+                     totp_secret=NULL, totp_enabled=FALSE, token_version=token_version + 1, updated_at=$2, deleted_at=$2
+                 WHERE id=$1`
+		if _, err := db.Exec(ctx, query, id, now); err != nil {
+			return mapError(err)
+		}
+
+		// Ключ счетчика строится так же, как в services.accountKey
+		keys := make([]string, 0, len(emails))
+		for _, email := range emails {
+			keys = append(keys, "email:"+strings.ToLower(strings.TrimSpace(email)))
+		}
+
+		statements := []struct {
+			query string
+			args  []any
+		}{
+			{`DELETE FROM referral_codes WHERE user_id=$1`, []any{id}},
+			{`DELETE FROM referral_codes_archive WHERE user_id=$1`, []any{id}},
+			{`DELETE FROM mfa_recovery_codes WHERE user_id=$1`, []any{id}},
+			{`UPDATE api_keys SET revoked_at=$2 WHERE user_id=$1 AND revoked_at IS NULL`, []any{id, now}},
+			// Отвязываем внешние аккаунты, чтобы через них нельзя было войти в удаленный аккаунт
+			{`DELETE FROM user_identities WHERE user_id=$1`, []any{id}},
+			{`DELETE FROM oauth_states WHERE user_id=$1`, []any{id}},
+			{`DELETE FROM login_attempts WHERE key = ANY($1)`, []any{keys}},
+			{`DELETE FROM notification_preferences WHERE user_id=$1`, []any{id}},
+			{`DELETE FROM sent_notifications WHERE user_id=$1`, []any{id}},
+		}
+		for _, statement := range statements {
+			if _, err := db.Exec(ctx, statement.query, statement.args...); err != nil {
+				return mapError(err)
+			}
+		}
+		return nil
+	})
 }
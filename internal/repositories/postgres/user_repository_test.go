@@ -0,0 +1,249 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_CreateUser_Conflict(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	createUser(t, db, "example@mail.com")
+
+	err := repo.CreateUser(ctx, &entities.User{Name: "other", Email: "example@mail.com", HashedPassword: "hash"})
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+}
+
+func TestUserRepository_GetUserByEmail(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	created := createUser(t, db, "example@mail.com")
+
+	user, err := repo.GetUserByEmail(ctx, "example@mail.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+
+	user, err = repo.GetUserByEmail(ctx, "missing@mail.com")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	assert.Nil(t, user)
+}
+
+func TestUserRepository_GetUserByID(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	created := createUser(t, db, "example@mail.com")
+
+	user, err := repo.GetUserByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Email, user.Email)
+
+	user, err = repo.GetUserByID(ctx, created.ID+1)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	assert.Nil(t, user)
+}
+
+func TestUserRepository_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+
+	name := "renamed"
+	updated, err := repo.UpdateProfile(ctx, user.ID, &entities.ProfileUpdate{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.True(t, updated.UpdatedAt.After(user.UpdatedAt))
+
+	// Незаданные поля не меняются
+	locale := "ru"
+	updated, err = repo.UpdateProfile(ctx, user.ID, &entities.ProfileUpdate{Locale: &locale})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, &locale, updated.Locale)
+
+	_, err = repo.UpdateProfile(ctx, user.ID+100, &entities.ProfileUpdate{Name: &name})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	// Удаленного пользователя изменение не восстанавливает
+	require.NoError(t, repo.AnonymizeUser(ctx, user.ID))
+	_, err = repo.UpdateProfile(ctx, user.ID, &entities.ProfileUpdate{Name: &name})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestUserRepository_ConfirmEmail(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	createUser(t, db, "taken@mail.com")
+
+	require.NoError(t, repo.SetPendingEmail(ctx, user.ID, "new@mail.com", "first", time.Now().Add(time.Hour)))
+	require.NoError(t, repo.SetPendingEmail(ctx, user.ID, "new@mail.com", "second", time.Now().Add(time.Hour)))
+	// Токен заменен новым запросом
+	assert.ErrorIs(t, repo.ConfirmEmail(ctx, user.ID, "first"), repositories.ErrNotFound)
+	require.NoError(t, repo.ConfirmEmail(ctx, user.ID, "second"))
+
+	stored, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new@mail.com", stored.Email)
+	assert.Nil(t, stored.PendingEmail)
+
+	require.NoError(t, repo.SetPendingEmail(ctx, user.ID, "taken@mail.com", "third", time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, repo.ConfirmEmail(ctx, user.ID, "third"), repositories.ErrConflict)
+
+	require.NoError(t, repo.SetPendingEmail(ctx, user.ID, "other@mail.com", "expired", time.Now().Add(-time.Minute)))
+	assert.ErrorIs(t, repo.ConfirmEmail(ctx, user.ID, "expired"), repositories.ErrNotFound)
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+
+	tokenVersion, err := repo.UpdatePassword(ctx, user.ID, "new-hash")
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokenVersion)
+
+	// Параллельное изменение профиля не возвращает старый пароль и версию токенов
+	name := "renamed"
+	_, err = repo.UpdateProfile(ctx, user.ID, &entities.ProfileUpdate{Name: &name})
+	require.NoError(t, err)
+
+	stored, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", stored.HashedPassword)
+	assert.Equal(t, 1, stored.TokenVersion)
+
+	_, err = repo.UpdatePassword(ctx, user.ID+100, "hash")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestUserRepository_TOTP(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+
+	require.NoError(t, repo.SetTOTPSecret(ctx, user.ID, "first"))
+	require.NoError(t, repo.SetTOTPSecret(ctx, user.ID, "second"))
+	// Код проверен по замененному секрету
+	assert.ErrorIs(t, repo.EnableTOTP(ctx, user.ID, "first", 10), repositories.ErrNotFound)
+	require.NoError(t, repo.EnableTOTP(ctx, user.ID, "second", 10))
+
+	stored, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.TOTPEnabled)
+	assert.Equal(t, int64(10), stored.TOTPLastStep)
+
+	// Секрет включенного второго фактора не заменяется
+	assert.ErrorIs(t, repo.SetTOTPSecret(ctx, user.ID, "third"), repositories.ErrNotFound)
+
+	require.NoError(t, repo.DisableTOTP(ctx, user.ID))
+	assert.ErrorIs(t, repo.DisableTOTP(ctx, user.ID), repositories.ErrNotFound)
+
+	stored, err = repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.TOTPEnabled)
+	assert.Nil(t, stored.TOTPSecret)
+}
+
+func TestUserRepository_UseTOTPStep(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+
+	user := createUser(t, db, "example@mail.com")
+	// Без включенного TOTP шаг не принимается
+	assert.ErrorIs(t, repo.UseTOTPStep(ctx, user.ID, 100), repositories.ErrNotFound)
+
+	_, err := db.Exec(ctx, `UPDATE users SET totp_enabled=TRUE WHERE id=$1`, user.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UseTOTPStep(ctx, user.ID, 100))
+	// Тот же и более старый шаг повторно не принимаются
+	assert.ErrorIs(t, repo.UseTOTPStep(ctx, user.ID, 100), repositories.ErrNotFound)
+	assert.ErrorIs(t, repo.UseTOTPStep(ctx, user.ID, 99), repositories.ErrNotFound)
+	require.NoError(t, repo.UseTOTPStep(ctx, user.ID, 101))
+
+	stored, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(101), stored.TOTPLastStep)
+}
+
+func TestUserRepository_AnonymizeUser(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresUserRepository(db)
+	codeRepo := postgres.NewPostgresReferralCodeRepository(db)
+	referralRepo := postgres.NewPostgresReferralRepository(db)
+
+	referrer := createUser(t, db, "referrer@mail.com")
+	referee := createUser(t, db, "referee@mail.com")
+	require.NoError(t, codeRepo.CreateReferralCode(ctx, &entities.ReferralCode{UserID: referee.ID, Code: "CODE", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, referralRepo.CreateReferralLink(ctx, &entities.Referral{ReferrerID: referrer.ID, RefereeID: referee.ID}))
+
+	// Данные, в которых остаются email и действия пользователя
+	_, err := postgres.NewPostgresLoginAttemptRepository(db).RecordFailedLogin(ctx, "email:referee@mail.com", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = postgres.NewPostgresLoginAttemptRepository(db).RecordFailedLogin(ctx, "email:referrer@mail.com", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, postgres.NewPostgresOAuthStateRepository(db).CreateState(ctx, &entities.OAuthState{
+		StateHash: "hash", Provider: "google", CodeVerifier: "verifier", Nonce: "nonce", UserID: &referee.ID,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+	notificationRepo := postgres.NewPostgresNotificationRepository(db)
+	require.NoError(t, notificationRepo.SetPreferences(ctx, referee.ID, entities.NotificationPreferences{entities.NotificationRewardGranted: false}))
+	_, err = notificationRepo.ClaimNotification(ctx, &entities.SentNotification{
+		Key: "evt:reward_granted:2", UserID: referee.ID, Type: entities.NotificationRewardGranted, SentAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AnonymizeUser(ctx, referee.ID))
+
+	for _, table := range []string{"oauth_states", "notification_preferences", "sent_notifications"} {
+		var count int
+		require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM `+table+` WHERE user_id=$1`, referee.ID).Scan(&count))
+		assert.Zero(t, count, table)
+	}
+	_, err = postgres.NewPostgresLoginAttemptRepository(db).GetLoginAttempt(ctx, "email:referee@mail.com")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	_, err = postgres.NewPostgresLoginAttemptRepository(db).GetLoginAttempt(ctx, "email:referrer@mail.com")
+	assert.NoError(t, err, "other users' counters are kept")
+
+	stored, err := repo.GetUserByID(ctx, referee.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, "referee@mail.com", stored.Email)
+	assert.NotNil(t, stored.DeletedAt)
+	assert.Equal(t, referee.TokenVersion+1, stored.TokenVersion)
+
+	_, err = repo.GetUserByEmail(ctx, "referee@mail.com")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	_, err = codeRepo.GetReferralCodeByUserID(ctx, referee.ID)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	// Статистика реферера сохраняется
+	referrals, err := referralRepo.GetReferralsByReferrerID(ctx, referrer.ID)
+	require.NoError(t, err)
+	assert.Len(t, referrals, 1)
+
+	assert.ErrorIs(t, repo.AnonymizeUser(ctx, referee.ID), repositories.ErrNotFound)
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// webhookSubscriptionColumns - столбцы, которые читаются в entities.WebhookSubscription через scanWebhookSubscription
+const webhookSubscriptionColumns = `id, url, secret, event_types, created_by, created_at`
+
+// webhookDeliveryColumns - столбцы, которые читаются в entities.WebhookDelivery через scanWebhookDelivery
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, response_status,
+	error, next_attempt_at, created_at, delivered_at, replay_of`
+
+// PostgresWebhookRepository реализация WebhookRepository для PostgreSQL
+type PostgresWebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresWebhookRepository создает новый PostgresWebhookRepository
+func NewPostgresWebhookRepository(db *pgxpool.Pool) repositories.WebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// scanWebhookSubscription читает подписку из строки с webhookSubscriptionColumns
+func scanWebhookSubscription(row pgx.Row) (*entities.WebhookSubscription, error) {
+	subscription := &entities.WebhookSubscription{}
+	err := row.Scan(&subscription.ID, &subscription.URL, &subscription.Secret, &subscription.EventTypes,
+		&subscription.CreatedBy, &subscription.CreatedAt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return subscription, nil
+}
+
+// scanWebhookDelivery читает доставку из строки с webhookDeliveryColumns
+func scanWebhookDelivery(row pgx.Row) (*entities.WebhookDelivery, error) {
+	delivery := &entities.WebhookDelivery{}
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.Error, &delivery.NextAttemptAt,
+		&delivery.CreatedAt, &delivery.DeliveredAt, &delivery.ReplayOf)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return delivery, nil
+}
+
+func (r *PostgresWebhookRepository) querySubscriptions(ctx context.Context, query string, args ...any) ([]*entities.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*entities.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, mapError(rows.Err())
+}
+
+func (r *PostgresWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]*entities.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []*entities.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, mapError(rows.Err())
+}
+
+// CreateSubscription создает подписку
+func (r *PostgresWebhookRepository) CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscriptions (url, secret, event_types, created_by, created_at)
+              VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	now := time.Now()
+	err := r.db.QueryRow(ctx, query, subscription.URL, subscription.Secret, subscription.EventTypes,
+		subscription.CreatedBy, now).Scan(&subscription.ID)
+	if err != nil {
+		return mapError(err)
+	}
+
+	subscription.CreatedAt = now
+	return nil
+}
+
+// GetSubscription находит подписку по ID
+func (r *PostgresWebhookRepository) GetSubscription(ctx context.Context, id int) (*entities.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id=$1`
+	return scanWebhookSubscription(r.db.QueryRow(ctx, query, id))
+}
+
+// ListSubscriptions возвращает все подписки
+func (r *PostgresWebhookRepository) ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	return r.querySubscriptions(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+// ListSubscriptionsForEvent возвращает подписки, в чьих event_types есть eventType
+func (r *PostgresWebhookRepository) ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*entities.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE $1 = ANY(event_types) ORDER BY id`
+	return r.querySubscriptions(ctx, query, eventType)
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом ее доставок
+func (r *PostgresWebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id=$1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+// CreateDelivery ставит доставку в очередь
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	return insertDelivery(ctx, r.db, delivery, time.Now())
+}
+
+// CreateDeliveries сохраняет доставки в одной транзакции
+func (r *PostgresWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*entities.WebhookDelivery) error {
+	return NewPostgresTransactor(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for _, delivery := range deliveries {
+			if err := insertDelivery(ctx, conn(ctx, r.db), delivery, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertDelivery сохраняет доставку через пул или транзакцию. Если событие уже поставлено подписке в очередь,
+// новая строка не создается и ID доставки остается нулевым. Повторы из журнала под это правило не попадают
+func insertDelivery(ctx context.Context, db querier, delivery *entities.WebhookDelivery, now time.Time) error {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at, replay_of)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              ON CONFLICT (subscription_id, event_id) WHERE replay_of IS NULL DO NOTHING
+              RETURNING id`
+	err := db.QueryRow(ctx, query, delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Payload,
+		delivery.Status, delivery.NextAttemptAt, now, delivery.ReplayOf).Scan(&delivery.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return mapError(err)
+	}
+
+	delivery.CreatedAt = now
+	return nil
+}
+
+// GetDelivery находит доставку по ID
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, id int64) (*entities.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id=$1`
+	return scanWebhookDelivery(r.db.QueryRow(ctx, query, id))
+}
+
+// ListDeliveries возвращает журнал доставок подписки, начиная с последних
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int, limit int) ([]*entities.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+              WHERE subscription_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2`
+	return r.queryDeliveries(ctx, query, subscriptionID, limit)
+}
+
+// ClaimDueDeliveries забирает доставки с SKIP LOCKED, поэтому параллельные обработчики получают разные строки
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2
+              WHERE id IN (
+                  SELECT id FROM webhook_deliveries
+                  WHERE status = 'pending' AND next_attempt_at <= $1
+                  ORDER BY next_attempt_at
+                  LIMIT $3
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING ` + webhookDeliveryColumns
+	return r.queryDeliveries(ctx, query, now, now.Add(lease), limit)
+}
+
+// UpdateDelivery сохраняет статус, число попыток и результат последней попытки
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries
+              SET status=$2, attempts=$3, response_status=$4, error=$5, next_attempt_at=$6, delivered_at=$7
+              WHERE id=$1`
+	tag, err := r.db.Exec(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.ResponseStatus,
+		delivery.Error, delivery.NextAttemptAt, delivery.DeliveredAt)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
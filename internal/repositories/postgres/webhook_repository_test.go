@@ -0,0 +1,147 @@
+package postgres_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/repositories/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepository_ListSubscriptionsForEvent(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresWebhookRepository(db)
+
+	created := &entities.WebhookSubscription{URL: "https://example.com/a", Secret: "whsec_a",
+		EventTypes: []string{entities.EventReferralCreated}}
+	require.NoError(t, repo.CreateSubscription(ctx, created))
+	require.NoError(t, repo.CreateSubscription(ctx, &entities.WebhookSubscription{URL: "https://example.com/b",
+		Secret: "whsec_b", EventTypes: []string{entities.EventReferralQualified}}))
+
+	subscriptions, err := repo.ListSubscriptionsForEvent(ctx, entities.EventReferralCreated)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, created.ID, subscriptions[0].ID)
+	assert.Equal(t, "whsec_a", subscriptions[0].Secret)
+
+	require.NoError(t, repo.DeleteSubscription(ctx, created.ID))
+	assert.ErrorIs(t, repo.DeleteSubscription(ctx, created.ID), repositories.ErrNotFound)
+}
+
+func TestWebhookRepository_ClaimDueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresWebhookRepository(db)
+
+	subscription := &entities.WebhookSubscription{URL: "https://example.com/a", Secret: "whsec_a",
+		EventTypes: []string{entities.EventReferralCreated}}
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	now := time.Now()
+	later := now.Add(time.Hour)
+	due := &entities.WebhookDelivery{SubscriptionID: subscription.ID, EventID: "evt_1", EventType: entities.EventReferralCreated,
+		Payload: []byte(`{"id":"evt_1"}`), Status: entities.DeliveryStatusPending, NextAttemptAt: &now}
+	require.NoError(t, repo.CreateDelivery(ctx, due))
+	require.NoError(t, repo.CreateDelivery(ctx, &entities.WebhookDelivery{SubscriptionID: subscription.ID, EventID: "evt_2",
+		EventType: entities.EventReferralCreated, Payload: []byte(`{}`), Status: entities.DeliveryStatusPending, NextAttemptAt: &later}))
+
+	claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.ID, claimed[0].ID)
+	assert.JSONEq(t, `{"id":"evt_1"}`, string(claimed[0].Payload))
+
+	// Забранная доставка скрыта до окончания аренды
+	claimed, err = repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	status := 200
+	delivered := time.Now()
+	due.Status = entities.DeliveryStatusSucceeded
+	due.Attempts = 1
+	due.ResponseStatus = &status
+	due.NextAttemptAt = nil
+	due.DeliveredAt = &delivered
+	require.NoError(t, repo.UpdateDelivery(ctx, due))
+
+	deliveries, err := repo.ListDeliveries(ctx, subscription.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+
+	saved, err := repo.GetDelivery(ctx, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.DeliveryStatusSucceeded, saved.Status)
+	assert.Equal(t, 200, *saved.ResponseStatus)
+	assert.Nil(t, saved.NextAttemptAt)
+}
+
+func TestWebhookRepository_CreateDeliveries_AllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresWebhookRepository(db)
+
+	subscription := &entities.WebhookSubscription{URL: "https://example.com/a", Secret: "whsec_a",
+		EventTypes: []string{entities.EventReferralCreated}}
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	now := time.Now()
+	delivery := func(subscriptionID int) *entities.WebhookDelivery {
+		return &entities.WebhookDelivery{SubscriptionID: subscriptionID, EventID: "evt_1", EventType: entities.EventReferralCreated,
+			Payload: []byte(`{}`), Status: entities.DeliveryStatusPending, NextAttemptAt: &now}
+	}
+
+	// Вторая подписка не существует, поэтому не сохраняется и первая доставка
+	err := repo.CreateDeliveries(ctx, []*entities.WebhookDelivery{delivery(subscription.ID), delivery(subscription.ID + 1)})
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	deliveries, err := repo.ListDeliveries(ctx, subscription.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	created := delivery(subscription.ID)
+	require.NoError(t, repo.CreateDeliveries(ctx, []*entities.WebhookDelivery{created}))
+	assert.NotZero(t, created.ID)
+}
+
+func TestWebhookRepository_CreateDeliveries_SkipsPublishedEvent(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	repo := postgres.NewPostgresWebhookRepository(db)
+
+	subscription := &entities.WebhookSubscription{URL: "https://example.com/a", Secret: "whsec_a",
+		EventTypes: []string{entities.EventReferralCreated}}
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	now := time.Now()
+	delivery := func() *entities.WebhookDelivery {
+		return &entities.WebhookDelivery{SubscriptionID: subscription.ID, EventID: "evt_1", EventType: entities.EventReferralCreated,
+			Payload: []byte(`{}`), Status: entities.DeliveryStatusPending, NextAttemptAt: &now}
+	}
+
+	first := delivery()
+	require.NoError(t, repo.CreateDeliveries(ctx, []*entities.WebhookDelivery{first}))
+	require.NotZero(t, first.ID)
+
+	// Повторная публикация того же события не создает вторую доставку
+	again := delivery()
+	require.NoError(t, repo.CreateDeliveries(ctx, []*entities.WebhookDelivery{again}))
+	assert.Zero(t, again.ID)
+
+	// Повтор из журнала создается, несмотря на тот же ID события
+	replay := delivery()
+	replay.ReplayOf = &first.ID
+	require.NoError(t, repo.CreateDelivery(ctx, replay))
+	assert.NotZero(t, replay.ID)
+
+	deliveries, err := repo.ListDeliveries(ctx, subscription.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	require.NotNil(t, deliveries[0].ReplayOf)
+	assert.Equal(t, first.ID, *deliveries[0].ReplayOf)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// RateLimitRepository интерфейс для хранения корзин ограничения частоты запросов. Корзина хранится
+// как время ее полного наполнения (GCRA), поэтому подходит любое хранилище с атомарным обновлением
+// одного значения по ключу
+type RateLimitRepository interface {
+	// Take забирает маркер из корзины key, если он есть. Отклоненный запрос корзину не меняет
+	Take(ctx context.Context, key string, limit entities.RateLimit) (*entities.RateLimitResult, error)
+	// PurgeRateLimits удаляет наполненные корзины, они не отличаются от отсутствующих
+	PurgeRateLimits(ctx context.Context) (int, error)
+}
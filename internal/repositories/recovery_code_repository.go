@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+)
+
+// RecoveryCodeRepository интерфейс для работы с кодами восстановления второго фактора
+type RecoveryCodeRepository interface {
+	// ReplaceRecoveryCodes удаляет старые коды пользователя и сохраняет новые хеши
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	// UseRecoveryCode помечает неиспользованный код использованным или возвращает ErrNotFound
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	DeleteRecoveryCodes(ctx context.Context, userID int) error
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// ReferralClickRepository интерфейс для учета переходов по реферальным ссылкам
+type ReferralClickRepository interface {
+	CreateClick(ctx context.Context, click *entities.ReferralClick) error
+	// ListClicksByUserID возвращает переходы по всем кодам пользователя, в том числе удаленным
+	ListClicksByUserID(ctx context.Context, userID int) ([]*entities.ReferralClick, error)
+}
@@ -1,13 +1,30 @@
 package repositories
 
 import (
+	"context"
 	"referral-system/internal/entities"
+	"time"
 )
 
 // ReferralCodeRepository интерфейс для работы с реферальными кодами
 type ReferralCodeRepository interface {
-	CreateReferralCode(referral *entities.ReferralCode) error
-	GetReferralCodeByUserID(userID int) (*entities.ReferralCode, error)
-	DeleteReferralCodeByUserID(userID int) error
-	GetReferralByReferralCode(referralCode string) (*entities.ReferralCode, error)
+	CreateReferralCode(ctx context.Context, referral *entities.ReferralCode) error
+	// GetReferralCodeByUserID возвращает действующий личный код пользователя. Истекший код считается отсутствующим
+	GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error)
+	// ArchivePersonalCodeExpiredBefore переносит в архив личный код пользователя, истекший раньше before,
+	// чтобы на его место можно было выпустить новый. Если такого кода нет, ничего не делает
+	ArchivePersonalCodeExpiredBefore(ctx context.Context, userID int, before time.Time) error
+	// ListReferralCodesByUserID возвращает все коды пользователя, включая коды партнерских пакетов
+	ListReferralCodesByUserID(ctx context.Context, userID int) ([]*entities.ReferralCode, error)
+	DeleteReferralCodeByUserID(ctx context.Context, userID int) error
+	GetReferralByReferralCode(ctx context.Context, referralCode string) (*entities.ReferralCode, error)
+	// LockReferralCode блокирует код до конца транзакции из контекста и возвращает его актуальное состояние
+	LockReferralCode(ctx context.Context, id int) (*entities.ReferralCode, error)
+	// ArchiveExpiredCodes переносит в архив до limit кодов, истекших раньше before, по которым не было
+	// переходов и регистраций, и возвращает их число
+	ArchiveExpiredCodes(ctx context.Context, before time.Time, limit int) (int, error)
+	// ListExpiringCodes возвращает личные коды, истекающие в (from, to], о которых владелец еще не предупрежден
+	ListExpiringCodes(ctx context.Context, from, to time.Time, limit int) ([]*entities.ExpiringReferralCode, error)
+	// MarkExpiryNotified отмечает, что владелец кода предупрежден об истечении
+	MarkExpiryNotified(ctx context.Context, codeID int, at time.Time) error
 }
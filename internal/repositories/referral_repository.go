@@ -1,11 +1,24 @@
 package repositories
 
 import (
+	"context"
 	"referral-system/internal/entities"
+	"time"
 )
 
 // ReferralRepository интерфейс для работы с рефералами
 type ReferralRepository interface {
-	CreateReferralLink(referrerID, refereeID int) error
-	GetReferralsByReferrerID(referrerID int) ([]*entities.Referral, error)
+	// CreateReferralLink, QualifyReferral и подсчеты рефералов выполняются в транзакции Transactor,
+	// если она есть в контексте
+	CreateReferralLink(ctx context.Context, referral *entities.Referral) error
+	CountReferralsByCode(ctx context.Context, referralCodeID int) (int, error)
+	CountReferralsByCampaign(ctx context.Context, campaignID int) (int, error)
+	CountQualifiedReferrals(ctx context.Context, referrerID int) (int, error)
+	GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error)
+	GetReferralByRefereeID(ctx context.Context, refereeID int) (*entities.Referral, error)
+	// QualifyReferral отмечает реферала квалифицированным и сообщает, был ли он квалифицирован этим вызовом.
+	// Повторный вызов не меняет qualified_at
+	QualifyReferral(ctx context.Context, id int, at time.Time) (*entities.Referral, bool, error)
+	// GetReferralStatsSeries считает переходы, регистрации и квалификации по шагам периода, включая пустые шаги
+	GetReferralStatsSeries(ctx context.Context, filter entities.ReferralStatsFilter) ([]*entities.ReferralStatsBucket, error)
 }
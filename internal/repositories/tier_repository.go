@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+)
+
+// TierRepository интерфейс для работы с уровнями рефереров
+type TierRepository interface {
+	// LockUserTier блокирует строку пользователя до конца транзакции из контекста и возвращает его уровень
+	LockUserTier(ctx context.Context, userID int) (*string, error)
+	// SaveTierChange сохраняет новый уровень пользователя и пишет смену в историю в транзакции из контекста
+	SaveTierChange(ctx context.Context, change *entities.TierChange) error
+	ListTierChanges(ctx context.Context, userID int) ([]*entities.TierChange, error)
+}
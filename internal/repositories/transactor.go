@@ -0,0 +1,9 @@
+package repositories
+
+import "context"
+
+// Transactor выполняет несколько вызовов репозиториев в одной транзакции.
+// Репозитории, поддерживающие это, берут транзакцию из переданного в fn контекста
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -1,12 +1,39 @@
 package repositories
 
 import (
+	"context"
 	"referral-system/internal/entities"
+	"time"
 )
 
 // UserRepository интерфейс для работы с пользователями
 type UserRepository interface {
-	CreateUser(user *entities.User) error
-	GetUserByEmail(email string) (*entities.User, error)
-	GetUserByID(id int) (*entities.User, error)
+	// CreateUser выполняется в транзакции Transactor, если она есть в контексте
+	CreateUser(ctx context.Context, user *entities.User) error
+	GetUserByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetUserByID(ctx context.Context, id int) (*entities.User, error)
+
+	// Каждое изменение обновляет только свои столбцы и не затрагивает удаленных пользователей,
+	// поэтому параллельные изменения не затирают друг друга. Если пользователя нет или условие изменения
+	// не выполнено, возвращается ErrNotFound
+
+	// UpdateProfile меняет переданные поля профиля и возвращает пользователя после изменения
+	UpdateProfile(ctx context.Context, userID int, update *entities.ProfileUpdate) (*entities.User, error)
+	// SetPendingEmail сохраняет новый email до подтверждения и хеш токена подтверждения
+	SetPendingEmail(ctx context.Context, userID int, email, tokenHash string, expiresAt time.Time) error
+	// ConfirmEmail заменяет email ожидающим подтверждения, если хеш токена все еще tokenHash и токен не истек
+	ConfirmEmail(ctx context.Context, userID int, tokenHash string) error
+	// UpdatePassword меняет хеш пароля, увеличивает версию токенов и возвращает новую версию
+	UpdatePassword(ctx context.Context, userID int, hashedPassword string) (int, error)
+	// SetTOTPSecret сохраняет новый секрет TOTP, пока второй фактор не включен
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	// EnableTOTP включает второй фактор, если секрет все еще secret, и сохраняет принятый шаг
+	EnableTOTP(ctx context.Context, userID int, secret string, step int64) error
+	// DisableTOTP отключает второй фактор и стирает секрет
+	DisableTOTP(ctx context.Context, userID int) error
+	// UseTOTPStep сохраняет принятый временной шаг TOTP, если он новее последнего принятого,
+	// иначе возвращает ErrNotFound. Так один код нельзя принять дважды даже в параллельных запросах
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+
+	AnonymizeUser(ctx context.Context, id int) error
 }
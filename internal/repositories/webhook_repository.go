@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"time"
+)
+
+// WebhookRepository интерфейс для работы с подписками на вебхуки и журналом доставок
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int) (*entities.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error)
+	// ListSubscriptionsForEvent возвращает подписки на события данного типа
+	ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*entities.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+
+	CreateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	// CreateDeliveries сохраняет доставки одного события: либо все, либо ни одной. Доставка события,
+	// уже поставленного подписке в очередь, пропускается и остается с нулевым ID
+	CreateDeliveries(ctx context.Context, deliveries []*entities.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*entities.WebhookDelivery, error)
+	// ListDeliveries возвращает последние limit доставок подписки
+	ListDeliveries(ctx context.Context, subscriptionID int, limit int) ([]*entities.WebhookDelivery, error)
+	// ClaimDueDeliveries забирает ожидающие доставки, чье время наступило, и откладывает их на lease,
+	// чтобы другие экземпляры сервиса не отправили их одновременно
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.WebhookDelivery, error)
+	// UpdateDelivery сохраняет результат попытки
+	UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+}
@@ -2,7 +2,10 @@ package routes
 
 import (
 	"net/http"
+	"referral-system/internal/auth"
 	"referral-system/internal/controllers"
+	"referral-system/internal/i18n"
+	"referral-system/internal/infrastructure/jwtkeys"
 	"referral-system/internal/middlewares"
 	"time"
 
@@ -10,37 +13,155 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, authController *controllers.AuthController, referralController *controllers.ReferralController, jwtSecret string) {
+func RegisterRoutes(router *gin.Engine, authController *controllers.AuthController, referralController *controllers.ReferralController,
+	referralLinkController *controllers.ReferralLinkController, qrController *controllers.QRController,
+	referralStatsController *controllers.ReferralStatsController, leaderboardController *controllers.LeaderboardController,
+	tierController *controllers.TierController, userController *controllers.UserController,
+	mfaController *controllers.MFAController, oauthController *controllers.OAuthController,
+	apiKeyController *controllers.APIKeyController, campaignController *controllers.CampaignController,
+	codeBatchController *controllers.CodeBatchController, webhookController *controllers.WebhookController,
+	notificationController *controllers.NotificationController, adminController *controllers.AdminController, tokens middlewares.TokenParser,
+	sessions middlewares.SessionValidator, apiKeys middlewares.APIKeyAuthenticator, rateLimiter *middlewares.RateLimiter, keys *jwtkeys.KeySet, dbTimeout time.Duration) {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept-Language"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Language", "Retry-After", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	router.Use(middlewares.LocaleMiddleware())
+	router.Use(middlewares.TimeoutMiddleware(dbTimeout))
+
+	// Открытые ключи для проверки наших токенов другими сервисами
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
+
+	// Реферальные ссылки
+	router.GET("/r/:code", rateLimiter.Limit("codes"), referralLinkController.Redirect)
+
+	// Отписка по ссылке из письма. POST без тела присылают почтовые клиенты при отписке в один клик
+	router.GET("/notifications/unsubscribe", notificationController.UnsubscribePage)
+	router.POST("/notifications/unsubscribe", notificationController.Unsubscribe)
 
 	// Маршруты для аутентификации
-	auth := router.Group("/auth")
+	login := router.Group("/auth")
+	login.Use(rateLimiter.Limit("auth"))
 	{
-		auth.POST("/login", authController.Login)
-		auth.POST("/register", authController.Register)
+		login.POST("/login", authController.Login)
+		login.POST("/login/mfa", authController.LoginMFA)
+		login.POST("/register", authController.Register)
+		login.GET("/oauth/:provider", oauthController.Authorize)
+		login.GET("/oauth/:provider/callback", oauthController.Callback)
 	}
 
+	// Все защищенные маршруты принимают JWT или API ключ
+	authenticated := middlewares.AuthMiddleware(tokens, sessions, apiKeys)
+	session := middlewares.RequireSession()
+	apiLimit := rateLimiter.Limit("api")
+
 	// Защищенные маршруты
 	protected := router.Group("/referrals")
-	protected.Use(middlewares.JWTMiddleware(jwtSecret))
+	protected.Use(authenticated, apiLimit)
+	{
+		protected.POST("/", middlewares.RequireScope(auth.ScopeReferralsWrite), referralController.CreateReferralCode)
+		protected.DELETE("/", middlewares.RequireScope(auth.ScopeReferralsWrite), referralController.DeleteReferralCode)
+		protected.GET("/list", middlewares.RequireScope(auth.ScopeReferralsRead), referralController.GetReferralsByUserID)
+		protected.GET("/stats", middlewares.RequireScope(auth.ScopeReferralsRead), referralStatsController.GetMyStats)
+		protected.GET("/leaderboard", middlewares.RequireScope(auth.ScopeReferralsRead), leaderboardController.GetLeaderboard)
+		protected.GET("/codes/:code/qr", middlewares.RequireScope(auth.ScopeReferralsRead), rateLimiter.Limit("codes"), qrController.ReferralCodeQR)
+	}
+
+	// Маршруты профиля пользователя
+	users := router.Group("/users/me")
+	users.Use(authenticated, apiLimit)
+	{
+		users.GET("", middlewares.RequireScope(auth.ScopeProfileRead), userController.GetProfile)
+		users.PATCH("", middlewares.RequireScope(auth.ScopeProfileWrite), userController.UpdateProfile)
+		users.GET("/export", middlewares.RequireScope(auth.ScopeProfileRead), userController.ExportData)
+		users.POST("/email/verify", middlewares.RequireScope(auth.ScopeProfileWrite), userController.ConfirmEmail)
+		users.GET("/tier", middlewares.RequireScope(auth.ScopeReferralsRead), tierController.GetMyTier)
+		users.GET("/notifications", middlewares.RequireScope(auth.ScopeProfileRead), notificationController.GetPreferences)
+		users.PATCH("/notifications", middlewares.RequireScope(auth.ScopeProfileWrite), notificationController.UpdatePreferences)
+
+		// Действия с учетными данными доступны только из сессии, но не по API ключу
+		users.DELETE("", session, userController.DeleteAccount)
+		users.POST("/password", session, userController.ChangePassword)
+		users.POST("/mfa/totp", session, mfaController.EnrollTOTP)
+		users.POST("/mfa/totp/confirm", session, mfaController.ConfirmTOTP)
+		users.DELETE("/mfa/totp", session, mfaController.DisableTOTP)
+		users.GET("/api-keys", session, apiKeyController.ListUserAPIKeys)
+		users.POST("/api-keys", session, apiKeyController.CreateUserAPIKey)
+		users.DELETE("/api-keys/:id", session, apiKeyController.RevokeUserAPIKey)
+		users.POST("/identities/:provider", session, oauthController.Link)
+	}
+
+	// Маршруты администратора
+	admin := router.Group("/admin")
+	admin.Use(authenticated, apiLimit)
+	{
+		admin.POST("/users/:id/unlock", middlewares.RequireScope(auth.ScopeAdminUsers), adminController.UnlockUser)
+
+		// Сервисные ключи выдает только администратор из своей сессии
+		admin.GET("/api-keys", session, middlewares.RequireScope(auth.ScopeAdminUsers), apiKeyController.ListServiceAPIKeys)
+		admin.POST("/api-keys", session, middlewares.RequireScope(auth.ScopeAdminUsers), apiKeyController.CreateServiceAPIKey)
+		admin.DELETE("/api-keys/:id", session, middlewares.RequireScope(auth.ScopeAdminUsers), apiKeyController.RevokeServiceAPIKey)
+	}
+
+	// Реферальные кампании
+	campaigns := admin.Group("/campaigns")
+	campaigns.Use(middlewares.RequireScope(auth.ScopeAdminCampaigns))
+	{
+		campaigns.GET("", campaignController.ListCampaigns)
+		campaigns.POST("", campaignController.CreateCampaign)
+		campaigns.GET("/:id", campaignController.GetCampaign)
+		campaigns.PUT("/:id", campaignController.UpdateCampaign)
+		campaigns.DELETE("/:id", campaignController.DeleteCampaign)
+		campaigns.GET("/:id/stats", campaignController.GetCampaignStats)
+		campaigns.GET("/:id/code-batches", codeBatchController.ListBatches)
+		campaigns.POST("/:id/code-batches", codeBatchController.CreateBatch)
+	}
+
+	// Рефералы и статистика программы
+	referrals := admin.Group("/referrals")
+	referrals.Use(middlewares.RequireScope(auth.ScopeAdminReferrals))
+	{
+		referrals.GET("/stats", referralStatsController.GetStats)
+		referrals.POST("/:id/qualify", referralController.QualifyReferral)
+	}
+
+	// Пакеты партнерских кодов
+	batches := admin.Group("/code-batches")
+	batches.Use(middlewares.RequireScope(auth.ScopeAdminCampaigns))
+	{
+		batches.GET("/:id", codeBatchController.GetBatch)
+		batches.GET("/:id/codes.csv", codeBatchController.DownloadBatch)
+	}
+
+	// Подписки на вебхуки
+	webhooks := admin.Group("/webhooks")
+	webhooks.Use(middlewares.RequireScope(auth.ScopeAdminWebhooks))
+	{
+		webhooks.GET("", webhookController.ListWebhooks)
+		webhooks.POST("", webhookController.CreateWebhook)
+		webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+		webhooks.POST("/:id/test", webhookController.TestWebhook)
+		webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)
+	}
+
+	deliveries := admin.Group("/webhook-deliveries")
+	deliveries.Use(middlewares.RequireScope(auth.ScopeAdminWebhooks))
 	{
-		protected.POST("/", referralController.CreateReferralCode)
-		protected.DELETE("/", referralController.DeleteReferralCode)
-		protected.GET("/list", referralController.GetReferralsByUserID)
+		deliveries.POST("/:id/replay", webhookController.ReplayDelivery)
 	}
 
 	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusMethodNotAllowed, gin.H{"message": "not found"})
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"message": i18n.Translate(c.Request.Context(), "route_not_found", nil)})
 	})
 
 	router.NoMethod(func(c *gin.Context) {
-		c.JSON(http.StatusMethodNotAllowed, gin.H{"message": "method not allowed"})
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"message": i18n.Translate(c.Request.Context(), "method_not_allowed", nil)})
 	})
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix отличает наши ключи от других секретов, например при поиске утечек в коде
+	apiKeyPrefix = "rk_"
+	// apiKeyTouchInterval - как часто обновляется last_used_at, чтобы не писать в базу на каждый запрос
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService интерфейс для управления API ключами. userID равный nil означает сервисные ключи
+type APIKeyService interface {
+	// CreateAPIKey создает ключ и возвращает его открытое значение. Оно показывается только один раз
+	CreateAPIKey(ctx context.Context, userID *int, name string, scopes []string, expiresIn time.Duration) (*entities.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID *int) ([]*entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID *int, keyID int) error
+	// Authenticate проверяет ключ из заголовка X-API-Key
+	Authenticate(ctx context.Context, rawKey string) (auth.User, error)
+}
+
+// apiKeyService реализация APIKeyService
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+}
+
+// NewAPIKeyService создает новый APIKeyService
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository) APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+// CreateAPIKey создает ключ вида rk_<prefix>_<secret>. В базе хранятся только prefix и хеш ключа целиком.
+// expiresIn равный нулю создает бессрочный ключ
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID *int, name string, scopes []string, expiresIn time.Duration) (*entities.APIKey, string, error) {
+	allowed := auth.ServiceScopes
+	if userID != nil {
+		user, err := s.userRepo.GetUserByID(ctx, *userID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, "", ErrUserNotFound
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		allowed = auth.ScopesForRoles([]string{user.Role})
+	}
+
+	// Ключ не может получить больше прав, чем есть у его владельца
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidAPIScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, "", ErrInvalidAPIScope
+		}
+	}
+
+	prefix, err := randomHex(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := apiKeyPrefix + prefix + "_" + secret
+
+	key := &entities.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  prefix,
+		KeyHash: hashToken(rawKey),
+		Scopes:  slices.Compact(slices.Sorted(slices.Values(scopes))),
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+// ListAPIKeys возвращает ключи владельца, включая отозванные
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID *int) ([]*entities.APIKey, error) {
+	return s.apiKeyRepo.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey отзывает ключ владельца
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID *int, keyID int) error {
+	return s.apiKeyRepo.RevokeAPIKey(ctx, keyID, userID)
+}
+
+// Authenticate находит ключ по prefix и сравнивает хеш. Права пользовательского ключа
+// дополнительно ограничиваются текущей ролью владельца
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (auth.User, error) {
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(rawKey, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return auth.User{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return auth.User{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	now := time.Now()
+	if !tokenMatches(rawKey, key.KeyHash) || key.RevokedAt != nil || (key.ExpiresAt != nil && key.ExpiresAt.Before(now)) {
+		return auth.User{}, ErrInvalidAPIKey
+	}
+
+	principal := auth.User{Scopes: key.Scopes, APIKeyID: key.ID}
+	if key.UserID != nil {
+		user, err := s.userRepo.GetUserByID(ctx, *key.UserID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return auth.User{}, ErrInvalidAPIKey
+		}
+		if err != nil {
+			return auth.User{}, err
+		}
+		if user.DeletedAt != nil {
+			return auth.User{}, ErrInvalidAPIKey
+		}
+
+		allowed := auth.ScopesForRoles([]string{user.Role})
+		principal.ID = user.ID
+		principal.Roles = []string{user.Role}
+		principal.Scopes = slices.DeleteFunc(slices.Clone(key.Scopes), func(scope string) bool {
+			return !slices.Contains(allowed, scope)
+		})
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			return auth.User{}, err
+		}
+	}
+
+	return principal, nil
+}
+
+// randomHex возвращает n случайных байт в hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := mocks.NewAPIKeyRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	apiKeyService := services.NewAPIKeyService(keyRepo, userRepo)
+
+	userID := 7
+	userRepo.On("GetUserByID", ctx, userID).Return(&entities.User{ID: userID, Role: entities.RoleUser}, nil)
+
+	var stored *entities.APIKey
+	keyRepo.On("CreateAPIKey", ctx, mock.AnythingOfType("*entities.APIKey")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*entities.APIKey)
+			stored.ID = 1
+		}).
+		Return(nil)
+
+	key, rawKey, err := apiKeyService.CreateAPIKey(ctx, &userID, "backend", []string{auth.ScopeReferralsRead}, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rawKey, "rk_"+key.Prefix+"_"))
+	assert.NotContains(t, key.KeyHash, rawKey)
+	require.NotNil(t, key.ExpiresAt)
+
+	keyRepo.On("GetAPIKeyByPrefix", ctx, key.Prefix).Return(stored, nil)
+	keyRepo.On("TouchAPIKey", ctx, 1, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	principal, err := apiKeyService.Authenticate(ctx, rawKey)
+	require.NoError(t, err)
+	assert.Equal(t, userID, principal.ID)
+	assert.Equal(t, 1, principal.APIKeyID)
+	assert.False(t, principal.IsSession())
+	assert.True(t, principal.HasScope(auth.ScopeReferralsRead))
+	assert.False(t, principal.HasScope(auth.ScopeReferralsWrite))
+
+	// Ключ с тем же prefix, но другим секретом не подходит
+	_, err = apiKeyService.Authenticate(ctx, "rk_"+key.Prefix+"_forged")
+	assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_CreateAPIKey_RejectsScopesAboveOwner(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := mocks.NewAPIKeyRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	apiKeyService := services.NewAPIKeyService(keyRepo, userRepo)
+
+	userID := 7
+	userRepo.On("GetUserByID", ctx, userID).Return(&entities.User{ID: userID, Role: entities.RoleUser}, nil)
+
+	_, _, err := apiKeyService.CreateAPIKey(ctx, &userID, "backend", []string{auth.ScopeAdminUsers}, 0)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIScope)
+
+	// Сервисному ключу нельзя выдать права, действующие от имени пользователя
+	_, _, err = apiKeyService.CreateAPIKey(ctx, nil, "billing", []string{auth.ScopeProfileRead}, 0)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIScope)
+}
+
+func TestAPIKeyService_Authenticate_RejectsRevokedAndExpired(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := mocks.NewAPIKeyRepository(t)
+	apiKeyService := services.NewAPIKeyService(keyRepo, mocks.NewUserRepository(t))
+
+	past := time.Now().Add(-time.Minute)
+	revoked := &entities.APIKey{ID: 1, Prefix: "revoked", RevokedAt: &past}
+	expired := &entities.APIKey{ID: 2, Prefix: "expired", ExpiresAt: &past}
+
+	keyRepo.On("GetAPIKeyByPrefix", ctx, "revoked").Return(revoked, nil)
+	keyRepo.On("GetAPIKeyByPrefix", ctx, "expired").Return(expired, nil)
+	keyRepo.On("GetAPIKeyByPrefix", ctx, "missing").Return(nil, repositories.ErrNotFound)
+
+	for _, rawKey := range []string{"rk_revoked_secret", "rk_expired_secret", "rk_missing_secret", "not-a-key"} {
+		_, err := apiKeyService.Authenticate(ctx, rawKey)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey, rawKey)
+	}
+}
@@ -1,50 +1,151 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"referral-system/internal/auth"
+	"referral-system/internal/config"
 	"referral-system/internal/entities"
 	"referral-system/internal/repositories"
+	"sync"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthService интерфейс для аутентификации и регистрации
 type AuthService interface {
-	RegisterUser(name, email, password string) (*entities.User, error)
-	LoginUser(email, password string) (*entities.User, string, error)
+	RegisterUser(ctx context.Context, name, email, password string) (*entities.User, error)
+	LoginUser(ctx context.Context, email, password, ip string) (*entities.User, string, error)
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (string, error)
+	// ValidateSession проверяет, что токен не отозван, и возвращает текущую роль пользователя
+	ValidateSession(ctx context.Context, userID, tokenVersion int) (string, error)
+	UnlockUser(ctx context.Context, userID int) error
+	VerifyMFA(ctx context.Context, mfaToken, code, ip string) (*entities.User, string, error)
+	// StartSession выдает токен пользователю, чья личность уже подтверждена, или
+	// MFARequiredError, если у него включен второй фактор
+	StartSession(user *entities.User) (string, error)
 }
 
+const (
+	// accessTokenTTL - время жизни токена доступа
+	accessTokenTTL = 3 * time.Hour
+	// mfaChallengeTTL - время жизни токена между первым и вторым шагом входа
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaChallengeAudience отличает токен второго шага от токена доступа,
+	// поэтому JWTMiddleware не примет его вместо полноценного токена
+	mfaChallengeAudience = "mfa-challenge"
+	// reauthWindow - сколько после входа пользователь без пароля может удалить аккаунт или задать пароль
+	reauthWindow = 10 * time.Minute
+)
+
+// MFARequiredError возвращается из LoginUser, когда пароль верный, но у пользователя включен
+// второй фактор. Token нужно передать в VerifyMFA вместе с кодом
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// TokenIssuer выпускает и проверяет JWT
+type TokenIssuer interface {
+	Issue(claims *auth.Claims, ttl time.Duration) (string, error)
+	ParseFor(tokenString, audience string) (*auth.Claims, error)
+}
+
+// PasswordValidator проверяет пароль на соответствие парольной политике
+type PasswordValidator interface {
+	Validate(password string) error
+}
+
+// dummyPasswordHash используется для сравнения пароля, когда пользователь не найден,
+// чтобы время ответа не выдавало существование аккаунта
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
 // authService реализация AuthService
 type authService struct {
-	userRepo  repositories.UserRepository
-	jwtSecret string
+	userRepo          repositories.UserRepository
+	tokens            TokenIssuer
+	passwordValidator PasswordValidator
+	throttle          *loginThrottle
+	mfaService        MFAService
+	mfaChallengeRepo  repositories.MFAChallengeRepository
 }
 
 // NewAuthService создает новый AuthService
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string) AuthService {
-	return &authService{userRepo: userRepo, jwtSecret: jwtSecret}
+func NewAuthService(userRepo repositories.UserRepository,
+	loginAttemptRepo repositories.LoginAttemptRepository,
+	tokens TokenIssuer,
+	passwordValidator PasswordValidator,
+	loginProtection config.LoginProtection,
+	mfaService MFAService,
+	mfaChallengeRepo repositories.MFAChallengeRepository) AuthService {
+	return &authService{
+		userRepo:          userRepo,
+		tokens:            tokens,
+		passwordValidator: passwordValidator,
+		throttle:          newLoginThrottle(loginAttemptRepo, loginProtection),
+		mfaService:        mfaService,
+		mfaChallengeRepo:  mfaChallengeRepo,
+	}
 }
 
 // GenerateJWT создает JWT токен для пользователя
 func (s *authService) GenerateJWT(user *entities.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 3).Unix(),
+	return s.tokens.Issue(auth.NewClaims(user.ID, user.TokenVersion, user.Role), accessTokenTTL)
+}
+
+// generateMFAChallenge создает короткоживущий токен второго шага входа
+func (s *authService) generateMFAChallenge(user *entities.User) (string, error) {
+	claims := auth.NewClaims(user.ID, user.TokenVersion)
+	claims.Audience = jwt.ClaimStrings{mfaChallengeAudience}
+
+	return s.tokens.Issue(claims, mfaChallengeTTL)
+}
+
+// parseMFAChallenge проверяет токен второго шага входа и возвращает его claims и ID пользователя
+func (s *authService) parseMFAChallenge(tokenString string) (*auth.Claims, int, error) {
+	claims, err := s.tokens.ParseFor(tokenString, mfaChallengeAudience)
+	if err != nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, 0, ErrInvalidMFAToken
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, 0, ErrInvalidMFAToken
+	}
 
-	return token.SignedString([]byte(s.jwtSecret))
+	return claims, userID, nil
 }
 
 // RegisterUser регистрирует нового пользователя
-func (s *authService) RegisterUser(name, email, password string) (*entities.User, error) {
+func (s *authService) RegisterUser(ctx context.Context, name, email, password string) (*entities.User, error) {
 	// Проверим, существует ли пользователь с таким email
-	_, err := s.userRepo.GetUserByEmail(email)
+	_, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err == nil {
-		return nil, errors.New("user already exists")
+		return nil, ErrUserAlreadyExists
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	// Проверим пароль на соответствие политике
+	if err := s.passwordValidator.Validate(password); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWeakPassword, err)
 	}
 
 	// Хешируем пароль
@@ -53,14 +154,18 @@ func (s *authService) RegisterUser(name, email, password string) (*entities.User
 		return nil, err
 	}
 
-	// Создаем нового пользователя
+	// Создаем нового пользователя. Письма ему приходят на языке, выбранном при регистрации
 	user := &entities.User{
 		Name:           name,
 		Email:          email,
 		HashedPassword: string(hashedPassword),
+		Locale:         requestLocale(ctx),
 	}
 
-	err = s.userRepo.CreateUser(user)
+	err = s.userRepo.CreateUser(ctx, user)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, ErrUserAlreadyExists
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -68,17 +173,176 @@ func (s *authService) RegisterUser(name, email, password string) (*entities.User
 	return user, nil
 }
 
-// LoginUser проверяет учетные данные пользователя и возвращает пользователя
-func (s *authService) LoginUser(email, password string) (*entities.User, string, error) {
-	user, err := s.userRepo.GetUserByEmail(email)
-	if err != nil {
-		return nil, "", errors.New("user not found")
+// LoginUser проверяет учетные данные пользователя и возвращает пользователя.
+// Неизвестный email и неверный пароль неотличимы ни по ошибке, ни по времени ответа,
+// а серия неудачных попыток временно блокирует вход для аккаунта и IP
+func (s *authService) LoginUser(ctx context.Context, email, password, ip string) (*entities.User, string, error) {
+	if err := s.throttle.check(ctx, accountKey(email), ipKey(ip)); err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return nil, "", err
 	}
 
 	// Проверим пароль
-	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password))
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = []byte(user.HashedPassword)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || user == nil {
+		if err := s.throttle.failLogin(ctx, email, ip); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrInvalidCredentials
+	}
+
+	// С включенным вторым фактором счетчик сбрасывается только после верного кода в VerifyMFA,
+	// иначе знающий пароль мог бы обнулять счетчик между попытками подобрать код
+	if !user.TOTPEnabled {
+		if err := s.throttle.reset(ctx, accountKey(email)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	token, err := s.StartSession(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+// StartSession выдает токен доступа. Если включен второй фактор, вместо него
+// возвращается токен для второго шага входа
+func (s *authService) StartSession(user *entities.User) (string, error) {
+	if user.TOTPEnabled {
+		challenge, err := s.generateMFAChallenge(user)
+		if err != nil {
+			return "", err
+		}
+		return "", &MFARequiredError{Token: challenge}
+	}
+
+	return s.GenerateJWT(user)
+}
+
+// ChangePassword меняет пароль пользователя и отзывает все ранее выданные токены.
+// Если пароля еще нет, задает первый пароль. Возвращает новый токен для текущей сессии
+func (s *authService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Проверим текущий пароль. Пользователь, вошедший через провайдера, задает первый пароль после свежего входа
+	if err := confirmIdentity(ctx, user, currentPassword); err != nil {
+		return "", err
+	}
+
+	if err := s.passwordValidator.Validate(newPassword); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	tokenVersion, err := s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	user.HashedPassword = string(hashedPassword)
+	user.TokenVersion = tokenVersion
+	return s.GenerateJWT(user)
+}
+
+// ValidateSession проверяет, что токен выдан для актуальной версии учетных данных. Роль читается
+// вместе с версией, поэтому ее изменение действует на уже выданные токены
+func (s *authService) ValidateSession(ctx context.Context, userID, tokenVersion int) (string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", ErrSessionRevoked
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if user.TokenVersion != tokenVersion {
+		return "", ErrSessionRevoked
+	}
+
+	return user.Role, nil
+}
+
+// UnlockUser снимает блокировку входа с аккаунта пользователя
+func (s *authService) UnlockUser(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.throttle.reset(ctx, accountKey(user.Email))
+}
+
+// VerifyMFA завершает вход с включенным вторым фактором: проверяет токен первого шага
+// и TOTP код или код восстановления. Неверные коды учитываются так же, как неверные пароли.
+// Токен первого шага принимается только один раз
+func (s *authService) VerifyMFA(ctx context.Context, mfaToken, code, ip string) (*entities.User, string, error) {
+	claims, userID, err := s.parseMFAChallenge(mfaToken)
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, "", ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, "", ErrInvalidMFAToken
+	}
+
+	if err := s.throttle.check(ctx, accountKey(user.Email), ipKey(ip)); err != nil {
+		return nil, "", err
+	}
+
+	err = s.mfaService.VerifyCode(ctx, user, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		if err := s.throttle.failLogin(ctx, user.Email, ip); err != nil {
+			return nil, "", err
+		}
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = s.mfaChallengeRepo.UseMFAChallenge(ctx, claims.ID, claims.ExpiresAt.Time)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, "", ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.throttle.reset(ctx, accountKey(user.Email)); err != nil {
+		return nil, "", err
 	}
 
 	token, err := s.GenerateJWT(user)
@@ -88,3 +352,20 @@ func (s *authService) LoginUser(email, password string) (*entities.User, string,
 
 	return user, token, nil
 }
+
+// confirmIdentity подтверждает чувствительное действие паролем. У пользователя без пароля, созданного входом
+// через провайдера, паролем служит свежий вход: токен должен быть выдан не раньше reauthWindow назад
+func confirmIdentity(ctx context.Context, user *entities.User, password string) error {
+	if user.HashedPassword != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password)); err != nil {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	authenticatedAt, ok := auth.AuthenticatedAtFromContext(ctx)
+	if !ok || time.Since(authenticatedAt) > reauthWindow {
+		return ErrReauthRequired
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/auth"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/jwtkeys"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type allowAll struct{}
+
+func (allowAll) Validate(string) error { return nil }
+
+var loginProtection = config.LoginProtection{MaxAttempts: 3, IPMaxAttempts: 10, Window: 60, BaseLockout: 30, MaxLockout: 300}
+
+func newTokens(t *testing.T) *auth.Tokens {
+	keys, err := jwtkeys.Generate("test")
+	require.NoError(t, err)
+	return auth.NewTokens(keys, config.JWTConfig{})
+}
+
+func TestAuthService_LoginUser_UnknownEmailLooksLikeWrongPassword(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	attemptRepo := mocks.NewLoginAttemptRepository(t)
+	authService := services.NewAuthService(userRepo, attemptRepo, newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("right_password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	attemptRepo.On("GetLoginAttempt", ctx, mock.Anything).Return(nil, repositories.ErrNotFound)
+	attemptRepo.On("RecordFailedLogin", ctx, mock.Anything, mock.Anything).
+		Return(&entities.LoginAttempt{Failures: 1}, nil)
+	userRepo.On("GetUserByEmail", ctx, "missing@mail.com").Return(nil, repositories.ErrNotFound)
+	userRepo.On("GetUserByEmail", ctx, "user@mail.com").Return(&entities.User{ID: 1, HashedPassword: string(hash)}, nil)
+
+	_, _, missingErr := authService.LoginUser(ctx, "missing@mail.com", "wrong_password", "10.0.0.1")
+	_, _, wrongErr := authService.LoginUser(ctx, "user@mail.com", "wrong_password", "10.0.0.1")
+
+	assert.ErrorIs(t, missingErr, services.ErrInvalidCredentials)
+	assert.Equal(t, wrongErr, missingErr)
+
+	// Неудачные попытки учитываются и для аккаунта, и для IP, даже если email неизвестен
+	attemptRepo.AssertCalled(t, "RecordFailedLogin", ctx, "email:missing@mail.com", mock.Anything)
+	attemptRepo.AssertNumberOfCalls(t, "RecordFailedLogin", 4)
+}
+
+func TestAuthService_LoginUser_LocksAccountAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	attemptRepo := mocks.NewLoginAttemptRepository(t)
+	authService := services.NewAuthService(userRepo, attemptRepo, newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	attemptRepo.On("GetLoginAttempt", ctx, mock.Anything).Return(nil, repositories.ErrNotFound)
+	attemptRepo.On("RecordFailedLogin", ctx, "email:user@mail.com", mock.Anything).
+		Return(&entities.LoginAttempt{Failures: 4}, nil)
+	attemptRepo.On("RecordFailedLogin", ctx, "ip:10.0.0.1", mock.Anything).
+		Return(&entities.LoginAttempt{Failures: 4}, nil)
+	userRepo.On("GetUserByEmail", ctx, "user@mail.com").Return(nil, repositories.ErrNotFound)
+
+	// Четвертая ошибка при лимите 3 удваивает базовую блокировку
+	attemptRepo.On("LockLogin", ctx, "email:user@mail.com", mock.MatchedBy(func(until time.Time) bool {
+		lockout := time.Until(until)
+		return lockout > 55*time.Second && lockout <= 60*time.Second
+	})).Return(nil)
+
+	_, _, err := authService.LoginUser(ctx, "user@mail.com", "wrong_password", "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+
+	attemptRepo.AssertExpectations(t)
+}
+
+func TestAuthService_LoginUser_Locked(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	attemptRepo := mocks.NewLoginAttemptRepository(t)
+	authService := services.NewAuthService(userRepo, attemptRepo, newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	attemptRepo.On("GetLoginAttempt", ctx, "email:user@mail.com").
+		Return(&entities.LoginAttempt{Failures: 3, LockedUntil: &lockedUntil}, nil)
+	attemptRepo.On("GetLoginAttempt", ctx, "ip:10.0.0.1").Return(nil, repositories.ErrNotFound)
+
+	_, _, err := authService.LoginUser(ctx, "user@mail.com", "right_password", "10.0.0.1")
+
+	var lockoutErr *services.LockoutError
+	require.ErrorAs(t, err, &lockoutErr)
+	assert.ErrorIs(t, err, services.ErrTooManyAttempts)
+	assert.InDelta(t, time.Minute.Seconds(), lockoutErr.RetryAfter.Seconds(), 1)
+
+	userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_LoginUser_SuccessResetsAccountCounter(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	attemptRepo := mocks.NewLoginAttemptRepository(t)
+	authService := services.NewAuthService(userRepo, attemptRepo, newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("right_password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	attemptRepo.On("GetLoginAttempt", ctx, mock.Anything).Return(nil, repositories.ErrNotFound)
+	attemptRepo.On("ResetLoginAttempts", ctx, "email:user@mail.com").Return(nil)
+	userRepo.On("GetUserByEmail", ctx, "User@Mail.com").Return(&entities.User{ID: 1, HashedPassword: string(hash)}, nil)
+
+	user, token, err := authService.LoginUser(ctx, "User@Mail.com", "right_password", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.NotEmpty(t, token)
+
+	attemptRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateSession_ReturnsCurrentRole(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	authService := services.NewAuthService(userRepo, mocks.NewLoginAttemptRepository(t), newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Role: entities.RoleUser, TokenVersion: 2}, nil)
+	userRepo.On("GetUserByID", ctx, 2).Return(nil, repositories.ErrNotFound)
+
+	role, err := authService.ValidateSession(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, entities.RoleUser, role)
+
+	_, err = authService.ValidateSession(ctx, 1, 1)
+	assert.ErrorIs(t, err, services.ErrSessionRevoked)
+
+	_, err = authService.ValidateSession(ctx, 2, 1)
+	assert.ErrorIs(t, err, services.ErrSessionRevoked)
+}
+
+func TestAuthService_ChangePassword_SetsInitialPasswordAfterFreshLogin(t *testing.T) {
+	userRepo := mocks.NewUserRepository(t)
+	authService := services.NewAuthService(userRepo, mocks.NewLoginAttemptRepository(t), newTokens(t), allowAll{}, loginProtection, nil, nil)
+
+	// Пользователь создан входом через провайдера и пароля не имеет
+	userRepo.On("GetUserByID", mock.Anything, 7).Return(&entities.User{ID: 7, Email: "social@mail.com"}, nil)
+
+	stale := auth.WithAuthenticatedAt(context.Background(), time.Now().Add(-time.Hour))
+	_, err := authService.ChangePassword(stale, 7, "", "new_password")
+	assert.ErrorIs(t, err, services.ErrReauthRequired)
+
+	_, err = authService.ChangePassword(context.Background(), 7, "", "new_password")
+	assert.ErrorIs(t, err, services.ErrReauthRequired, "api keys can't set a password")
+
+	fresh := auth.WithAuthenticatedAt(context.Background(), time.Now().Add(-time.Minute))
+	userRepo.On("UpdatePassword", fresh, 7, mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new_password")) == nil
+	})).Return(2, nil)
+	token, err := authService.ChangePassword(fresh, 7, "", "new_password")
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+	"strings"
+)
+
+// CampaignService интерфейс для управления реферальными кампаниями
+type CampaignService interface {
+	CreateCampaign(ctx context.Context, campaign *entities.Campaign) error
+	GetCampaign(ctx context.Context, id int) (*entities.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]*entities.Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign *entities.Campaign) error
+	DeleteCampaign(ctx context.Context, id int) error
+	GetCampaignStats(ctx context.Context, id int) (*entities.CampaignStats, error)
+}
+
+// campaignService реализация CampaignService
+type campaignService struct {
+	campaignRepo repositories.CampaignRepository
+}
+
+// NewCampaignService создает новый CampaignService
+func NewCampaignService(campaignRepo repositories.CampaignRepository) CampaignService {
+	return &campaignService{campaignRepo: campaignRepo}
+}
+
+// validateCampaign проверяет правила кампании перед сохранением
+func validateCampaign(campaign *entities.Campaign) error {
+	campaign.Name = strings.TrimSpace(campaign.Name)
+
+	switch {
+	case campaign.Name == "":
+		return invalidField(ErrInvalidCampaign, "name", "validation_required", nil)
+	case !campaign.EndsAt.After(campaign.StartsAt):
+		return invalidField(ErrInvalidCampaign, "ends_at", "validation_after", i18n.Args{"field": "starts_at"})
+	case campaign.CodeTTL <= 0:
+		return invalidField(ErrInvalidCampaign, "code_ttl", "validation_positive", nil)
+	case campaign.ReferrerReward < 0:
+		return invalidField(ErrInvalidCampaign, "referrer_reward", "validation_not_negative", nil)
+	case campaign.RefereeReward < 0:
+		return invalidField(ErrInvalidCampaign, "referee_reward", "validation_not_negative", nil)
+	case campaign.MaxUsesPerCode != nil && *campaign.MaxUsesPerCode <= 0:
+		return invalidField(ErrInvalidCampaign, "max_uses_per_code", "validation_positive", nil)
+	case campaign.MaxReferrals != nil && *campaign.MaxReferrals <= 0:
+		return invalidField(ErrInvalidCampaign, "max_referrals", "validation_positive", nil)
+	}
+
+	return nil
+}
+
+// campaignError приводит ошибки репозитория к ошибкам кампаний
+func campaignError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return ErrCampaignNotFound
+	case errors.Is(err, repositories.ErrConflict):
+		return ErrCampaignInUse
+	default:
+		return err
+	}
+}
+
+// CreateCampaign создает кампанию
+func (s *campaignService) CreateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	if err := validateCampaign(campaign); err != nil {
+		return err
+	}
+	return s.campaignRepo.CreateCampaign(ctx, campaign)
+}
+
+// GetCampaign возвращает кампанию по ID
+func (s *campaignService) GetCampaign(ctx context.Context, id int) (*entities.Campaign, error) {
+	campaign, err := s.campaignRepo.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, campaignError(err)
+	}
+	return campaign, nil
+}
+
+// ListCampaigns возвращает все кампании
+func (s *campaignService) ListCampaigns(ctx context.Context) ([]*entities.Campaign, error) {
+	return s.campaignRepo.ListCampaigns(ctx)
+}
+
+// UpdateCampaign меняет правила кампании. Уже выпущенные коды и засчитанные награды не пересчитываются
+func (s *campaignService) UpdateCampaign(ctx context.Context, campaign *entities.Campaign) error {
+	if err := validateCampaign(campaign); err != nil {
+		return err
+	}
+	return campaignError(s.campaignRepo.UpdateCampaign(ctx, campaign))
+}
+
+// DeleteCampaign удаляет кампанию, по которой еще не выпущено ни одного кода
+func (s *campaignService) DeleteCampaign(ctx context.Context, id int) error {
+	return campaignError(s.campaignRepo.DeleteCampaign(ctx, id))
+}
+
+// GetCampaignStats возвращает статистику кампании
+func (s *campaignService) GetCampaignStats(ctx context.Context, id int) (*entities.CampaignStats, error) {
+	stats, err := s.campaignRepo.GetCampaignStats(ctx, id)
+	if err != nil {
+		return nil, campaignError(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,214 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func activeCampaign() *entities.Campaign {
+	maxUses := 2
+	return &entities.Campaign{
+		ID:             4,
+		Name:           "Spring",
+		StartsAt:       time.Now().Add(-time.Hour),
+		EndsAt:         time.Now().Add(2 * time.Hour),
+		CodeTTL:        int((24 * time.Hour).Seconds()),
+		ReferrerReward: 100,
+		RefereeReward:  50,
+		MaxUsesPerCode: &maxUses,
+	}
+}
+
+func TestCampaignService_CreateCampaign_Validates(t *testing.T) {
+	ctx := context.Background()
+	campaignService := services.NewCampaignService(mocks.NewCampaignRepository(t))
+
+	campaign := activeCampaign()
+	campaign.EndsAt = campaign.StartsAt
+	err := campaignService.CreateCampaign(ctx, campaign)
+	assert.ErrorIs(t, err, services.ErrInvalidCampaign)
+	var fieldErr *services.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "ends_at", fieldErr.Field)
+	assert.Equal(t, "validation_after", fieldErr.Message.Code)
+
+	campaign = activeCampaign()
+	campaign.Name = "  "
+	assert.ErrorIs(t, campaignService.CreateCampaign(ctx, campaign), services.ErrInvalidCampaign)
+}
+
+func TestCampaignService_DeleteCampaign_MapsErrors(t *testing.T) {
+	ctx := context.Background()
+	campaignRepo := mocks.NewCampaignRepository(t)
+	campaignService := services.NewCampaignService(campaignRepo)
+
+	campaignRepo.On("DeleteCampaign", ctx, 1).Return(repositories.ErrNotFound)
+	campaignRepo.On("DeleteCampaign", ctx, 2).Return(repositories.ErrConflict)
+
+	assert.ErrorIs(t, campaignService.DeleteCampaign(ctx, 1), services.ErrCampaignNotFound)
+	assert.ErrorIs(t, campaignService.DeleteCampaign(ctx, 2), services.ErrCampaignInUse)
+}
+
+func TestReferralService_CreateReferralCode_InheritsCampaign(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), mocks.NewReferralRepository(t), campaignRepo, nil, nil, nil, nil)
+
+	campaign := activeCampaign()
+	codeRepo.On("ArchivePersonalCodeExpiredBefore", ctx, 3, mock.AnythingOfType("time.Time")).Return(nil)
+	codeRepo.On("GetReferralCodeByUserID", ctx, 3).Return(nil, repositories.ErrNotFound)
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	codeRepo.On("CreateReferralCode", ctx, mock.AnythingOfType("*entities.ReferralCode")).Return(nil)
+
+	code, err := referralService.CreateReferralCode(ctx, 3, 0, &campaign.ID)
+	require.NoError(t, err)
+	require.NotNil(t, code.CampaignID)
+	assert.Equal(t, campaign.ID, *code.CampaignID)
+	assert.Equal(t, campaign.MaxUsesPerCode, code.MaxUses)
+	// Время жизни по умолчанию длиннее кампании, поэтому код истекает вместе с ней
+	assert.Equal(t, campaign.EndsAt, code.ExpiresAt)
+}
+
+func TestReferralService_CreateReferralCode_RejectsInactiveCampaign(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), mocks.NewReferralRepository(t), campaignRepo, nil, nil, nil, nil)
+
+	campaign := activeCampaign()
+	campaign.StartsAt = time.Now().Add(time.Hour)
+	codeRepo.On("ArchivePersonalCodeExpiredBefore", ctx, 3, mock.AnythingOfType("time.Time")).Return(nil)
+	codeRepo.On("GetReferralCodeByUserID", ctx, 3).Return(nil, repositories.ErrNotFound)
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+
+	_, err := referralService.CreateReferralCode(ctx, 3, time.Hour, &campaign.ID)
+	assert.ErrorIs(t, err, services.ErrCampaignInactive)
+}
+
+func TestReferralService_CreditReferral_AppliesCampaignRules(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	referralRepo := mocks.NewReferralRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	tierService := services.NewTierService(testTiers, referralRepo, mocks.NewTierRepository(t), inlineTx{})
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), referralRepo, campaignRepo, nil, tierService, inlineTx{}, &recordingPublisher{})
+
+	campaign := activeCampaign()
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "SPRING", ExpiresAt: campaign.EndsAt,
+		CampaignID: &campaign.ID, MaxUses: campaign.MaxUsesPerCode}
+	codeRepo.On("GetReferralByReferralCode", ctx, "SPRING").Return(code, nil)
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	codeRepo.On("LockReferralCode", ctx, code.ID).Return(code, nil)
+	campaignRepo.On("LockCampaign", ctx, campaign.ID).Return(campaign, nil)
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(0, nil)
+
+	// Лимит проверяется до транзакции и повторно под блокировкой кода
+	referralRepo.On("CountReferralsByCode", ctx, code.ID).Return(1, nil).Twice()
+	referralRepo.On("CreateReferralLink", ctx, mock.MatchedBy(func(r *entities.Referral) bool {
+		return r.ReferrerID == 3 && r.RefereeID == 10 && *r.ReferralCodeID == code.ID && *r.CampaignID == campaign.ID &&
+			r.ReferrerReward == 100 && r.RefereeReward == 50
+	})).Return(nil)
+	require.NoError(t, referralService.CreditReferral(ctx, "SPRING", 10))
+
+	// Код исчерпал лимит регистраций
+	referralRepo.On("CountReferralsByCode", ctx, code.ID).Return(2, nil).Once()
+	assert.ErrorIs(t, referralService.CreditReferral(ctx, "SPRING", 11), services.ErrReferralLimitReached)
+}
+
+func TestReferralService_CreditReferral_RechecksLimitsUnderLock(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	referralRepo := mocks.NewReferralRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	tierService := services.NewTierService(testTiers, referralRepo, mocks.NewTierRepository(t), inlineTx{})
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), referralRepo, campaignRepo, nil, tierService, inlineTx{}, &recordingPublisher{})
+
+	campaign := activeCampaign()
+	maxReferrals := 5
+	campaign.MaxReferrals = &maxReferrals
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "SPRING", ExpiresAt: campaign.EndsAt,
+		CampaignID: &campaign.ID, MaxUses: campaign.MaxUsesPerCode}
+	codeRepo.On("GetReferralByReferralCode", ctx, "SPRING").Return(code, nil)
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	codeRepo.On("LockReferralCode", ctx, code.ID).Return(code, nil)
+	campaignRepo.On("LockCampaign", ctx, campaign.ID).Return(campaign, nil)
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(0, nil)
+	referralRepo.On("CountReferralsByCode", ctx, code.ID).Return(1, nil)
+
+	// Пока регистрация ждала блокировку, одновременные регистрации исчерпали лимит кампании
+	referralRepo.On("CountReferralsByCampaign", ctx, campaign.ID).Return(4, nil).Once()
+	referralRepo.On("CountReferralsByCampaign", ctx, campaign.ID).Return(5, nil).Once()
+
+	assert.ErrorIs(t, referralService.CreditReferral(ctx, "SPRING", 10), services.ErrReferralLimitReached)
+	referralRepo.AssertNotCalled(t, "CreateReferralLink", mock.Anything, mock.Anything)
+}
+
+// rollbackTx выполняет fn в "транзакции" и запоминает, была ли она отменена ошибкой
+type rollbackTx struct {
+	rolledBack bool
+}
+
+type txMarker struct{}
+
+func (tx *rollbackTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txMarker{}) != nil {
+		return fn(ctx)
+	}
+	err := fn(context.WithValue(ctx, txMarker{}, true))
+	tx.rolledBack = err != nil
+	return err
+}
+
+// inTx проверяет, что вызов репозитория сделан внутри транзакции
+func inTx(ctx context.Context) bool {
+	return ctx.Value(txMarker{}) != nil
+}
+
+func TestReferralService_RegisterWithReferralCode_IsAtomic(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	referralRepo := mocks.NewReferralRepository(t)
+	authService := services.NewAuthService(userRepo, mocks.NewLoginAttemptRepository(t), newTokens(t), allowAll{}, loginProtection, nil, nil)
+	tx := &rollbackTx{}
+	publisher := &recordingPublisher{}
+	referralService := services.NewReferralService(codeRepo, userRepo, referralRepo, mocks.NewCampaignRepository(t), authService,
+		services.NewTierService(testTiers, referralRepo, mocks.NewTierRepository(t), tx), tx, publisher)
+
+	maxUses := 1
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "REFCODE", ExpiresAt: time.Now().Add(time.Hour), MaxUses: &maxUses}
+	codeRepo.On("GetReferralByReferralCode", ctx, "REFCODE").Return(code, nil)
+	codeRepo.On("LockReferralCode", mock.MatchedBy(inTx), code.ID).Return(code, nil)
+	userRepo.On("GetUserByEmail", mock.Anything, mock.Anything).Return(nil, repositories.ErrNotFound)
+	userRepo.On("CreateUser", mock.MatchedBy(inTx), mock.AnythingOfType("*entities.User")).
+		Run(func(args mock.Arguments) { args.Get(1).(*entities.User).ID = 10 }).Return(nil)
+	referralRepo.On("CountReferralsByCode", ctx, code.ID).Return(0, nil)
+
+	// Пользователь, связь и событие сохраняются в одной транзакции
+	referralRepo.On("CountReferralsByCode", mock.MatchedBy(inTx), code.ID).Return(0, nil).Once()
+	referralRepo.On("CreateReferralLink", mock.MatchedBy(inTx), mock.MatchedBy(func(r *entities.Referral) bool {
+		return r.ReferrerID == 3 && r.RefereeID == 10
+	})).Return(nil).Once()
+	user, err := referralService.RegisterWithReferralCode(ctx, "REFCODE", "John", "john@mail.com", "Str0ng!pass")
+	require.NoError(t, err)
+	assert.Equal(t, 10, user.ID)
+	assert.False(t, tx.rolledBack)
+	assert.Equal(t, []string{entities.EventReferralCreated}, publisher.types())
+
+	// Лимит исчерпан, пока регистрация ждала блокировку: пользователь не остается без реферера
+	referralRepo.On("CountReferralsByCode", mock.MatchedBy(inTx), code.ID).Return(1, nil).Once()
+	_, err = referralService.RegisterWithReferralCode(ctx, "REFCODE", "Jane", "jane@mail.com", "Str0ng!pass")
+	assert.ErrorIs(t, err, services.ErrReferralLimitReached)
+	assert.True(t, tx.rolledBack, "user creation is rolled back")
+	referralRepo.AssertNumberOfCalls(t, "CreateReferralLink", 1)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"unicode/utf8"
+)
+
+const (
+	maxClickReferrerLength = 2048
+	maxClickUTMLength      = 255
+)
+
+// ClickService интерфейс для учета переходов по реферальным ссылкам
+type ClickService interface {
+	// TrackClick сохраняет переход по коду. Переход записывается для любого существующего кода,
+	// а ошибка сообщает, что регистрацию по коду засчитать нельзя и атрибутировать переход не нужно
+	TrackClick(ctx context.Context, referralCode string, click *entities.ReferralClick, ip string) error
+}
+
+// clickService реализация ClickService
+type clickService struct {
+	referralCodeRepo repositories.ReferralCodeRepository
+	clickRepo        repositories.ReferralClickRepository
+	referralService  ReferralService
+	ipHashKey        []byte
+}
+
+// NewClickService создает новый ClickService. ipHashKey - секрет HMAC, которым хешируются IP адреса
+func NewClickService(referralCodeRepo repositories.ReferralCodeRepository,
+	clickRepo repositories.ReferralClickRepository,
+	referralService ReferralService,
+	ipHashKey []byte) ClickService {
+	return &clickService{
+		referralCodeRepo: referralCodeRepo,
+		clickRepo:        clickRepo,
+		referralService:  referralService,
+		ipHashKey:        ipHashKey,
+	}
+}
+
+// TrackClick сохраняет переход и проверяет, действует ли код
+func (s *clickService) TrackClick(ctx context.Context, referralCode string, click *entities.ReferralClick, ip string) error {
+	code, err := s.referralCodeRepo.GetReferralByReferralCode(ctx, referralCode)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidReferralCode
+	}
+	if err != nil {
+		return err
+	}
+
+	click.ReferralCodeID = &code.ID
+	click.ReferrerID = &code.UserID
+	click.CampaignID = code.CampaignID
+	click.IPHash = s.hashIP(ip)
+	click.ReferrerURL = truncate(click.ReferrerURL, maxClickReferrerLength)
+	for _, value := range []*string{&click.UTMSource, &click.UTMMedium, &click.UTMCampaign, &click.UTMTerm, &click.UTMContent} {
+		*value = truncate(*value, maxClickUTMLength)
+	}
+
+	if err := s.clickRepo.CreateClick(ctx, click); err != nil {
+		return err
+	}
+
+	return s.referralService.ValidateReferralCode(ctx, referralCode)
+}
+
+// hashIP считает HMAC от IP: уникальных посетителей можно посчитать, а восстановить адрес перебором нельзя
+func (s *clickService) hashIP(ip string) string {
+	mac := hmac.New(sha256.New, s.ipHashKey)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// truncate обрезает строку до max символов
+func truncate(value string, max int) string {
+	if utf8.RuneCountInString(value) > max {
+		return string([]rune(value)[:max])
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickService_TrackClick(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	clickRepo := mocks.NewReferralClickRepository(t)
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), mocks.NewReferralRepository(t),
+		mocks.NewCampaignRepository(t), nil, nil, nil, nil)
+	clickService := services.NewClickService(codeRepo, clickRepo, referralService, []byte("secret"))
+
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "REFCODE", ExpiresAt: time.Now().Add(time.Hour)}
+	codeRepo.On("GetReferralByReferralCode", ctx, "REFCODE").Return(code, nil)
+
+	var saved []*entities.ReferralClick
+	clickRepo.On("CreateClick", ctx, mock.AnythingOfType("*entities.ReferralClick")).
+		Run(func(args mock.Arguments) { saved = append(saved, args.Get(1).(*entities.ReferralClick)) }).
+		Return(nil)
+
+	first := &entities.ReferralClick{UTMSource: strings.Repeat("s", 300)}
+	require.NoError(t, clickService.TrackClick(ctx, "REFCODE", first, "203.0.113.7"))
+	second := &entities.ReferralClick{}
+	require.NoError(t, clickService.TrackClick(ctx, "REFCODE", second, "203.0.113.7"))
+
+	require.Len(t, saved, 2)
+	assert.Equal(t, 8, *first.ReferralCodeID)
+	assert.Equal(t, 3, *first.ReferrerID)
+	assert.Len(t, first.UTMSource, 255)
+	assert.NotContains(t, first.IPHash, "203.0.113.7")
+	assert.Len(t, first.IPHash, 64)
+	// Один и тот же адрес дает один и тот же хеш, чтобы можно было считать уникальных посетителей
+	assert.Equal(t, first.IPHash, second.IPHash)
+}
+
+func TestClickService_TrackClick_ExpiredCodeIsRecordedButNotAttributed(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	clickRepo := mocks.NewReferralClickRepository(t)
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), mocks.NewReferralRepository(t),
+		mocks.NewCampaignRepository(t), nil, nil, nil, nil)
+	clickService := services.NewClickService(codeRepo, clickRepo, referralService, []byte("secret"))
+
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "OLD", ExpiresAt: time.Now().Add(-time.Hour)}
+	codeRepo.On("GetReferralByReferralCode", ctx, "OLD").Return(code, nil)
+	codeRepo.On("GetReferralByReferralCode", ctx, "MISSING").Return(nil, repositories.ErrNotFound)
+	clickRepo.On("CreateClick", ctx, mock.Anything).Return(nil).Once()
+
+	assert.ErrorIs(t, clickService.TrackClick(ctx, "OLD", &entities.ReferralClick{}, "203.0.113.7"), services.ErrReferralCodeExpired)
+	assert.ErrorIs(t, clickService.TrackClick(ctx, "MISSING", &entities.ReferralClick{}, "203.0.113.7"), services.ErrInvalidReferralCode)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+	"time"
+)
+
+const (
+	// MaxBatchSize - сколько кодов можно выпустить одним пакетом
+	MaxBatchSize = 10000
+	// batchCodeLength длиннее личных кодов: пакеты большие, и коды не должны подбираться перебором
+	batchCodeLength = 12
+	// batchInsertAttempts - сколько раз догенерировать коды, если часть из них оказалась занята
+	batchInsertAttempts = 5
+)
+
+// CodeBatchService интерфейс для выпуска пакетов одноразовых кодов для партнеров
+type CodeBatchService interface {
+	// CreateBatch выпускает size одноразовых кодов кампании, регистрации по которым засчитываются партнеру
+	CreateBatch(ctx context.Context, campaignID, partnerID, size int, createdBy *int) (*entities.CodeBatch, error)
+	GetBatch(ctx context.Context, id int) (*entities.CodeBatch, error)
+	ListBatches(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error)
+	// StreamBatchCodes передает коды готового пакета в fn
+	StreamBatchCodes(ctx context.Context, id int, fn func(code *entities.ReferralCode) error) error
+}
+
+// codeBatchService реализация CodeBatchService
+type codeBatchService struct {
+	batchRepo    repositories.CodeBatchRepository
+	campaignRepo repositories.CampaignRepository
+	userRepo     repositories.UserRepository
+}
+
+// NewCodeBatchService создает новый CodeBatchService
+func NewCodeBatchService(batchRepo repositories.CodeBatchRepository,
+	campaignRepo repositories.CampaignRepository,
+	userRepo repositories.UserRepository) CodeBatchService {
+	return &codeBatchService{batchRepo: batchRepo, campaignRepo: campaignRepo, userRepo: userRepo}
+}
+
+// CreateBatch создает задание на выпуск пакета и сразу генерирует коды. Если сгенерировать
+// пакет не удалось, задание остается в статусе failed с описанием ошибки
+func (s *codeBatchService) CreateBatch(ctx context.Context, campaignID, partnerID, size int, createdBy *int) (*entities.CodeBatch, error) {
+	if size <= 0 || size > MaxBatchSize {
+		return nil, invalidField(ErrInvalidCodeBatch, "size", "validation_range", i18n.Args{"min": 1, "max": MaxBatchSize})
+	}
+
+	campaign, err := s.campaignRepo.GetCampaignByID(ctx, campaignID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrCampaignNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Коды печатаются заранее, поэтому кампания может еще не начаться, но не должна закончиться
+	now := time.Now()
+	if !now.Before(campaign.EndsAt) {
+		return nil, ErrCampaignInactive
+	}
+
+	partner, err := s.userRepo.GetUserByID(ctx, partnerID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && partner.DeletedAt != nil) {
+		return nil, invalidField(ErrInvalidCodeBatch, "partner_id", "user_not_found", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &entities.CodeBatch{
+		CampaignID: campaign.ID,
+		PartnerID:  partner.ID,
+		Size:       size,
+		CreatedBy:  createdBy,
+	}
+	if err := s.batchRepo.CreateBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	genErr := s.generate(ctx, batch, campaign, now)
+
+	batch.Status = entities.BatchStatusCompleted
+	if genErr != nil {
+		message := genErr.Error()
+		batch.Status, batch.Error = entities.BatchStatusFailed, &message
+	}
+	// Статус сохраняем, даже если запрос уже отменен, чтобы задание не зависло в pending
+	if err := s.batchRepo.FinishBatch(context.WithoutCancel(ctx), batch); err != nil {
+		return nil, err
+	}
+	if genErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCodeBatchFailed, genErr)
+	}
+
+	return batch, nil
+}
+
+// generate сохраняет коды пакета, догенерируя те, что совпали с уже существующими
+func (s *codeBatchService) generate(ctx context.Context, batch *entities.CodeBatch, campaign *entities.Campaign, now time.Time) error {
+	start := now
+	if campaign.StartsAt.After(start) {
+		start = campaign.StartsAt
+	}
+	expiresAt := start.Add(time.Duration(campaign.CodeTTL) * time.Second)
+	if expiresAt.After(campaign.EndsAt) {
+		expiresAt = campaign.EndsAt
+	}
+	maxUses := 1
+
+	inserted := 0
+	for attempt := 0; attempt < batchInsertAttempts && inserted < batch.Size; attempt++ {
+		codes := make([]*entities.ReferralCode, batch.Size-inserted)
+		for i := range codes {
+			value, err := randomReferralCode(batchCodeLength)
+			if err != nil {
+				return err
+			}
+			codes[i] = &entities.ReferralCode{
+				UserID:     batch.PartnerID,
+				Code:       value,
+				ExpiresAt:  expiresAt,
+				CampaignID: &batch.CampaignID,
+				MaxUses:    &maxUses,
+				BatchID:    &batch.ID,
+			}
+		}
+
+		n, err := s.batchRepo.InsertBatchCodes(ctx, codes)
+		if err != nil {
+			return err
+		}
+		inserted += n
+	}
+
+	if inserted < batch.Size {
+		return fmt.Errorf("generated %d of %d unique codes", inserted, batch.Size)
+	}
+	return nil
+}
+
+// GetBatch возвращает пакет по ID
+func (s *codeBatchService) GetBatch(ctx context.Context, id int) (*entities.CodeBatch, error) {
+	batch, err := s.batchRepo.GetBatchByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrCodeBatchNotFound
+	}
+	return batch, err
+}
+
+// ListBatches возвращает пакеты кампании
+func (s *codeBatchService) ListBatches(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error) {
+	return s.batchRepo.ListBatchesByCampaign(ctx, campaignID)
+}
+
+// StreamBatchCodes передает коды пакета в fn. Коды незавершенного пакета не отдаются
+func (s *codeBatchService) StreamBatchCodes(ctx context.Context, id int, fn func(code *entities.ReferralCode) error) error {
+	batch, err := s.GetBatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if batch.Status != entities.BatchStatusCompleted {
+		return ErrCodeBatchNotReady
+	}
+
+	return s.batchRepo.StreamBatchCodes(ctx, id, fn)
+}
+
+// randomReferralCode генерирует код из charset с помощью crypto/rand. Байты, не делящиеся
+// нацело на длину алфавита, отбрасываются, чтобы символы встречались равновероятно
+func randomReferralCode(length int) (string, error) {
+	limit := byte(256 - 256%len(charset))
+	code := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(code) < length {
+				code = append(code, charset[int(b)%len(charset)])
+			}
+		}
+	}
+	return string(code), nil
+}
@@ -0,0 +1,86 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeBatchService_CreateBatch_RegeneratesTakenCodes(t *testing.T) {
+	ctx := context.Background()
+	batchRepo := mocks.NewCodeBatchRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	batchService := services.NewCodeBatchService(batchRepo, campaignRepo, userRepo)
+
+	campaign := activeCampaign()
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	userRepo.On("GetUserByID", ctx, 9).Return(&entities.User{ID: 9}, nil)
+	batchRepo.On("CreateBatch", ctx, mock.AnythingOfType("*entities.CodeBatch")).
+		Run(func(args mock.Arguments) { args.Get(1).(*entities.CodeBatch).ID = 12 }).
+		Return(nil)
+
+	// Первая вставка сохраняет 2 кода из 3, вторая должна догенерировать один
+	batchRepo.On("InsertBatchCodes", ctx, mock.MatchedBy(func(codes []*entities.ReferralCode) bool {
+		return len(codes) == 3
+	})).Return(2, nil).Once()
+	batchRepo.On("InsertBatchCodes", ctx, mock.MatchedBy(func(codes []*entities.ReferralCode) bool {
+		code := codes[0]
+		return len(codes) == 1 && len(code.Code) == 12 && code.UserID == 9 && *code.BatchID == 12 &&
+			*code.CampaignID == campaign.ID && *code.MaxUses == 1 && !code.ExpiresAt.After(campaign.EndsAt)
+	})).Return(1, nil).Once()
+	batchRepo.On("FinishBatch", mock.Anything, mock.MatchedBy(func(b *entities.CodeBatch) bool {
+		return b.Status == entities.BatchStatusCompleted
+	})).Return(nil)
+
+	createdBy := 1
+	batch, err := batchService.CreateBatch(ctx, campaign.ID, 9, 3, &createdBy)
+	require.NoError(t, err)
+	assert.Equal(t, entities.BatchStatusCompleted, batch.Status)
+}
+
+func TestCodeBatchService_CreateBatch_MarksFailed(t *testing.T) {
+	ctx := context.Background()
+	batchRepo := mocks.NewCodeBatchRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	batchService := services.NewCodeBatchService(batchRepo, campaignRepo, userRepo)
+
+	campaign := activeCampaign()
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	userRepo.On("GetUserByID", ctx, 9).Return(&entities.User{ID: 9}, nil)
+	batchRepo.On("CreateBatch", ctx, mock.Anything).Return(nil)
+	// Все сгенерированные коды заняты
+	batchRepo.On("InsertBatchCodes", ctx, mock.Anything).Return(0, nil)
+	batchRepo.On("FinishBatch", mock.Anything, mock.MatchedBy(func(b *entities.CodeBatch) bool {
+		return b.Status == entities.BatchStatusFailed && b.Error != nil
+	})).Return(nil)
+
+	_, err := batchService.CreateBatch(ctx, campaign.ID, 9, 3, nil)
+	assert.ErrorIs(t, err, services.ErrCodeBatchFailed)
+}
+
+func TestCodeBatchService_CreateBatch_Validates(t *testing.T) {
+	ctx := context.Background()
+	batchService := services.NewCodeBatchService(mocks.NewCodeBatchRepository(t), mocks.NewCampaignRepository(t), mocks.NewUserRepository(t))
+
+	_, err := batchService.CreateBatch(ctx, 4, 9, services.MaxBatchSize+1, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidCodeBatch)
+}
+
+func TestCodeBatchService_StreamBatchCodes_RequiresCompletedBatch(t *testing.T) {
+	ctx := context.Background()
+	batchRepo := mocks.NewCodeBatchRepository(t)
+	batchService := services.NewCodeBatchService(batchRepo, mocks.NewCampaignRepository(t), mocks.NewUserRepository(t))
+
+	batchRepo.On("GetBatchByID", ctx, 12).Return(&entities.CodeBatch{ID: 12, Status: entities.BatchStatusFailed}, nil)
+
+	err := batchService.StreamBatchCodes(ctx, 12, func(*entities.ReferralCode) error { return nil })
+	assert.ErrorIs(t, err, services.ErrCodeBatchNotReady)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+)
+
+const (
+	defaultCodeArchiveAfter = 30 * 24 * time.Hour
+	defaultCodeNotifyBefore = 3 * 24 * time.Hour
+
+	// codeExpiryBatchSize - сколько кодов обрабатывается за один запрос
+	codeExpiryBatchSize = 500
+)
+
+// CodeExpiryNotifier предупреждает владельца, что срок его реферального кода скоро истечет
+type CodeExpiryNotifier interface {
+	SendReferralCodeExpiring(ctx context.Context, code *entities.ExpiringReferralCode) error
+}
+
+// CodeExpiryService интерфейс для фонового обслуживания истекающих реферальных кодов
+type CodeExpiryService interface {
+	// ArchiveExpiredCodes переносит в архив давно истекшие неиспользованные коды и возвращает их число
+	ArchiveExpiredCodes(ctx context.Context) (int, error)
+	// NotifyExpiringCodes предупреждает владельцев кодов, срок которых скоро истечет, и возвращает число писем
+	NotifyExpiringCodes(ctx context.Context) (int, error)
+}
+
+// codeExpiryService реализация CodeExpiryService
+type codeExpiryService struct {
+	referralCodeRepo repositories.ReferralCodeRepository
+	notifier         CodeExpiryNotifier
+	archiveAfter     time.Duration
+	notifyBefore     time.Duration
+}
+
+// NewCodeExpiryService создает новый CodeExpiryService
+func NewCodeExpiryService(referralCodeRepo repositories.ReferralCodeRepository, notifier CodeExpiryNotifier,
+	cfg config.CodeExpiry) CodeExpiryService {
+	s := &codeExpiryService{
+		referralCodeRepo: referralCodeRepo,
+		notifier:         notifier,
+		archiveAfter:     time.Duration(cfg.ArchiveAfter) * time.Second,
+		notifyBefore:     time.Duration(cfg.NotifyBefore) * time.Second,
+	}
+
+	if s.archiveAfter <= 0 {
+		s.archiveAfter = defaultCodeArchiveAfter
+	}
+	if s.notifyBefore <= 0 {
+		s.notifyBefore = defaultCodeNotifyBefore
+	}
+
+	return s
+}
+
+// ArchiveExpiredCodes переносит коды пачками, пока они не закончатся
+func (s *codeExpiryService) ArchiveExpiredCodes(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.archiveAfter)
+
+	total := 0
+	for {
+		archived, err := s.referralCodeRepo.ArchiveExpiredCodes(ctx, before, codeExpiryBatchSize)
+		total += archived
+		if err != nil || archived < codeExpiryBatchSize {
+			return total, err
+		}
+	}
+}
+
+// NotifyExpiringCodes отправляет по одному напоминанию на код. Код отмечается после отправки,
+// поэтому при сбое между ними напоминание может прийти повторно, но не потеряется
+func (s *codeExpiryService) NotifyExpiringCodes(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	sent := 0
+	for {
+		codes, err := s.referralCodeRepo.ListExpiringCodes(ctx, now, now.Add(s.notifyBefore), codeExpiryBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, code := range codes {
+			if err := s.notifier.SendReferralCodeExpiring(ctx, code); err != nil {
+				return sent, err
+			}
+			if err := s.referralCodeRepo.MarkExpiryNotified(ctx, code.CodeID, time.Now()); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(codes) < codeExpiryBatchSize {
+			return sent, nil
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier запоминает коды, о которых отправлены напоминания
+type recordingNotifier struct {
+	codes []string
+	err   error
+}
+
+func (n *recordingNotifier) SendReferralCodeExpiring(_ context.Context, code *entities.ExpiringReferralCode) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.codes = append(n.codes, code.Code)
+	return nil
+}
+
+func TestCodeExpiryService_ArchiveExpiredCodes(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	service := services.NewCodeExpiryService(codeRepo, &recordingNotifier{}, config.CodeExpiry{ArchiveAfter: 3600})
+
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		return time.Until(before) < -59*time.Minute && time.Until(before) > -61*time.Minute
+	})
+	// Полная пачка означает, что коды еще остались
+	codeRepo.On("ArchiveExpiredCodes", ctx, cutoff, 500).Return(500, nil).Once()
+	codeRepo.On("ArchiveExpiredCodes", ctx, cutoff, 500).Return(20, nil).Once()
+
+	archived, err := service.ArchiveExpiredCodes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 520, archived)
+}
+
+func TestCodeExpiryService_NotifyExpiringCodes(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	notifier := &recordingNotifier{}
+	service := services.NewCodeExpiryService(codeRepo, notifier, config.CodeExpiry{})
+
+	codeRepo.On("ListExpiringCodes", ctx, mock.AnythingOfType("time.Time"), mock.MatchedBy(func(to time.Time) bool {
+		return time.Until(to) > 71*time.Hour && time.Until(to) <= 72*time.Hour
+	}), 500).Return([]*entities.ExpiringReferralCode{
+		{CodeID: 1, Code: "FIRST", Email: "first@mail.com"},
+		{CodeID: 2, Code: "SECOND", Email: "second@mail.com"},
+	}, nil)
+	codeRepo.On("MarkExpiryNotified", ctx, 1, mock.AnythingOfType("time.Time")).Return(nil)
+	codeRepo.On("MarkExpiryNotified", ctx, 2, mock.AnythingOfType("time.Time")).Return(nil)
+
+	sent, err := service.NotifyExpiringCodes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"FIRST", "SECOND"}, notifier.codes)
+}
+
+func TestCodeExpiryService_NotifyExpiringCodes_StopsOnSendError(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	notifier := &recordingNotifier{err: errors.New("smtp unavailable")}
+	service := services.NewCodeExpiryService(codeRepo, notifier, config.CodeExpiry{})
+
+	codeRepo.On("ListExpiringCodes", ctx, mock.Anything, mock.Anything, 500).
+		Return([]*entities.ExpiringReferralCode{{CodeID: 1, Code: "FIRST"}}, nil)
+
+	// Код не отмечается, и напоминание будет отправлено при следующем запуске
+	sent, err := service.NotifyExpiringCodes(ctx)
+	assert.ErrorContains(t, err, "smtp unavailable")
+	assert.Equal(t, 0, sent)
+}
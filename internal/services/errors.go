@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"referral-system/internal/i18n"
+)
+
+var (
+	ErrUserAlreadyExists        = errors.New("user already exists")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrTooManyAttempts          = errors.New("too many failed login attempts")
+	ErrWeakPassword             = errors.New("weak password")
+	ErrSessionRevoked           = errors.New("session has been revoked")
+	ErrReauthRequired           = errors.New("sign in again to confirm this action")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+	ErrMFARequired       = errors.New("second factor required")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+
+	ErrUnknownOAuthProvider  = errors.New("unknown oauth provider")
+	ErrInvalidOAuthState     = errors.New("invalid or expired oauth state")
+	ErrOAuthExchangeFailed   = errors.New("oauth provider rejected the login")
+	ErrOAuthEmailNotVerified = errors.New("oauth provider did not return a verified email")
+	ErrOAuthAccountExists    = errors.New("account with this email already exists, sign in and link the provider from the profile")
+	ErrOAuthIdentityLinked   = errors.New("provider account is linked to another user")
+
+	ErrInvalidAPIKey   = errors.New("invalid, expired or revoked api key")
+	ErrInvalidAPIScope = errors.New("invalid api key scope")
+
+	ErrReferralCodeExists   = errors.New("referral code already exists for user")
+	ErrReferralCodeExpired  = errors.New("referral code has expired")
+	ErrInvalidReferralCode  = errors.New("invalid referral code")
+	ErrReferralCodeNotFound = errors.New("referral code not found")
+	ErrReferralNotFound     = errors.New("referral not found")
+	ErrInvalidStatsRange    = errors.New("invalid stats range")
+
+	ErrCampaignNotFound     = errors.New("campaign not found")
+	ErrInvalidCampaign      = errors.New("invalid campaign")
+	ErrCampaignInactive     = errors.New("campaign is not active")
+	ErrCampaignInUse        = errors.New("campaign already has referral codes")
+	ErrReferralLimitReached = errors.New("referral limit reached")
+
+	ErrInvalidCodeBatch  = errors.New("invalid code batch")
+	ErrCodeBatchNotFound = errors.New("code batch not found")
+	ErrCodeBatchNotReady = errors.New("code batch is not completed")
+	ErrCodeBatchFailed   = errors.New("code batch generation failed")
+
+	ErrInvalidWebhook          = errors.New("invalid webhook subscription")
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrInvalidNotificationType = errors.New("invalid notification type")
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+)
+
+// FieldError уточняет ошибку проверки запроса: Err определяет ответ, а Message описывает, что не так с полем,
+// сообщением каталога, которое переводится на язык клиента
+type FieldError struct {
+	Err     error
+	Field   string
+	Message i18n.Message
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Err, e.Field, e.Message.Translate(i18n.DefaultLocale))
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// invalidField создает FieldError для поля field с сообщением code из каталога
+func invalidField(err error, field, code string, args i18n.Args) error {
+	return &FieldError{Err: err, Field: field, Message: i18n.Message{Code: code, Args: args}}
+}
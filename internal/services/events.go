@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"time"
+)
+
+// EventPublisher публикует доменные события для внешних систем
+type EventPublisher interface {
+	Publish(ctx context.Context, event *entities.Event) error
+}
+
+// newEvent создает событие с новым ID
+func newEvent(eventType string, data any) (*entities.Event, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.Event{ID: id, Type: eventType, OccurredAt: time.Now().UTC(), Data: data}, nil
+}
+
+// publish создает и публикует событие
+func publish(ctx context.Context, publisher EventPublisher, eventType string, data any) error {
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(ctx, event)
+}
+
+// backoff возвращает паузу после attempts неудачных попыток: base, удваивающаяся до maxDelay
+func backoff(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
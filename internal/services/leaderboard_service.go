@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+)
+
+// LeaderboardService интерфейс для рейтинга рефереров по квалифицированным рефералам
+type LeaderboardService interface {
+	// GetLeaderboard возвращает первые limit мест за период и место пользователя userID
+	GetLeaderboard(ctx context.Context, period string, limit int, userID int) (*entities.Leaderboard, error)
+	// RefreshLeaderboard пересчитывает снимок рейтинга, вызывается по расписанию
+	RefreshLeaderboard(ctx context.Context) error
+}
+
+// leaderboardService реализация LeaderboardService
+type leaderboardService struct {
+	leaderboardRepo repositories.LeaderboardRepository
+	userRepo        repositories.UserRepository
+}
+
+// NewLeaderboardService создает новый LeaderboardService
+func NewLeaderboardService(leaderboardRepo repositories.LeaderboardRepository, userRepo repositories.UserRepository) LeaderboardService {
+	return &leaderboardService{leaderboardRepo: leaderboardRepo, userRepo: userRepo}
+}
+
+// GetLeaderboard читает рейтинг из снимка. Пользователь, скрывший себя, не видит своего места
+func (s *leaderboardService) GetLeaderboard(ctx context.Context, period string, limit int, userID int) (*entities.Leaderboard, error) {
+	entries, err := s.leaderboardRepo.GetTopReferrers(ctx, period, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := &entities.Leaderboard{Period: period, Entries: entries}
+	if len(entries) > 0 {
+		leaderboard.RefreshedAt = &entries[0].RefreshedAt
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.LeaderboardOptOut {
+		leaderboard.OptedOut = true
+		return leaderboard, nil
+	}
+
+	me, err := s.leaderboardRepo.GetLeaderboardEntry(ctx, period, userID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+	leaderboard.Me = me
+
+	return leaderboard, nil
+}
+
+// RefreshLeaderboard пересчитывает снимок рейтинга
+func (s *leaderboardService) RefreshLeaderboard(ctx context.Context) error {
+	return s.leaderboardRepo.RefreshLeaderboard(ctx)
+}
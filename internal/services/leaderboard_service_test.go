@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardService_GetLeaderboard(t *testing.T) {
+	ctx := context.Background()
+	leaderboardRepo := mocks.NewLeaderboardRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	leaderboardService := services.NewLeaderboardService(leaderboardRepo, userRepo)
+
+	refreshedAt := time.Now()
+	top := []*entities.LeaderboardEntry{{Rank: 1, UserID: 3, Name: "Ann", Qualified: 7, RefreshedAt: refreshedAt}}
+	leaderboardRepo.On("GetTopReferrers", ctx, entities.LeaderboardWeek, 10).Return(top, nil)
+	userRepo.On("GetUserByID", ctx, 5).Return(&entities.User{ID: 5}, nil)
+	leaderboardRepo.On("GetLeaderboardEntry", ctx, entities.LeaderboardWeek, 5).
+		Return(&entities.LeaderboardEntry{Rank: 4, UserID: 5, Qualified: 2}, nil)
+
+	leaderboard, err := leaderboardService.GetLeaderboard(ctx, entities.LeaderboardWeek, 10, 5)
+	require.NoError(t, err)
+	assert.Equal(t, top, leaderboard.Entries)
+	require.NotNil(t, leaderboard.RefreshedAt)
+	assert.Equal(t, refreshedAt, *leaderboard.RefreshedAt)
+	require.NotNil(t, leaderboard.Me)
+	assert.Equal(t, 4, leaderboard.Me.Rank)
+	assert.False(t, leaderboard.OptedOut)
+}
+
+func TestLeaderboardService_GetLeaderboard_UnrankedAndOptedOut(t *testing.T) {
+	ctx := context.Background()
+	leaderboardRepo := mocks.NewLeaderboardRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	leaderboardService := services.NewLeaderboardService(leaderboardRepo, userRepo)
+
+	leaderboardRepo.On("GetTopReferrers", ctx, entities.LeaderboardAllTime, 10).Return(nil, nil)
+	userRepo.On("GetUserByID", ctx, 5).Return(&entities.User{ID: 5}, nil)
+	userRepo.On("GetUserByID", ctx, 6).Return(&entities.User{ID: 6, LeaderboardOptOut: true}, nil)
+	leaderboardRepo.On("GetLeaderboardEntry", ctx, entities.LeaderboardAllTime, 5).Return(nil, repositories.ErrNotFound)
+
+	leaderboard, err := leaderboardService.GetLeaderboard(ctx, entities.LeaderboardAllTime, 10, 5)
+	require.NoError(t, err)
+	assert.Nil(t, leaderboard.Me)
+	assert.Nil(t, leaderboard.RefreshedAt)
+
+	// Скрывший себя пользователь не получает места, даже если оно есть в снимке
+	leaderboard, err = leaderboardService.GetLeaderboard(ctx, entities.LeaderboardAllTime, 10, 6)
+	require.NoError(t, err)
+	assert.Nil(t, leaderboard.Me)
+	assert.True(t, leaderboard.OptedOut)
+}
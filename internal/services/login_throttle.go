@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"referral-system/internal/config"
+	"referral-system/internal/repositories"
+	"strings"
+	"time"
+)
+
+// Значения по умолчанию для config.LoginProtection
+const (
+	defaultMaxLoginAttempts   = 5
+	defaultIPMaxLoginAttempts = 20
+	defaultLoginWindow        = 15 * time.Minute
+	defaultBaseLockout        = 30 * time.Second
+	defaultMaxLockout         = time.Hour
+)
+
+// LockoutError возвращается, когда вход временно заблокирован после серии ошибок
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// loginThrottle считает неудачные попытки входа по аккаунту и по IP адресу
+// и блокирует вход с экспоненциально растущей длительностью
+type loginThrottle struct {
+	repo          repositories.LoginAttemptRepository
+	maxAttempts   int
+	ipMaxAttempts int
+	window        time.Duration
+	baseLockout   time.Duration
+	maxLockout    time.Duration
+}
+
+func newLoginThrottle(repo repositories.LoginAttemptRepository, cfg config.LoginProtection) *loginThrottle {
+	t := &loginThrottle{
+		repo:          repo,
+		maxAttempts:   cfg.MaxAttempts,
+		ipMaxAttempts: cfg.IPMaxAttempts,
+		window:        time.Duration(cfg.Window) * time.Second,
+		baseLockout:   time.Duration(cfg.BaseLockout) * time.Second,
+		maxLockout:    time.Duration(cfg.MaxLockout) * time.Second,
+	}
+
+	if t.maxAttempts <= 0 {
+		t.maxAttempts = defaultMaxLoginAttempts
+	}
+	if t.ipMaxAttempts <= 0 {
+		t.ipMaxAttempts = defaultIPMaxLoginAttempts
+	}
+	if t.window <= 0 {
+		t.window = defaultLoginWindow
+	}
+	if t.baseLockout <= 0 {
+		t.baseLockout = defaultBaseLockout
+	}
+	if t.maxLockout < t.baseLockout {
+		t.maxLockout = max(defaultMaxLockout, t.baseLockout)
+	}
+
+	return t
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// check возвращает *LockoutError, если хотя бы один из ключей сейчас заблокирован
+func (t *loginThrottle) check(ctx context.Context, keys ...string) error {
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempt, err := t.repo.GetLoginAttempt(ctx, key)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			retryAfter = max(retryAfter, attempt.LockedUntil.Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LockoutError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// fail учитывает неудачную попытку и при превышении лимита блокирует ключ
+func (t *loginThrottle) fail(ctx context.Context, key string, limit int) error {
+	now := time.Now()
+
+	attempt, err := t.repo.RecordFailedLogin(ctx, key, now.Add(-t.window))
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures < limit {
+		return nil
+	}
+
+	return t.repo.LockLogin(ctx, key, now.Add(t.lockout(attempt.Failures-limit)))
+}
+
+// lockout возвращает длительность блокировки: base * 2^exceeded, но не больше maxLockout
+func (t *loginThrottle) lockout(exceeded int) time.Duration {
+	lockout := t.baseLockout
+	for i := 0; i < exceeded && lockout < t.maxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, t.maxLockout)
+}
+
+// failLogin учитывает неудачную попытку сразу для аккаунта и для IP
+func (t *loginThrottle) failLogin(ctx context.Context, email, ip string) error {
+	if err := t.fail(ctx, accountKey(email), t.maxAttempts); err != nil {
+		return err
+	}
+	return t.fail(ctx, ipKey(ip), t.ipMaxAttempts)
+}
+
+// reset снимает блокировку и обнуляет счетчик ключа
+func (t *loginThrottle) reset(ctx context.Context, key string) error {
+	return t.repo.ResetLoginAttempts(ctx, key)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/totp"
+	"referral-system/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMFAIssuer = "Referral System"
+
+	recoveryCodesCount = 10
+	// totpSkew - сколько соседних временных шагов принимается из-за рассинхронизации часов
+	totpSkew = 1
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFAService интерфейс для управления вторым фактором аутентификации
+type MFAService interface {
+	EnrollTOTP(ctx context.Context, userID int) (*entities.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int, code string) error
+	// VerifyCode проверяет TOTP код или одноразовый код восстановления пользователя
+	VerifyCode(ctx context.Context, user *entities.User, code string) error
+}
+
+// mfaService реализация MFAService
+type mfaService struct {
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	issuer           string
+}
+
+// NewMFAService создает новый MFAService
+func NewMFAService(userRepo repositories.UserRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, issuer string) MFAService {
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	return &mfaService{userRepo: userRepo, recoveryCodeRepo: recoveryCodeRepo, issuer: issuer}
+}
+
+func (s *mfaService) getUser(ctx context.Context, userID int) (*entities.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// EnrollTOTP создает новый секрет. Второй фактор включается только после ConfirmTOTP
+func (s *mfaService) EnrollTOTP(ctx context.Context, userID int) (*entities.TOTPEnrollment, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.SetTOTPSecret(ctx, userID, secret)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// второй фактор успели включить параллельным запросом
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает второй фактор по коду из приложения и возвращает коды восстановления.
+// Коды показываются пользователю один раз, в базе хранятся только их хеши
+func (s *mfaService) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	// Если секрет заменили повторным EnrollTOTP или второй фактор уже включен, код больше не подходит
+	err = s.userRepo.EnableTOTP(ctx, userID, *user.TOTPSecret, step)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidMFACode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор после проверки действующего кода
+func (s *mfaService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	if err := s.VerifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	err = s.userRepo.DisableTOTP(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteRecoveryCodes(ctx, user.ID)
+}
+
+// VerifyCode проверяет TOTP код, не допуская повторного использования уже принятого шага,
+// а если код не похож на TOTP - пробует его как код восстановления
+func (s *mfaService) VerifyCode(ctx context.Context, user *entities.User, code string) error {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok || step <= user.TOTPLastStep {
+			return ErrInvalidMFACode
+		}
+
+		// Шаг сохраняется условным обновлением: из параллельных запросов с одним кодом пройдет только один
+		err := s.userRepo.UseTOTPStep(ctx, user.ID, step)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+		if err != nil {
+			return err
+		}
+
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	err := s.recoveryCodeRepo.UseRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+// generateRecoveryCodes возвращает коды вида xxxx-xxxx и их хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	buf := make([]byte, 5)
+	for i := 0; i < recoveryCodesCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,165 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/totp"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMFAService_EnrollAndConfirm(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	recoveryRepo := mocks.NewRecoveryCodeRepository(t)
+	mfaService := services.NewMFAService(userRepo, recoveryRepo, "Test")
+
+	user := &entities.User{ID: 1, Email: "user@mail.com"}
+	userRepo.On("GetUserByID", ctx, 1).Return(user, nil)
+	userRepo.On("SetTOTPSecret", ctx, 1, mock.Anything).
+		Run(func(args mock.Arguments) {
+			secret := args.String(2)
+			user.TOTPSecret = &secret
+		}).Return(nil)
+	recoveryRepo.On("ReplaceRecoveryCodes", ctx, 1, mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == 10
+	})).Return(nil)
+
+	enrollment, err := mfaService.EnrollTOTP(ctx, 1)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Test:user@mail.com")
+	assert.Equal(t, enrollment.Secret, *user.TOTPSecret)
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	wrongCode := string('0'+(code[0]-'0'+1)%10) + code[1:]
+	_, err = mfaService.ConfirmTOTP(ctx, 1, wrongCode)
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+
+	// Второй фактор включается только с тем секретом, по которому проверен код
+	userRepo.On("EnableTOTP", ctx, 1, enrollment.Secret, mock.Anything).
+		Run(func(args mock.Arguments) {
+			user.TOTPEnabled = true
+			user.TOTPLastStep = args.Get(3).(int64)
+		}).Return(nil).Once()
+	recoveryCodes, err := mfaService.ConfirmTOTP(ctx, 1, code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+
+	// Тот же код нельзя использовать повторно
+	assert.ErrorIs(t, mfaService.VerifyCode(ctx, user, code), services.ErrInvalidMFACode)
+}
+
+func TestMFAService_ConfirmTOTP_SecretReplaced(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	recoveryRepo := mocks.NewRecoveryCodeRepository(t)
+	mfaService := services.NewMFAService(userRepo, recoveryRepo, "Test")
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, TOTPSecret: &secret}, nil)
+	recoveryRepo.On("ReplaceRecoveryCodes", ctx, 1, mock.Anything).Return(nil)
+	// Параллельный EnrollTOTP заменил секрет, и условное обновление не затронуло строку
+	userRepo.On("EnableTOTP", ctx, 1, secret, mock.Anything).Return(repositories.ErrNotFound)
+
+	_, err = mfaService.ConfirmTOTP(ctx, 1, code)
+	assert.ErrorIs(t, err, services.ErrInvalidMFACode)
+}
+
+func TestMFAService_VerifyRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	recoveryRepo := mocks.NewRecoveryCodeRepository(t)
+	mfaService := services.NewMFAService(userRepo, recoveryRepo, "Test")
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: &secret}
+
+	recoveryRepo.On("UseRecoveryCode", ctx, 1, mock.Anything).Return(nil).Once()
+	recoveryRepo.On("UseRecoveryCode", ctx, 1, mock.Anything).Return(repositories.ErrNotFound).Once()
+
+	assert.NoError(t, mfaService.VerifyCode(ctx, user, "ABCD-EFGH"))
+	assert.ErrorIs(t, mfaService.VerifyCode(ctx, user, "ABCD-EFGH"), services.ErrInvalidMFACode)
+}
+
+func TestAuthService_LoginUser_RequiresSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	attemptRepo := mocks.NewLoginAttemptRepository(t)
+	recoveryRepo := mocks.NewRecoveryCodeRepository(t)
+	mfaService := services.NewMFAService(userRepo, recoveryRepo, "Test")
+	challengeRepo := mocks.NewMFAChallengeRepository(t)
+	authService := services.NewAuthService(userRepo, attemptRepo, newTokens(t), allowAll{}, loginProtection, mfaService, challengeRepo)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("right_password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user := &entities.User{ID: 1, Email: "user@mail.com", HashedPassword: string(hash), TOTPEnabled: true, TOTPSecret: &secret}
+
+	attemptRepo.On("GetLoginAttempt", ctx, mock.Anything).Return(nil, repositories.ErrNotFound)
+	attemptRepo.On("ResetLoginAttempts", ctx, "email:user@mail.com").Return(nil)
+	userRepo.On("GetUserByEmail", ctx, "user@mail.com").Return(user, nil)
+	userRepo.On("GetUserByID", ctx, 1).Return(user, nil)
+	userRepo.On("UseTOTPStep", ctx, 1, mock.Anything).Return(nil).Once()
+	challengeRepo.On("UseMFAChallenge", ctx, mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, token, err := authService.LoginUser(ctx, "user@mail.com", "right_password", "10.0.0.1")
+	assert.Empty(t, token)
+
+	var mfaErr *services.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	// Верный пароль не сбрасывает счетчик ошибок, пока не введен второй фактор
+	attemptRepo.AssertNotCalled(t, "ResetLoginAttempts", mock.Anything, mock.Anything)
+
+	// Токен второго шага не является полноценным токеном доступа
+	_, _, err = authService.VerifyMFA(ctx, "not-a-token", "123456", "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAToken)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	loggedIn, token, err := authService.VerifyMFA(ctx, mfaErr.Token, code, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, loggedIn.ID)
+	assert.NotEmpty(t, token)
+	attemptRepo.AssertCalled(t, "ResetLoginAttempts", ctx, "email:user@mail.com")
+
+	// Токен второго шага принимается один раз, даже с новым верным кодом
+	user.TOTPLastStep = 0
+	userRepo.On("UseTOTPStep", ctx, 1, mock.Anything).Return(nil).Once()
+	challengeRepo.On("UseMFAChallenge", ctx, mock.Anything, mock.Anything).Return(repositories.ErrConflict).Once()
+	_, _, err = authService.VerifyMFA(ctx, mfaErr.Token, code, "10.0.0.1")
+	assert.ErrorIs(t, err, services.ErrInvalidMFAToken)
+}
+
+func TestMFAService_VerifyCode_ConcurrentReplay(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	mfaService := services.NewMFAService(userRepo, mocks.NewRecoveryCodeRepository(t), "Test")
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	// Параллельный запрос уже принял этот шаг, и условное обновление не затронуло строку
+	userRepo.On("UseTOTPStep", ctx, 1, mock.Anything).Return(repositories.ErrNotFound)
+
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: &secret}
+	assert.ErrorIs(t, mfaService.VerifyCode(ctx, user, code), services.ErrInvalidMFACode)
+}
@@ -0,0 +1,171 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CodeBatchRepository is an autogenerated mock type for the CodeBatchRepository type
+type CodeBatchRepository struct {
+	mock.Mock
+}
+
+// CreateBatch provides a mock function with given fields: ctx, batch
+func (_m *CodeBatchRepository) CreateBatch(ctx context.Context, batch *entities.CodeBatch) error {
+	ret := _m.Called(ctx, batch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.CodeBatch) error); ok {
+		r0 = rf(ctx, batch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishBatch provides a mock function with given fields: ctx, batch
+func (_m *CodeBatchRepository) FinishBatch(ctx context.Context, batch *entities.CodeBatch) error {
+	ret := _m.Called(ctx, batch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.CodeBatch) error); ok {
+		r0 = rf(ctx, batch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetBatchByID provides a mock function with given fields: ctx, id
+func (_m *CodeBatchRepository) GetBatchByID(ctx context.Context, id int) (*entities.CodeBatch, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBatchByID")
+	}
+
+	var r0 *entities.CodeBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.CodeBatch, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.CodeBatch); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.CodeBatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertBatchCodes provides a mock function with given fields: ctx, codes
+func (_m *CodeBatchRepository) InsertBatchCodes(ctx context.Context, codes []*entities.ReferralCode) (int, error) {
+	ret := _m.Called(ctx, codes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertBatchCodes")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*entities.ReferralCode) (int, error)); ok {
+		return rf(ctx, codes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []*entities.ReferralCode) int); ok {
+		r0 = rf(ctx, codes)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*entities.ReferralCode) error); ok {
+		r1 = rf(ctx, codes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBatchesByCampaign provides a mock function with given fields: ctx, campaignID
+func (_m *CodeBatchRepository) ListBatchesByCampaign(ctx context.Context, campaignID int) ([]*entities.CodeBatch, error) {
+	ret := _m.Called(ctx, campaignID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBatchesByCampaign")
+	}
+
+	var r0 []*entities.CodeBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.CodeBatch, error)); ok {
+		return rf(ctx, campaignID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.CodeBatch); ok {
+		r0 = rf(ctx, campaignID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.CodeBatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, campaignID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamBatchCodes provides a mock function with given fields: ctx, batchID, fn
+func (_m *CodeBatchRepository) StreamBatchCodes(ctx context.Context, batchID int, fn func(*entities.ReferralCode) error) error {
+	ret := _m.Called(ctx, batchID, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamBatchCodes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, func(*entities.ReferralCode) error) error); ok {
+		r0 = rf(ctx, batchID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCodeBatchRepository creates a new instance of CodeBatchRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCodeBatchRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CodeBatchRepository {
+	mock := &CodeBatchRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
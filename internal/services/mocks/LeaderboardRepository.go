@@ -0,0 +1,107 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// LeaderboardRepository is an autogenerated mock type for the LeaderboardRepository type
+type LeaderboardRepository struct {
+	mock.Mock
+}
+
+// GetLeaderboardEntry provides a mock function with given fields: ctx, period, userID
+func (_m *LeaderboardRepository) GetLeaderboardEntry(ctx context.Context, period string, userID int) (*entities.LeaderboardEntry, error) {
+	ret := _m.Called(ctx, period, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLeaderboardEntry")
+	}
+
+	var r0 *entities.LeaderboardEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (*entities.LeaderboardEntry, error)); ok {
+		return rf(ctx, period, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) *entities.LeaderboardEntry); ok {
+		r0 = rf(ctx, period, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.LeaderboardEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, period, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopReferrers provides a mock function with given fields: ctx, period, limit
+func (_m *LeaderboardRepository) GetTopReferrers(ctx context.Context, period string, limit int) ([]*entities.LeaderboardEntry, error) {
+	ret := _m.Called(ctx, period, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopReferrers")
+	}
+
+	var r0 []*entities.LeaderboardEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*entities.LeaderboardEntry, error)); ok {
+		return rf(ctx, period, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*entities.LeaderboardEntry); ok {
+		r0 = rf(ctx, period, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.LeaderboardEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, period, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefreshLeaderboard provides a mock function with given fields: ctx
+func (_m *LeaderboardRepository) RefreshLeaderboard(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshLeaderboard")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLeaderboardRepository creates a new instance of LeaderboardRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLeaderboardRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LeaderboardRepository {
+	mock := &LeaderboardRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,123 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NotificationRepository is an autogenerated mock type for the NotificationRepository type
+type NotificationRepository struct {
+	mock.Mock
+}
+
+// ClaimNotification provides a mock function with given fields: ctx, notification
+func (_m *NotificationRepository) ClaimNotification(ctx context.Context, notification *entities.SentNotification) (bool, error) {
+	ret := _m.Called(ctx, notification)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimNotification")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.SentNotification) (bool, error)); ok {
+		return rf(ctx, notification)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.SentNotification) bool); ok {
+		r0 = rf(ctx, notification)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *entities.SentNotification) error); ok {
+		r1 = rf(ctx, notification)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPreferences provides a mock function with given fields: ctx, userID
+func (_m *NotificationRepository) GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPreferences")
+	}
+
+	var r0 entities.NotificationPreferences
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (entities.NotificationPreferences, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) entities.NotificationPreferences); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.NotificationPreferences)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseNotification provides a mock function with given fields: ctx, key
+func (_m *NotificationRepository) ReleaseNotification(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseNotification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPreferences provides a mock function with given fields: ctx, userID, preferences
+func (_m *NotificationRepository) SetPreferences(ctx context.Context, userID int, preferences entities.NotificationPreferences) error {
+	ret := _m.Called(ctx, userID, preferences)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPreferences")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, entities.NotificationPreferences) error); ok {
+		r0 = rf(ctx, userID, preferences)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewNotificationRepository creates a new instance of NotificationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewNotificationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *NotificationRepository {
+	mock := &NotificationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OAuthStateRepository is an autogenerated mock type for the OAuthStateRepository type
+type OAuthStateRepository struct {
+	mock.Mock
+}
+
+// ConsumeState provides a mock function with given fields: ctx, stateHash
+func (_m *OAuthStateRepository) ConsumeState(ctx context.Context, stateHash string) (*entities.OAuthState, error) {
+	ret := _m.Called(ctx, stateHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeState")
+	}
+
+	var r0 *entities.OAuthState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entities.OAuthState, error)); ok {
+		return rf(ctx, stateHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entities.OAuthState); ok {
+		r0 = rf(ctx, stateHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.OAuthState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, stateHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateState provides a mock function with given fields: ctx, state
+func (_m *OAuthStateRepository) CreateState(ctx context.Context, state *entities.OAuthState) error {
+	ret := _m.Called(ctx, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.OAuthState) error); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewOAuthStateRepository creates a new instance of OAuthStateRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOAuthStateRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OAuthStateRepository {
+	mock := &OAuthStateRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReferralClickRepository is an autogenerated mock type for the ReferralClickRepository type
+type ReferralClickRepository struct {
+	mock.Mock
+}
+
+// CreateClick provides a mock function with given fields: ctx, click
+func (_m *ReferralClickRepository) CreateClick(ctx context.Context, click *entities.ReferralClick) error {
+	ret := _m.Called(ctx, click)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.ReferralClick) error); ok {
+		r0 = rf(ctx, click)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListClicksByUserID provides a mock function with given fields: ctx, userID
+func (_m *ReferralClickRepository) ListClicksByUserID(ctx context.Context, userID int) ([]*entities.ReferralClick, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListClicksByUserID")
+	}
+
+	var r0 []*entities.ReferralClick
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.ReferralClick, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.ReferralClick); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.ReferralClick)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReferralClickRepository creates a new instance of ReferralClickRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReferralClickRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReferralClickRepository {
+	mock := &ReferralClickRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
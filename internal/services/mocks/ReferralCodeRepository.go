@@ -0,0 +1,281 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ReferralCodeRepository is an autogenerated mock type for the ReferralCodeRepository type
+type ReferralCodeRepository struct {
+	mock.Mock
+}
+
+// ArchiveExpiredCodes provides a mock function with given fields: ctx, before, limit
+func (_m *ReferralCodeRepository) ArchiveExpiredCodes(ctx context.Context, before time.Time, limit int) (int, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveExpiredCodes")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) (int, error)); ok {
+		return rf(ctx, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ArchivePersonalCodeExpiredBefore provides a mock function with given fields: ctx, userID, before
+func (_m *ReferralCodeRepository) ArchivePersonalCodeExpiredBefore(ctx context.Context, userID int, before time.Time) error {
+	ret := _m.Called(ctx, userID, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchivePersonalCodeExpiredBefore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateReferralCode provides a mock function with given fields: ctx, referral
+func (_m *ReferralCodeRepository) CreateReferralCode(ctx context.Context, referral *entities.ReferralCode) error {
+	ret := _m.Called(ctx, referral)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReferralCode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.ReferralCode) error); ok {
+		r0 = rf(ctx, referral)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteReferralCodeByUserID provides a mock function with given fields: ctx, userID
+func (_m *ReferralCodeRepository) DeleteReferralCodeByUserID(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteReferralCodeByUserID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetReferralByReferralCode provides a mock function with given fields: ctx, referralCode
+func (_m *ReferralCodeRepository) GetReferralByReferralCode(ctx context.Context, referralCode string) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, referralCode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReferralByReferralCode")
+	}
+
+	var r0 *entities.ReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, referralCode)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entities.ReferralCode); ok {
+		r0 = rf(ctx, referralCode)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.ReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, referralCode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReferralCodeByUserID provides a mock function with given fields: ctx, userID
+func (_m *ReferralCodeRepository) GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReferralCodeByUserID")
+	}
+
+	var r0 *entities.ReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.ReferralCode); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.ReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListExpiringCodes provides a mock function with given fields: ctx, from, to, limit
+func (_m *ReferralCodeRepository) ListExpiringCodes(ctx context.Context, from time.Time, to time.Time, limit int) ([]*entities.ExpiringReferralCode, error) {
+	ret := _m.Called(ctx, from, to, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpiringCodes")
+	}
+
+	var r0 []*entities.ExpiringReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) ([]*entities.ExpiringReferralCode, error)); ok {
+		return rf(ctx, from, to, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) []*entities.ExpiringReferralCode); ok {
+		r0 = rf(ctx, from, to, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.ExpiringReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, int) error); ok {
+		r1 = rf(ctx, from, to, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReferralCodesByUserID provides a mock function with given fields: ctx, userID
+func (_m *ReferralCodeRepository) ListReferralCodesByUserID(ctx context.Context, userID int) ([]*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListReferralCodesByUserID")
+	}
+
+	var r0 []*entities.ReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.ReferralCode, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.ReferralCode); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.ReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockReferralCode provides a mock function with given fields: ctx, id
+func (_m *ReferralCodeRepository) LockReferralCode(ctx context.Context, id int) (*entities.ReferralCode, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockReferralCode")
+	}
+
+	var r0 *entities.ReferralCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.ReferralCode, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.ReferralCode); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.ReferralCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkExpiryNotified provides a mock function with given fields: ctx, codeID, at
+func (_m *ReferralCodeRepository) MarkExpiryNotified(ctx context.Context, codeID int, at time.Time) error {
+	ret := _m.Called(ctx, codeID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkExpiryNotified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, codeID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewReferralCodeRepository creates a new instance of ReferralCodeRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReferralCodeRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReferralCodeRepository {
+	mock := &ReferralCodeRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,260 @@
+// Code generated by mockery v2.46.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entities "referral-system/internal/entities"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ReferralRepository is an autogenerated mock type for the ReferralRepository type
+type ReferralRepository struct {
+	mock.Mock
+}
+
+// CountQualifiedReferrals provides a mock function with given fields: ctx, referrerID
+func (_m *ReferralRepository) CountQualifiedReferrals(ctx context.Context, referrerID int) (int, error) {
+	ret := _m.Called(ctx, referrerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountQualifiedReferrals")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, referrerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, referrerID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, referrerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountReferralsByCampaign provides a mock function with given fields: ctx, campaignID
+func (_m *ReferralRepository) CountReferralsByCampaign(ctx context.Context, campaignID int) (int, error) {
+	ret := _m.Called(ctx, campaignID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountReferralsByCampaign")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, campaignID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, campaignID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, campaignID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountReferralsByCode provides a mock function with given fields: ctx, referralCodeID
+func (_m *ReferralRepository) CountReferralsByCode(ctx context.Context, referralCodeID int) (int, error) {
+	ret := _m.Called(ctx, referralCodeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountReferralsByCode")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, referralCodeID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, referralCodeID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, referralCodeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReferralLink provides a mock function with given fields: ctx, referral
+func (_m *ReferralRepository) CreateReferralLink(ctx context.Context, referral *entities.Referral) error {
+	ret := _m.Called(ctx, referral)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReferralLink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.Referral) error); ok {
+		r0 = rf(ctx, referral)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetReferralByRefereeID provides a mock function with given fields: ctx, refereeID
+func (_m *ReferralRepository) GetReferralByRefereeID(ctx context.Context, refereeID int) (*entities.Referral, error) {
+	ret := _m.Called(ctx, refereeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReferralByRefereeID")
+	}
+
+	var r0 *entities.Referral
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entities.Referral, error)); ok {
+		return rf(ctx, refereeID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entities.Referral); ok {
+		r0 = rf(ctx, refereeID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.Referral)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, refereeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReferralStatsSeries provides a mock function with given fields: ctx, filter
+func (_m *ReferralRepository) GetReferralStatsSeries(ctx context.Context, filter entities.ReferralStatsFilter) ([]*entities.ReferralStatsBucket, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReferralStatsSeries")
+	}
+
+	var r0 []*entities.ReferralStatsBucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entities.ReferralStatsFilter) ([]*entities.ReferralStatsBucket, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entities.ReferralStatsFilter) []*entities.ReferralStatsBucket); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.ReferralStatsBucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entities.ReferralStatsFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReferralsByReferrerID provides a mock function with given fields: ctx, referrerID
+func (_m *ReferralRepository) GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error) {
+	ret := _m.Called(ctx, referrerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReferralsByReferrerID")
+	}
+
+	var r0 []*entities.Referral
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*entities.Referral, error)); ok {
+		return rf(ctx, referrerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*entities.Referral); ok {
+		r0 = rf(ctx, referrerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entities.Referral)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, referrerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QualifyReferral provides a mock function with given fields: ctx, id, at
+func (_m *ReferralRepository) QualifyReferral(ctx context.Context, id int, at time.Time) (*entities.Referral, bool, error) {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QualifyReferral")
+	}
+
+	var r0 *entities.Referral
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) (*entities.Referral, bool, error)); ok {
+		return rf(ctx, id, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) *entities.Referral); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entities.Referral)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Time) bool); ok {
+		r1 = rf(ctx, id, at)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, time.Time) error); ok {
+		r2 = rf(ctx, id, at)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// NewReferralRepository creates a new instance of ReferralRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReferralRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReferralRepository {
+	mock := &ReferralRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// unsubscribeAll - тип в ссылке отписки от всех уведомлений
+	unsubscribeAll = "all"
+	// emailVerificationTemplate - шаблон письма с кодом подтверждения нового email
+	emailVerificationTemplate = "email_verification"
+)
+
+// EmailSender отправляет готовое письмо
+type EmailSender interface {
+	SendEmail(ctx context.Context, msg *entities.EmailMessage) error
+}
+
+// EmailTemplates собирает тему и HTML письма по шаблону name на языке locale
+type EmailTemplates interface {
+	Render(locale, name string, data any) (subject, html string, err error)
+}
+
+// NotificationService интерфейс для уведомлений пользователей
+type NotificationService interface {
+	// HandleEvent уведомляет участников реферала о доменном событии. Повторы события отсеиваются
+	HandleEvent(ctx context.Context, event *entities.Event) error
+	// SendReferralCodeExpiring предупреждает владельца об истечении срока кода
+	SendReferralCodeExpiring(ctx context.Context, code *entities.ExpiringReferralCode) error
+	// SendEmailVerification отправляет на новый email пользователя код подтверждения
+	SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error
+
+	// GetPreferences возвращает настройки всех типов уведомлений пользователя
+	GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error)
+	// UpdatePreferences меняет настройки переданных типов и возвращает все настройки
+	UpdatePreferences(ctx context.Context, userID int, preferences entities.NotificationPreferences) (entities.NotificationPreferences, error)
+	// Unsubscribe отключает уведомления по токену из ссылки в письме
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+// notificationService реализация NotificationService
+type notificationService struct {
+	notificationRepo repositories.NotificationRepository
+	userRepo         repositories.UserRepository
+	sender           EmailSender
+	templates        EmailTemplates
+	locale           string
+	baseURL          string
+	unsubscribeKey   []byte
+}
+
+// NewNotificationService создает новый NotificationService. Письма уходят на языке пользователя, а если он
+// не выбран - на cfg.DefaultLocale. Без cfg.BaseURL письма уходят без ссылки отписки
+func NewNotificationService(notificationRepo repositories.NotificationRepository, userRepo repositories.UserRepository,
+	sender EmailSender, templates EmailTemplates, cfg config.Notifications, unsubscribeKey []byte) NotificationService {
+	s := &notificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		sender:           sender,
+		templates:        templates,
+		locale:           cfg.DefaultLocale,
+		baseURL:          strings.TrimSuffix(cfg.BaseURL, "/"),
+		unsubscribeKey:   unsubscribeKey,
+	}
+
+	if s.locale == "" {
+		s.locale = i18n.DefaultLocale
+	}
+
+	return s
+}
+
+// notificationData - данные для шаблонов писем
+type notificationData struct {
+	Name           string
+	Code           string
+	ExpiresAt      time.Time
+	Reward         int
+	Token          string
+	UnsubscribeURL string
+}
+
+// HandleEvent разбирает реферал из события. Из outbox данные приходят как JSON, поэтому они
+// всегда проходят через кодирование
+func (s *notificationService) HandleEvent(ctx context.Context, event *entities.Event) error {
+	if event.Type != entities.EventReferralCreated && event.Type != entities.EventReferralRewarded {
+		return nil
+	}
+
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	var referral entities.Referral
+	if err := json.Unmarshal(raw, &referral); err != nil {
+		return fmt.Errorf("decode %s event: %w", event.Type, err)
+	}
+
+	if event.Type == entities.EventReferralCreated {
+		return s.notify(ctx, event.ID, referral.ReferrerID, entities.NotificationReferralSignup, notificationData{})
+	}
+
+	var errs []error
+	if referral.ReferrerReward > 0 {
+		errs = append(errs, s.notify(ctx, event.ID, referral.ReferrerID, entities.NotificationRewardGranted,
+			notificationData{Reward: referral.ReferrerReward}))
+	}
+	if referral.RefereeReward > 0 {
+		errs = append(errs, s.notify(ctx, event.ID, referral.RefereeID, entities.NotificationRewardGranted,
+			notificationData{Reward: referral.RefereeReward}))
+	}
+	return errors.Join(errs...)
+}
+
+// SendReferralCodeExpiring отправляет напоминание один раз на каждый срок действия кода
+func (s *notificationService) SendReferralCodeExpiring(ctx context.Context, code *entities.ExpiringReferralCode) error {
+	key := "code:" + strconv.Itoa(code.CodeID) + ":" + strconv.FormatInt(code.ExpiresAt.Unix(), 10)
+	return s.notify(ctx, key, code.UserID, entities.NotificationCodeExpiring,
+		notificationData{Code: code.Code, ExpiresAt: code.ExpiresAt})
+}
+
+// notify отправляет письмо, если пользователь его не отключил. Отметка ставится до отправки,
+// а при ошибке снимается, чтобы повтор события отправил письмо еще раз
+func (s *notificationService) notify(ctx context.Context, source string, userID int, notificationType string, data notificationData) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return nil
+	}
+
+	preferences, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enabled, ok := preferences[notificationType]; ok && !enabled {
+		return nil
+	}
+
+	key := source + ":" + notificationType + ":" + strconv.Itoa(userID)
+	claimed, err := s.notificationRepo.ClaimNotification(ctx, &entities.SentNotification{
+		Key: key, UserID: userID, Type: notificationType, SentAt: time.Now(),
+	})
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := s.send(ctx, user, notificationType, data); err != nil {
+		if releaseErr := s.notificationRepo.ReleaseNotification(ctx, key); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// send собирает письмо по шаблону типа уведомления и отправляет его
+func (s *notificationService) send(ctx context.Context, user *entities.User, notificationType string, data notificationData) error {
+	data.Name = user.Name
+
+	headers := map[string]string{}
+	if s.baseURL != "" {
+		data.UnsubscribeURL = s.baseURL + "/notifications/unsubscribe?token=" + url.QueryEscape(s.unsubscribeToken(user.ID, notificationType))
+		// почтовые клиенты показывают кнопку отписки и отправляют POST без перехода по ссылке
+		headers["List-Unsubscribe"] = "<" + data.UnsubscribeURL + ">"
+		headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+
+	subject, html, err := s.templates.Render(s.userLocale(user), notificationType, data)
+	if err != nil {
+		return err
+	}
+
+	return s.sender.SendEmail(ctx, &entities.EmailMessage{To: user.Email, Subject: subject, HTML: html, Headers: headers})
+}
+
+// SendEmailVerification отправляет служебное письмо: настройки уведомлений к нему не применяются
+// и ссылки отписки в нем нет
+func (s *notificationService) SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error {
+	subject, html, err := s.templates.Render(s.userLocale(user), emailVerificationTemplate,
+		notificationData{Name: user.Name, Token: token})
+	if err != nil {
+		return err
+	}
+
+	return s.sender.SendEmail(ctx, &entities.EmailMessage{To: email, Subject: subject, HTML: html})
+}
+
+// userLocale возвращает язык писем пользователя
+func (s *notificationService) userLocale(user *entities.User) string {
+	if user.Locale != nil {
+		return *user.Locale
+	}
+	return s.locale
+}
+
+// GetPreferences дополняет сохраненные настройки включенными типами
+func (s *notificationService) GetPreferences(ctx context.Context, userID int) (entities.NotificationPreferences, error) {
+	stored, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(entities.NotificationPreferences, len(entities.NotificationTypes))
+	for _, notificationType := range entities.NotificationTypes {
+		enabled, ok := stored[notificationType]
+		preferences[notificationType] = !ok || enabled
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences проверяет типы и сохраняет настройки
+func (s *notificationService) UpdatePreferences(ctx context.Context, userID int,
+	preferences entities.NotificationPreferences) (entities.NotificationPreferences, error) {
+	for notificationType := range preferences {
+		if !slices.Contains(entities.NotificationTypes, notificationType) {
+			return nil, ErrInvalidNotificationType
+		}
+	}
+
+	if len(preferences) > 0 {
+		if err := s.notificationRepo.SetPreferences(ctx, userID, preferences); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// Unsubscribe отключает один тип уведомлений или все сразу
+func (s *notificationService) Unsubscribe(ctx context.Context, token string) error {
+	userID, notificationType, ok := s.parseUnsubscribeToken(token)
+	if !ok {
+		return ErrInvalidUnsubscribeToken
+	}
+
+	preferences := entities.NotificationPreferences{notificationType: false}
+	if notificationType == unsubscribeAll {
+		preferences = make(entities.NotificationPreferences, len(entities.NotificationTypes))
+		for _, t := range entities.NotificationTypes {
+			preferences[t] = false
+		}
+	}
+
+	err := s.notificationRepo.SetPreferences(ctx, userID, preferences)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidUnsubscribeToken
+	}
+	return err
+}
+
+// unsubscribeToken подписывает ID пользователя и тип уведомления. Токен не истекает,
+// чтобы ссылка работала и в старых письмах
+func (s *notificationService) unsubscribeToken(userID int, notificationType string) string {
+	payload := strconv.Itoa(userID) + "." + notificationType
+	return payload + "." + s.signUnsubscribe(payload)
+}
+
+// parseUnsubscribeToken проверяет подпись и разбирает токен отписки
+func (s *notificationService) parseUnsubscribeToken(token string) (int, string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signUnsubscribe(payload))) {
+		return 0, "", false
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	notificationType := parts[1]
+	if err != nil || (notificationType != unsubscribeAll && !slices.Contains(entities.NotificationTypes, notificationType)) {
+		return 0, "", false
+	}
+	return userID, notificationType, true
+}
+
+// signUnsubscribe считает HMAC токена отписки
+func (s *notificationService) signUnsubscribe(payload string) string {
+	mac := hmac.New(sha256.New, s.unsubscribeKey)
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
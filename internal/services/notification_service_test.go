@@ -0,0 +1,220 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/infrastructure/mailer"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSender запоминает отправленные письма
+type recordingSender struct {
+	messages []*entities.EmailMessage
+	err      error
+}
+
+func (s *recordingSender) SendEmail(_ context.Context, msg *entities.EmailMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func newNotificationService(t *testing.T, sender services.EmailSender) (services.NotificationService, *mocks.NotificationRepository, *mocks.UserRepository) {
+	templates, err := mailer.NewTemplates("en")
+	require.NoError(t, err)
+
+	notificationRepo := mocks.NewNotificationRepository(t)
+	userRepo := mocks.NewUserRepository(t)
+	service := services.NewNotificationService(notificationRepo, userRepo, sender, templates,
+		config.Notifications{BaseURL: "https://ref.example.com/"}, []byte("unsubscribe-secret"))
+	return service, notificationRepo, userRepo
+}
+
+func TestNotificationService_HandleEvent_ReferralCreated(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Name: "Alice", Email: "alice@mail.com"}, nil)
+	notificationRepo.On("GetPreferences", ctx, 1).Return(entities.NotificationPreferences{}, nil)
+	notificationRepo.On("ClaimNotification", ctx, mock.MatchedBy(func(n *entities.SentNotification) bool {
+		return n.Key == "evt_1:referral_signup:1" && n.UserID == 1 && n.Type == entities.NotificationReferralSignup
+	})).Return(true, nil)
+
+	event := &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated,
+		Data: &entities.Referral{ID: 7, ReferrerID: 1, RefereeID: 2}}
+	require.NoError(t, service.HandleEvent(ctx, event))
+
+	require.Len(t, sender.messages, 1)
+	msg := sender.messages[0]
+	assert.Equal(t, "alice@mail.com", msg.To)
+	assert.Equal(t, "Someone signed up with your referral code", msg.Subject)
+	assert.Contains(t, msg.HTML, "Hi Alice")
+	assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+	assert.True(t, strings.HasPrefix(msg.Headers["List-Unsubscribe"], "<https://ref.example.com/notifications/unsubscribe?token="))
+
+	// Ссылка из письма отключает уведомления этого типа
+	link, err := url.Parse(strings.Trim(msg.Headers["List-Unsubscribe"], "<>"))
+	require.NoError(t, err)
+	notificationRepo.On("SetPreferences", ctx, 1, entities.NotificationPreferences{entities.NotificationReferralSignup: false}).
+		Return(nil)
+	require.NoError(t, service.Unsubscribe(ctx, link.Query().Get("token")))
+}
+
+func TestNotificationService_HandleEvent_RewardedFromOutbox(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Name: "Alice", Email: "alice@mail.com"}, nil)
+	userRepo.On("GetUserByID", ctx, 2).Return(&entities.User{ID: 2, Name: "Bob", Email: "bob@mail.com"}, nil)
+	// Реферер отключил письма о наградах
+	notificationRepo.On("GetPreferences", ctx, 1).
+		Return(entities.NotificationPreferences{entities.NotificationRewardGranted: false}, nil)
+	notificationRepo.On("GetPreferences", ctx, 2).Return(entities.NotificationPreferences{}, nil)
+	notificationRepo.On("ClaimNotification", ctx, mock.MatchedBy(func(n *entities.SentNotification) bool {
+		return n.Key == "evt_2:reward_granted:2"
+	})).Return(true, nil)
+
+	// Из outbox данные события приходят как JSON
+	event := &entities.Event{ID: "evt_2", Type: entities.EventReferralRewarded,
+		Data: json.RawMessage(`{"id":7,"referrer_id":1,"referee_id":2,"referrer_reward":100,"referee_reward":50}`)}
+	require.NoError(t, service.HandleEvent(ctx, event))
+
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "bob@mail.com", sender.messages[0].To)
+	assert.Contains(t, sender.messages[0].HTML, "<strong>50</strong>")
+}
+
+func TestNotificationService_HandleEvent_SkipsDuplicatesAndDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	deletedAt := time.Now()
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Email: "alice@mail.com"}, nil)
+	userRepo.On("GetUserByID", ctx, 2).Return(&entities.User{ID: 2, DeletedAt: &deletedAt}, nil)
+	notificationRepo.On("GetPreferences", ctx, 1).Return(entities.NotificationPreferences{}, nil)
+	// Письмо по этому событию уже отправлено
+	notificationRepo.On("ClaimNotification", ctx, mock.Anything).Return(false, nil)
+
+	event := &entities.Event{ID: "evt_3", Type: entities.EventReferralRewarded,
+		Data: &entities.Referral{ReferrerID: 1, RefereeID: 2, ReferrerReward: 100, RefereeReward: 50}}
+	require.NoError(t, service.HandleEvent(ctx, event))
+	assert.Empty(t, sender.messages)
+}
+
+func TestNotificationService_HandleEvent_ReleasesOnSendError(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{err: errors.New("smtp unavailable")}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Email: "alice@mail.com"}, nil)
+	notificationRepo.On("GetPreferences", ctx, 1).Return(entities.NotificationPreferences{}, nil)
+	notificationRepo.On("ClaimNotification", ctx, mock.Anything).Return(true, nil)
+	// Отметка снимается, и повтор события отправит письмо
+	notificationRepo.On("ReleaseNotification", ctx, "evt_4:referral_signup:1").Return(nil)
+
+	event := &entities.Event{ID: "evt_4", Type: entities.EventReferralCreated, Data: &entities.Referral{ReferrerID: 1}}
+	assert.ErrorContains(t, service.HandleEvent(ctx, event), "smtp unavailable")
+}
+
+func TestNotificationService_SendReferralCodeExpiring(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	expiresAt := time.Date(2030, 5, 1, 12, 0, 0, 0, time.UTC)
+	userRepo.On("GetUserByID", ctx, 1).Return(&entities.User{ID: 1, Name: "Alice", Email: "alice@mail.com"}, nil)
+	notificationRepo.On("GetPreferences", ctx, 1).Return(entities.NotificationPreferences{}, nil)
+	notificationRepo.On("ClaimNotification", ctx, mock.MatchedBy(func(n *entities.SentNotification) bool {
+		return n.Key == "code:3:1903867200:code_expiring:1"
+	})).Return(true, nil)
+
+	err := service.SendReferralCodeExpiring(ctx, &entities.ExpiringReferralCode{CodeID: 3, Code: "ALICE1", ExpiresAt: expiresAt, UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "Your referral code ALICE1 expires soon", sender.messages[0].Subject)
+	assert.Contains(t, sender.messages[0].HTML, "May 1, 2030")
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	ctx := context.Background()
+	service, notificationRepo, _ := newNotificationService(t, &recordingSender{})
+
+	_, err := service.UpdatePreferences(ctx, 1, entities.NotificationPreferences{"newsletter": false})
+	assert.ErrorIs(t, err, services.ErrInvalidNotificationType)
+
+	notificationRepo.On("SetPreferences", ctx, 1, entities.NotificationPreferences{entities.NotificationCodeExpiring: false}).Return(nil)
+	notificationRepo.On("GetPreferences", ctx, 1).
+		Return(entities.NotificationPreferences{entities.NotificationCodeExpiring: false}, nil)
+
+	preferences, err := service.UpdatePreferences(ctx, 1, entities.NotificationPreferences{entities.NotificationCodeExpiring: false})
+	require.NoError(t, err)
+	// Типы без сохраненной настройки включены
+	assert.Equal(t, entities.NotificationPreferences{
+		entities.NotificationReferralSignup: true,
+		entities.NotificationRewardGranted:  true,
+		entities.NotificationCodeExpiring:   false,
+	}, preferences)
+}
+
+func TestNotificationService_Unsubscribe_InvalidToken(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newNotificationService(t, &recordingSender{})
+
+	for _, token := range []string{"", "1.all", "1.all.forged", "2.all.c2lnbmF0dXJl"} {
+		assert.ErrorIs(t, service.Unsubscribe(ctx, token), services.ErrInvalidUnsubscribeToken, token)
+	}
+}
+
+func TestNotificationService_HandleEvent_UsesUserLocale(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, notificationRepo, userRepo := newNotificationService(t, sender)
+
+	locale := "ru"
+	userRepo.On("GetUserByID", ctx, 2).Return(&entities.User{ID: 2, Name: "Bob", Email: "bob@mail.com", Locale: &locale}, nil)
+	notificationRepo.On("GetPreferences", ctx, 2).Return(entities.NotificationPreferences{}, nil)
+	notificationRepo.On("ClaimNotification", ctx, mock.Anything).Return(true, nil)
+
+	event := &entities.Event{ID: "evt_5", Type: entities.EventReferralRewarded,
+		Data: &entities.Referral{ReferrerID: 1, RefereeID: 2, RefereeReward: 50}}
+	require.NoError(t, service.HandleEvent(ctx, event))
+
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "Вам начислена реферальная награда", sender.messages[0].Subject)
+	assert.Contains(t, sender.messages[0].HTML, `<html lang="ru">`)
+}
+
+func TestNotificationService_SendEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	sender := &recordingSender{}
+	service, _, _ := newNotificationService(t, sender)
+
+	// Служебное письмо уходит без проверки настроек и на новый адрес, а язык берется из профиля
+	locale := "ru"
+	user := &entities.User{ID: 1, Name: "Alice", Email: "old@mail.com", Locale: &locale}
+	require.NoError(t, service.SendEmailVerification(ctx, user, "new@mail.com", "verification-token"))
+
+	require.Len(t, sender.messages, 1)
+	msg := sender.messages[0]
+	assert.Equal(t, "new@mail.com", msg.To)
+	assert.Equal(t, "Подтвердите новый адрес электронной почты", msg.Subject)
+	assert.Contains(t, msg.HTML, "<strong>verification-token</strong>")
+	assert.Empty(t, msg.Headers["List-Unsubscribe"])
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	// oauthStateTTL - сколько времени у пользователя есть на вход у провайдера
+	oauthStateTTL = 10 * time.Minute
+	// maxOAuthNameLength совпадает с ограничением тега username
+	maxOAuthNameLength = 100
+)
+
+// OAuthProvider - внешний провайдер входа (OIDC или OAuth2)
+type OAuthProvider interface {
+	// AuthCodeURL возвращает адрес страницы входа провайдера. В него передается
+	// только хеш PKCE verifier
+	AuthCodeURL(state, nonce, codeVerifier string) string
+	// Exchange обменивает код авторизации на данные пользователя
+	Exchange(ctx context.Context, code, nonce, codeVerifier string) (*entities.ExternalIdentity, error)
+}
+
+// OAuthService интерфейс для входа через внешних провайдеров
+type OAuthService interface {
+	// AuthorizationURL начинает вход и возвращает адрес для перенаправления пользователя.
+	// Реферальный код сохраняется до возврата с провайдера
+	AuthorizationURL(ctx context.Context, provider, referralCode string) (string, error)
+	// LinkURL начинает привязку аккаунта провайдера к уже вошедшему пользователю
+	LinkURL(ctx context.Context, provider string, userID int) (string, error)
+	// Callback завершает вход: находит, привязывает или регистрирует пользователя и выдает токен
+	Callback(ctx context.Context, provider, state, code string) (*entities.User, string, error)
+}
+
+// oauthService реализация OAuthService
+type oauthService struct {
+	providers       map[string]OAuthProvider
+	userRepo        repositories.UserRepository
+	identityRepo    repositories.UserIdentityRepository
+	stateRepo       repositories.OAuthStateRepository
+	referralService ReferralService
+	authService     AuthService
+}
+
+// NewOAuthService создает новый OAuthService
+func NewOAuthService(providers map[string]OAuthProvider,
+	userRepo repositories.UserRepository,
+	identityRepo repositories.UserIdentityRepository,
+	stateRepo repositories.OAuthStateRepository,
+	referralService ReferralService,
+	authService AuthService) OAuthService {
+	return &oauthService{
+		providers:       providers,
+		userRepo:        userRepo,
+		identityRepo:    identityRepo,
+		stateRepo:       stateRepo,
+		referralService: referralService,
+		authService:     authService,
+	}
+}
+
+// AuthorizationURL сохраняет state, nonce и PKCE verifier и возвращает адрес провайдера
+func (s *oauthService) AuthorizationURL(ctx context.Context, providerName, referralCode string) (string, error) {
+	if _, ok := s.providers[providerName]; !ok {
+		return "", ErrUnknownOAuthProvider
+	}
+
+	// Проверяем код сразу, чтобы не отправлять пользователя к провайдеру зря
+	state := &entities.OAuthState{Provider: providerName, ExpiresAt: time.Now().Add(oauthStateTTL)}
+	if referralCode != "" {
+		if err := s.referralService.ValidateReferralCode(ctx, referralCode); err != nil {
+			return "", err
+		}
+		state.ReferralCode = &referralCode
+	}
+
+	return s.startAuthorization(ctx, state)
+}
+
+// LinkURL запоминает в state пользователя, к которому будет привязан аккаунт провайдера
+func (s *oauthService) LinkURL(ctx context.Context, providerName string, userID int) (string, error) {
+	if _, ok := s.providers[providerName]; !ok {
+		return "", ErrUnknownOAuthProvider
+	}
+
+	state := &entities.OAuthState{Provider: providerName, UserID: &userID, ExpiresAt: time.Now().Add(oauthStateTTL)}
+	return s.startAuthorization(ctx, state)
+}
+
+// startAuthorization дополняет state случайными значениями, сохраняет его и возвращает адрес провайдера
+func (s *oauthService) startAuthorization(ctx context.Context, state *entities.OAuthState) (string, error) {
+	rawState, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+	if state.Nonce, err = generateToken(); err != nil {
+		return "", err
+	}
+	if state.CodeVerifier, err = generateToken(); err != nil {
+		return "", err
+	}
+	state.StateHash = hashToken(rawState)
+
+	if err := s.stateRepo.CreateState(ctx, state); err != nil {
+		return "", err
+	}
+
+	return s.providers[state.Provider].AuthCodeURL(rawState, state.Nonce, state.CodeVerifier), nil
+}
+
+// Callback проверяет state, получает данные пользователя у провайдера и начинает сессию
+func (s *oauthService) Callback(ctx context.Context, providerName, rawState, code string) (*entities.User, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, "", ErrUnknownOAuthProvider
+	}
+
+	state, err := s.stateRepo.ConsumeState(ctx, hashToken(rawState))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, "", ErrInvalidOAuthState
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if state.Provider != providerName || state.ExpiresAt.Before(time.Now()) {
+		return nil, "", ErrInvalidOAuthState
+	}
+
+	identity, err := provider.Exchange(ctx, code, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrOAuthExchangeFailed, err)
+	}
+	identity.Provider = providerName
+
+	var user *entities.User
+	if state.UserID != nil {
+		user, err = s.linkIdentity(ctx, *state.UserID, identity)
+	} else {
+		user, err = s.resolveUser(ctx, identity, state.ReferralCode)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.authService.StartSession(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+// resolveUser находит пользователя по привязанному аккаунту, привязывает аккаунт к пользователю
+// без пароля с тем же подтвержденным email или регистрирует нового пользователя
+func (s *oauthService) resolveUser(ctx context.Context, identity *entities.ExternalIdentity, referralCode *string) (*entities.User, error) {
+	linked, err := s.identityRepo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserByID(ctx, linked.UserID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return user, err
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	// Привязывать аккаунт по email можно только если провайдер подтвердил владение им
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, identity.Email)
+	isNew := errors.Is(err, repositories.ErrNotFound)
+	switch {
+	case isNew:
+		// Пароля у такого пользователя нет, войти по паролю он не сможет
+		user = &entities.User{Name: oauthUserName(identity), Email: identity.Email, Locale: requestLocale(ctx)}
+		err = s.userRepo.CreateUser(ctx, user)
+		if errors.Is(err, repositories.ErrConflict) {
+			return nil, ErrUserAlreadyExists
+		}
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case user.HashedPassword != "":
+		// Владение адресом при регистрации по паролю не проверялось: аккаунт мог заранее создать
+		// кто угодно. Такой пользователь привязывает провайдера сам после входа по паролю
+		return nil, ErrOAuthAccountExists
+	}
+
+	err = s.identityRepo.CreateIdentity(ctx, &entities.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Код проверялся перед переходом к провайдеру. Если он успел истечь или исчерпать лимит,
+	// регистрация все равно завершается, но без начисления рефереру
+	if isNew && referralCode != nil {
+		err := s.referralService.CreditReferral(ctx, *referralCode, user.ID)
+		if err != nil && !errors.Is(err, ErrInvalidReferralCode) && !errors.Is(err, ErrReferralCodeExpired) &&
+			!errors.Is(err, ErrReferralLimitReached) {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// linkIdentity привязывает аккаунт провайдера к пользователю, начавшему привязку из профиля.
+// Email провайдера при этом не важен: пользователь подтвердил владение обоими аккаунтами
+func (s *oauthService) linkIdentity(ctx context.Context, userID int, identity *entities.ExternalIdentity) (*entities.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.identityRepo.GetIdentity(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil && linked.UserID == user.ID:
+		return user, nil
+	case err == nil:
+		return nil, ErrOAuthIdentityLinked
+	case !errors.Is(err, repositories.ErrNotFound):
+		return nil, err
+	}
+
+	err = s.identityRepo.CreateIdentity(ctx, &entities.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, ErrOAuthIdentityLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// oauthUserName выбирает имя нового пользователя: имя из профиля провайдера или часть email до @
+func oauthUserName(identity *entities.ExternalIdentity) string {
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	return truncate(name, maxOAuthNameLength)
+}
@@ -0,0 +1,233 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider возвращает заданного пользователя, если в Exchange пришли те же nonce и verifier,
+// что и в AuthCodeURL
+type fakeProvider struct {
+	identity *entities.ExternalIdentity
+	nonce    string
+	verifier string
+}
+
+func (p *fakeProvider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	p.nonce, p.verifier = nonce, codeVerifier
+	return "https://provider.test/authorize?state=" + url.QueryEscape(state)
+}
+
+func (p *fakeProvider) Exchange(_ context.Context, _, nonce, codeVerifier string) (*entities.ExternalIdentity, error) {
+	if nonce != p.nonce || codeVerifier != p.verifier {
+		return nil, errors.New("nonce or verifier mismatch")
+	}
+	identity := *p.identity
+	return &identity, nil
+}
+
+type oauthFixture struct {
+	userRepo         *mocks.UserRepository
+	identityRepo     *mocks.UserIdentityRepository
+	stateRepo        *mocks.OAuthStateRepository
+	referralCodeRepo *mocks.ReferralCodeRepository
+	referralRepo     *mocks.ReferralRepository
+	campaignRepo     *mocks.CampaignRepository
+	provider         *fakeProvider
+	service          services.OAuthService
+}
+
+func newOAuthFixture(t *testing.T, identity *entities.ExternalIdentity) *oauthFixture {
+	f := &oauthFixture{
+		userRepo:         mocks.NewUserRepository(t),
+		identityRepo:     mocks.NewUserIdentityRepository(t),
+		stateRepo:        mocks.NewOAuthStateRepository(t),
+		referralCodeRepo: mocks.NewReferralCodeRepository(t),
+		referralRepo:     mocks.NewReferralRepository(t),
+		campaignRepo:     mocks.NewCampaignRepository(t),
+		provider:         &fakeProvider{identity: identity},
+	}
+
+	authService := services.NewAuthService(f.userRepo, mocks.NewLoginAttemptRepository(t), newTokens(t), allowAll{}, loginProtection, nil, nil)
+	referralService := services.NewReferralService(f.referralCodeRepo, f.userRepo, f.referralRepo, f.campaignRepo, authService, nil, inlineTx{}, &recordingPublisher{})
+	f.service = services.NewOAuthService(map[string]services.OAuthProvider{"google": f.provider},
+		f.userRepo, f.identityRepo, f.stateRepo, referralService, authService)
+
+	return f
+}
+
+// start начинает вход и возвращает state из адреса перенаправления. Сохраненное состояние
+// будет возвращено из ConsumeState
+func (f *oauthFixture) start(t *testing.T, ctx context.Context, referralCode string) string {
+	return f.capture(t, ctx, func() (string, error) {
+		return f.service.AuthorizationURL(ctx, "google", referralCode)
+	})
+}
+
+// startLink начинает привязку аккаунта провайдера к пользователю и возвращает state
+func (f *oauthFixture) startLink(t *testing.T, ctx context.Context, userID int) string {
+	return f.capture(t, ctx, func() (string, error) {
+		return f.service.LinkURL(ctx, "google", userID)
+	})
+}
+
+func (f *oauthFixture) capture(t *testing.T, ctx context.Context, begin func() (string, error)) string {
+	var saved *entities.OAuthState
+	f.stateRepo.On("CreateState", ctx, mock.AnythingOfType("*entities.OAuthState")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*entities.OAuthState) }).
+		Return(nil)
+
+	redirect, err := begin()
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(redirect)
+	require.NoError(t, err)
+	state := parsed.Query().Get("state")
+
+	require.NotNil(t, saved)
+	assert.NotEqual(t, state, saved.StateHash, "state must be stored hashed")
+	f.stateRepo.On("ConsumeState", ctx, saved.StateHash).Return(saved, nil).Once()
+
+	return state
+}
+
+func TestOAuthService_Callback_RegistersUserAndCreditsReferrer(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "new@mail.com", EmailVerified: true, Name: "John"})
+
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "REFCODE", ExpiresAt: time.Now().Add(time.Hour)}
+	f.referralCodeRepo.On("GetReferralByReferralCode", ctx, "REFCODE").Return(code, nil)
+	f.referralCodeRepo.On("LockReferralCode", ctx, code.ID).Return(code, nil)
+
+	state := f.start(t, ctx, "REFCODE")
+
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(nil, repositories.ErrNotFound)
+	f.userRepo.On("GetUserByEmail", ctx, "new@mail.com").Return(nil, repositories.ErrNotFound)
+	f.userRepo.On("CreateUser", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.Name == "John" && u.Email == "new@mail.com" && u.HashedPassword == ""
+	})).Run(func(args mock.Arguments) { args.Get(1).(*entities.User).ID = 10 }).Return(nil)
+	f.identityRepo.On("CreateIdentity", ctx, mock.MatchedBy(func(i *entities.UserIdentity) bool {
+		return i.UserID == 10 && i.Provider == "google" && i.Subject == "42"
+	})).Return(nil)
+	f.referralRepo.On("CreateReferralLink", ctx, mock.MatchedBy(func(r *entities.Referral) bool {
+		return r.ReferrerID == 3 && r.RefereeID == 10
+	})).Return(nil)
+
+	user, token, err := f.service.Callback(ctx, "google", state, "code")
+	require.NoError(t, err)
+	assert.Equal(t, 10, user.ID)
+	assert.NotEmpty(t, token)
+}
+
+func TestOAuthService_Callback_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "user@mail.com", EmailVerified: true})
+
+	state := f.start(t, ctx, "")
+
+	existing := &entities.User{ID: 5, Email: "user@mail.com"}
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(nil, repositories.ErrNotFound)
+	f.userRepo.On("GetUserByEmail", ctx, "user@mail.com").Return(existing, nil)
+	f.identityRepo.On("CreateIdentity", ctx, mock.MatchedBy(func(i *entities.UserIdentity) bool {
+		return i.UserID == 5
+	})).Return(nil)
+
+	user, token, err := f.service.Callback(ctx, "google", state, "code")
+	require.NoError(t, err)
+	assert.Equal(t, 5, user.ID)
+	assert.NotEmpty(t, token)
+}
+
+func TestOAuthService_Callback_RejectsPasswordAccountWithSameEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "user@mail.com", EmailVerified: true})
+
+	state := f.start(t, ctx, "")
+
+	// Аккаунт с паролем мог зарегистрировать кто угодно, не владея адресом
+	existing := &entities.User{ID: 5, Email: "user@mail.com", HashedPassword: "hash"}
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(nil, repositories.ErrNotFound)
+	f.userRepo.On("GetUserByEmail", ctx, "user@mail.com").Return(existing, nil)
+
+	_, _, err := f.service.Callback(ctx, "google", state, "code")
+	assert.ErrorIs(t, err, services.ErrOAuthAccountExists)
+	f.identityRepo.AssertNotCalled(t, "CreateIdentity", mock.Anything, mock.Anything)
+}
+
+func TestOAuthService_Callback_LinksIdentityToSessionUser(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "other@mail.com"})
+
+	state := f.startLink(t, ctx, 5)
+
+	// Email провайдера не обязан совпадать и быть подтвержденным: пользователь вошел в оба аккаунта
+	existing := &entities.User{ID: 5, Email: "user@mail.com", HashedPassword: "hash"}
+	f.userRepo.On("GetUserByID", ctx, 5).Return(existing, nil)
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(nil, repositories.ErrNotFound)
+	f.identityRepo.On("CreateIdentity", ctx, mock.MatchedBy(func(i *entities.UserIdentity) bool {
+		return i.UserID == 5 && i.Provider == "google" && i.Subject == "42"
+	})).Return(nil)
+
+	user, token, err := f.service.Callback(ctx, "google", state, "code")
+	require.NoError(t, err)
+	assert.Equal(t, 5, user.ID)
+	assert.NotEmpty(t, token)
+}
+
+func TestOAuthService_Callback_RejectsIdentityLinkedToAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "other@mail.com"})
+
+	state := f.startLink(t, ctx, 5)
+
+	f.userRepo.On("GetUserByID", ctx, 5).Return(&entities.User{ID: 5}, nil)
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(&entities.UserIdentity{UserID: 7}, nil)
+
+	_, _, err := f.service.Callback(ctx, "google", state, "code")
+	assert.ErrorIs(t, err, services.ErrOAuthIdentityLinked)
+}
+
+func TestOAuthService_Callback_RejectsUnverifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42", Email: "user@mail.com", EmailVerified: false})
+
+	state := f.start(t, ctx, "")
+	f.identityRepo.On("GetIdentity", ctx, "google", "42").Return(nil, repositories.ErrNotFound)
+
+	_, _, err := f.service.Callback(ctx, "google", state, "code")
+	assert.ErrorIs(t, err, services.ErrOAuthEmailNotVerified)
+}
+
+func TestOAuthService_Callback_RejectsUnknownState(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42"})
+
+	f.stateRepo.On("ConsumeState", ctx, mock.Anything).Return(nil, repositories.ErrNotFound)
+
+	_, _, err := f.service.Callback(ctx, "google", "forged", "code")
+	assert.ErrorIs(t, err, services.ErrInvalidOAuthState)
+
+	_, _, err = f.service.Callback(ctx, "github", "forged", "code")
+	assert.ErrorIs(t, err, services.ErrUnknownOAuthProvider)
+}
+
+func TestOAuthService_AuthorizationURL_RejectsInvalidReferralCode(t *testing.T) {
+	ctx := context.Background()
+	f := newOAuthFixture(t, &entities.ExternalIdentity{Subject: "42"})
+
+	f.referralCodeRepo.On("GetReferralByReferralCode", ctx, "MISSING").Return(nil, repositories.ErrNotFound)
+
+	_, err := f.service.AuthorizationURL(ctx, "google", "MISSING")
+	assert.ErrorIs(t, err, services.ErrInvalidReferralCode)
+}
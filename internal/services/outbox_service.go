@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"slices"
+	"time"
+)
+
+const (
+	defaultOutboxBaseBackoff = 5 * time.Second
+	defaultOutboxMaxBackoff  = 10 * time.Minute
+	defaultOutboxMaxAttempts = 50
+
+	// outboxBatchSize - сколько событий забирается из outbox за раз
+	outboxBatchSize = 100
+	// outboxLease - на сколько забранные события скрываются от других экземпляров
+	outboxLease = time.Minute
+	// maxOutboxError - сколько символов ошибки публикации хранится в outbox
+	maxOutboxError = 500
+)
+
+// outboxPublisher реализация EventPublisher, которая сохраняет события в outbox
+type outboxPublisher struct {
+	outboxRepo repositories.OutboxRepository
+}
+
+// NewOutboxPublisher создает EventPublisher, который сохраняет события в outbox.
+// Вызванный внутри Transactor.WithinTransaction, он сохраняет событие в той же транзакции,
+// что и изменение, которое его породило. Опубликует событие OutboxRelay
+func NewOutboxPublisher(outboxRepo repositories.OutboxRepository) EventPublisher {
+	return &outboxPublisher{outboxRepo: outboxRepo}
+}
+
+// Publish сохраняет событие. Событие с уже сохраненным ID не сохраняется повторно
+func (p *outboxPublisher) Publish(ctx context.Context, event *entities.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = p.outboxRepo.AddEvent(ctx, &entities.OutboxEvent{EventID: event.ID, EventType: event.Type, Payload: payload})
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil
+	}
+	return err
+}
+
+// OutboxRelay интерфейс для публикации событий из outbox
+type OutboxRelay interface {
+	// RelayPending публикует события, чье время наступило, и возвращает их число
+	RelayPending(ctx context.Context) (int, error)
+}
+
+// outboxRelay реализация OutboxRelay
+type outboxRelay struct {
+	outboxRepo  repositories.OutboxRepository
+	publishers  map[string]EventPublisher
+	names       []string // имена издателей в постоянном порядке
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+// NewOutboxRelay создает новый OutboxRelay, который публикует события в каждый из publishers.
+// Ключ - имя издателя, по нему запоминается, кто из издателей уже принял событие
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, publishers map[string]EventPublisher, cfg config.Outbox) OutboxRelay {
+	r := &outboxRelay{
+		outboxRepo:  outboxRepo,
+		publishers:  publishers,
+		names:       slices.Sorted(maps.Keys(publishers)),
+		maxAttempts: cfg.MaxAttempts,
+		baseBackoff: time.Duration(cfg.BaseBackoff) * time.Second,
+		maxBackoff:  time.Duration(cfg.MaxBackoff) * time.Second,
+	}
+
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultOutboxMaxAttempts
+	}
+	if r.baseBackoff <= 0 {
+		r.baseBackoff = defaultOutboxBaseBackoff
+	}
+	if r.maxBackoff < r.baseBackoff {
+		r.maxBackoff = max(defaultOutboxMaxBackoff, r.baseBackoff)
+	}
+
+	return r
+}
+
+// RelayPending публикует события в порядке создания. Событие отмечается опубликованным только после
+// того, как его приняли все издатели, поэтому сбой между ними приводит к повторной публикации, но не к потере.
+// Неудачная попытка повторяется с удваивающейся паузой только для не принявших событие издателей, а после
+// maxAttempts попыток событие отмечается failed_at и больше не публикуется
+func (r *outboxRelay) RelayPending(ctx context.Context) (int, error) {
+	events, err := r.outboxRepo.ClaimPendingEvents(ctx, time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, outboxEvent := range events {
+		err := r.publish(ctx, outboxEvent)
+
+		now := time.Now()
+		outboxEvent.Attempts++
+		switch {
+		case err == nil:
+			outboxEvent.LastError = ""
+			outboxEvent.PublishedAt = &now
+		case outboxEvent.Attempts >= r.maxAttempts:
+			outboxEvent.LastError = truncate(err.Error(), maxOutboxError)
+			outboxEvent.FailedAt = &now
+		default:
+			outboxEvent.LastError = truncate(err.Error(), maxOutboxError)
+			outboxEvent.NextAttemptAt = now.Add(backoff(r.baseBackoff, r.maxBackoff, outboxEvent.Attempts))
+		}
+
+		if err := r.outboxRepo.UpdateEvent(context.WithoutCancel(ctx), outboxEvent); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+// publish восстанавливает событие из outbox и публикует его в издатели, которые его еще не приняли.
+// Данные передаются издателям как есть, без повторной сериализации
+func (r *outboxRelay) publish(ctx context.Context, outboxEvent *entities.OutboxEvent) error {
+	var stored struct {
+		entities.Event
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(outboxEvent.Payload, &stored); err != nil {
+		return err
+	}
+
+	event := stored.Event
+	event.Data = stored.Data
+
+	var errs []error
+	for _, name := range r.names {
+		if slices.Contains(outboxEvent.PublishedTo, name) {
+			continue
+		}
+		if err := r.publishers[name].Publish(ctx, &event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		outboxEvent.PublishedTo = append(outboxEvent.PublishedTo, name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// inlineTx выполняет функцию без транзакции
+type inlineTx struct{}
+
+func (inlineTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// failingPublisher возвращает ошибку на каждую публикацию
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, *entities.Event) error {
+	return errors.New("broker unavailable")
+}
+
+// flakyPublisher возвращает ошибку на первые failures публикаций
+type flakyPublisher struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyPublisher) Publish(context.Context, *entities.Event) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("broker unavailable")
+	}
+	return nil
+}
+
+func TestOutboxPublisher_Publish(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mocks.NewOutboxRepository(t)
+	publisher := services.NewOutboxPublisher(outboxRepo)
+
+	outboxRepo.On("AddEvent", ctx, mock.MatchedBy(func(e *entities.OutboxEvent) bool {
+		return e.EventID == "evt_1" && e.EventType == entities.EventReferralCreated &&
+			string(e.Payload) == `{"id":"evt_1","type":"referral.created","occurred_at":"0001-01-01T00:00:00Z","data":{"id":5}}`
+	})).Return(nil).Once()
+	outboxRepo.On("AddEvent", ctx, mock.Anything).Return(repositories.ErrConflict).Once()
+
+	event := &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated, Data: map[string]int{"id": 5}}
+	require.NoError(t, publisher.Publish(ctx, event))
+	// Событие уже в outbox
+	require.NoError(t, publisher.Publish(ctx, event))
+}
+
+func TestOutboxRelay_RelayPending(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mocks.NewOutboxRepository(t)
+	publisher := &recordingPublisher{}
+	relay := services.NewOutboxRelay(outboxRepo, map[string]services.EventPublisher{"webhook": publisher}, config.Outbox{})
+
+	outboxEvent := &entities.OutboxEvent{ID: 3, EventID: "evt_1", EventType: entities.EventReferralCreated,
+		Payload: []byte(`{"id":"evt_1","type":"referral.created","occurred_at":"2026-03-01T10:00:00Z","data":{"referee_id":10,"id":5}}`)}
+	outboxRepo.On("ClaimPendingEvents", ctx, mock.AnythingOfType("time.Time"), time.Minute, 100).
+		Return([]*entities.OutboxEvent{outboxEvent}, nil)
+	outboxRepo.On("UpdateEvent", mock.Anything, outboxEvent).Return(nil)
+
+	relayed, err := relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.NotNil(t, outboxEvent.PublishedAt)
+	assert.Equal(t, 1, outboxEvent.Attempts)
+	assert.Equal(t, []string{"webhook"}, outboxEvent.PublishedTo)
+
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "evt_1", publisher.events[0].ID)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), publisher.events[0].OccurredAt)
+	// Данные передаются без повторной сериализации
+	data, err := json.Marshal(publisher.events[0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, `{"referee_id":10,"id":5}`, string(data))
+}
+
+func TestOutboxRelay_RelayPending_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mocks.NewOutboxRepository(t)
+	relay := services.NewOutboxRelay(outboxRepo, map[string]services.EventPublisher{"nats": failingPublisher{}}, config.Outbox{BaseBackoff: 10})
+
+	outboxEvent := &entities.OutboxEvent{ID: 3, EventID: "evt_1", EventType: entities.EventReferralCreated,
+		Payload: []byte(`{"id":"evt_1","type":"referral.created"}`), Attempts: 2}
+	outboxRepo.On("ClaimPendingEvents", ctx, mock.AnythingOfType("time.Time"), time.Minute, 100).
+		Return([]*entities.OutboxEvent{outboxEvent}, nil)
+	outboxRepo.On("UpdateEvent", mock.Anything, outboxEvent).Return(nil)
+
+	start := time.Now()
+	_, err := relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, outboxEvent.PublishedAt)
+	assert.Equal(t, 3, outboxEvent.Attempts)
+	assert.Equal(t, "nats: broker unavailable", outboxEvent.LastError)
+	assert.WithinDuration(t, start.Add(40*time.Second), outboxEvent.NextAttemptAt, 5*time.Second)
+}
+
+func TestOutboxRelay_RelayPending_RetriesOnlyFailedPublishers(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mocks.NewOutboxRepository(t)
+	webhook, nats := &recordingPublisher{}, &flakyPublisher{failures: 1}
+	relay := services.NewOutboxRelay(outboxRepo, map[string]services.EventPublisher{"webhook": webhook, "nats": nats}, config.Outbox{})
+
+	outboxEvent := &entities.OutboxEvent{ID: 3, EventID: "evt_1", EventType: entities.EventReferralCreated,
+		Payload: []byte(`{"id":"evt_1","type":"referral.created"}`)}
+	outboxRepo.On("ClaimPendingEvents", ctx, mock.AnythingOfType("time.Time"), time.Minute, 100).
+		Return([]*entities.OutboxEvent{outboxEvent}, nil)
+	outboxRepo.On("UpdateEvent", mock.Anything, outboxEvent).Return(nil)
+
+	_, err := relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, outboxEvent.PublishedAt)
+	assert.Equal(t, []string{"webhook"}, outboxEvent.PublishedTo)
+
+	// Повтор отправляет событие только издателю, который его не принял
+	_, err = relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, outboxEvent.PublishedAt)
+	assert.ElementsMatch(t, []string{"webhook", "nats"}, outboxEvent.PublishedTo)
+	assert.Len(t, webhook.events, 1)
+	assert.Equal(t, 2, nats.calls)
+}
+
+func TestOutboxRelay_RelayPending_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mocks.NewOutboxRepository(t)
+	relay := services.NewOutboxRelay(outboxRepo, map[string]services.EventPublisher{"nats": failingPublisher{}}, config.Outbox{MaxAttempts: 3})
+
+	outboxEvent := &entities.OutboxEvent{ID: 3, EventID: "evt_1", EventType: entities.EventReferralCreated,
+		Payload: []byte(`{"id":"evt_1","type":"referral.created"}`), Attempts: 2}
+	outboxRepo.On("ClaimPendingEvents", ctx, mock.AnythingOfType("time.Time"), time.Minute, 100).
+		Return([]*entities.OutboxEvent{outboxEvent}, nil)
+	outboxRepo.On("UpdateEvent", mock.Anything, outboxEvent).Return(nil)
+
+	_, err := relay.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, outboxEvent.PublishedAt)
+	assert.NotNil(t, outboxEvent.FailedAt)
+	assert.Equal(t, 3, outboxEvent.Attempts)
+}
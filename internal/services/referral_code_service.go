@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"math"
 	"referral-system/internal/entities"
 	"referral-system/internal/repositories"
 	"time"
@@ -14,25 +16,50 @@ type referralService struct {
 	referralCodeRepo repositories.ReferralCodeRepository
 	userRepo         repositories.UserRepository
 	referralRepo     repositories.ReferralRepository
+	campaignRepo     repositories.CampaignRepository
+	authService      AuthService
+	tierService      TierService
+	tx               repositories.Transactor
+	events           EventPublisher
 }
 
 // ReferralService интерфейс для управления реферальными кодами
 type ReferralService interface {
-	CreateReferralCode(userID int, expiresIn time.Duration) (*entities.ReferralCode, error)
-	DeleteReferralCode(userID int) error
-	GetReferralCodeByUserID(userID int) (*entities.ReferralCode, error)
-	RegisterWithReferralCode(referralCode string, name, email, password string) (*entities.User, error)
-	GetReferralsByReferrerID(referrerID int) ([]*entities.Referral, error)
+	// CreateReferralCode создает код пользователя. Если указана кампания, код наследует ее правила,
+	// а нулевой expiresIn означает время жизни по умолчанию для кампании
+	CreateReferralCode(ctx context.Context, userID int, expiresIn time.Duration, campaignID *int) (*entities.ReferralCode, error)
+	DeleteReferralCode(ctx context.Context, userID int) error
+	GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error)
+	// GetUserReferralCode возвращает код по значению, если он принадлежит пользователю
+	GetUserReferralCode(ctx context.Context, userID int, referralCode string) (*entities.ReferralCode, error)
+	RegisterWithReferralCode(ctx context.Context, referralCode string, name, email, password string) (*entities.User, error)
+	// ValidateReferralCode проверяет, что код существует, не истек и не исчерпал лимиты кампании
+	ValidateReferralCode(ctx context.Context, referralCode string) error
+	// CreditReferral привязывает уже зарегистрированного пользователя к владельцу кода
+	CreditReferral(ctx context.Context, referralCode string, refereeID int) error
+	GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error)
+	// QualifyReferral отмечает, что реферал выполнил целевое действие. Повторная отметка ничего не меняет
+	QualifyReferral(ctx context.Context, referralID int) (*entities.Referral, error)
 }
 
 // NewReferralService создает новый ReferralService
 func NewReferralService(referralCodeRepo repositories.ReferralCodeRepository,
 	userRepo repositories.UserRepository,
-	referralRepo repositories.ReferralRepository) ReferralService {
+	referralRepo repositories.ReferralRepository,
+	campaignRepo repositories.CampaignRepository,
+	authService AuthService,
+	tierService TierService,
+	tx repositories.Transactor,
+	events EventPublisher) ReferralService {
 	return &referralService{
 		referralRepo:     referralRepo,
+		campaignRepo:     campaignRepo,
 		userRepo:         userRepo,
 		referralCodeRepo: referralCodeRepo,
+		authService:      authService,
+		tierService:      tierService,
+		tx:               tx,
+		events:           events,
 	}
 }
 
@@ -49,27 +76,56 @@ func GenerateReferralCode(length int) string {
 }
 
 // CreateReferralCode создает реферальный код для пользователя
-func (s *referralService) CreateReferralCode(userID int, expiresIn time.Duration) (*entities.ReferralCode, error) {
+func (s *referralService) CreateReferralCode(ctx context.Context, userID int, expiresIn time.Duration, campaignID *int) (*entities.ReferralCode, error) {
+	now := time.Now()
+
+	// Истекший код не мешает выпустить новый: он уходит в архив вместе с историей
+	if err := s.referralCodeRepo.ArchivePersonalCodeExpiredBefore(ctx, userID, now); err != nil {
+		return nil, err
+	}
+
 	// Проверим, есть ли уже активный код
-	existingCode, err := s.referralCodeRepo.GetReferralCodeByUserID(userID)
-	if err != nil {
+	_, err := s.referralCodeRepo.GetReferralCodeByUserID(ctx, userID)
+	if err == nil {
+		return nil, ErrReferralCodeExists
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
 		return nil, err
 	}
 
-	if existingCode != nil {
-		return nil, errors.New("referral code already exists for user")
+	referral := &entities.ReferralCode{
+		UserID:     userID,
+		Code:       GenerateReferralCode(10),
+		CampaignID: campaignID,
 	}
 
-	code := GenerateReferralCode(10)
+	if campaignID != nil {
+		campaign, err := s.campaign(ctx, *campaignID)
+		if err != nil {
+			return nil, err
+		}
+		if !campaign.ActiveAt(now) {
+			return nil, ErrCampaignInactive
+		}
+
+		if expiresIn <= 0 {
+			expiresIn = time.Duration(campaign.CodeTTL) * time.Second
+		}
+		// Код кампании не переживает саму кампанию
+		referral.ExpiresAt = now.Add(expiresIn)
+		if referral.ExpiresAt.After(campaign.EndsAt) {
+			referral.ExpiresAt = campaign.EndsAt
+		}
+		referral.MaxUses = campaign.MaxUsesPerCode
+	} else {
+		referral.ExpiresAt = now.Add(expiresIn)
+	}
 
 	// Создаем новый реферальный код
-	expiresAt := time.Now().Add(expiresIn)
-	referral := &entities.ReferralCode{
-		UserID:    userID,
-		Code:      code,
-		ExpiresAt: expiresAt,
+	err = s.referralCodeRepo.CreateReferralCode(ctx, referral)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, ErrReferralCodeExists
 	}
-	err = s.referralCodeRepo.CreateReferralCode(referral)
 	if err != nil {
 		return nil, err
 	}
@@ -78,46 +134,204 @@ func (s *referralService) CreateReferralCode(userID int, expiresIn time.Duration
 }
 
 // DeleteReferralCode удаляет реферальный код пользователя
-func (s *referralService) DeleteReferralCode(userID int) error {
-	return s.referralCodeRepo.DeleteReferralCodeByUserID(userID)
+func (s *referralService) DeleteReferralCode(ctx context.Context, userID int) error {
+	return s.referralCodeRepo.DeleteReferralCodeByUserID(ctx, userID)
 }
 
-// GetReferralCodeByUserID возвращает реферальный код по ID пользователя
-func (s *referralService) GetReferralCodeByUserID(userID int) (*entities.ReferralCode, error) {
-	referral, err := s.referralCodeRepo.GetReferralCodeByUserID(userID)
+// GetReferralCodeByUserID возвращает действующий реферальный код по ID пользователя. Срок проверяет запрос
+func (s *referralService) GetReferralCodeByUserID(ctx context.Context, userID int) (*entities.ReferralCode, error) {
+	return s.referralCodeRepo.GetReferralCodeByUserID(ctx, userID)
+}
+
+// GetUserReferralCode возвращает любой код пользователя, включая коды из партнерских пакетов.
+// Чужой код не отличается от несуществующего
+func (s *referralService) GetUserReferralCode(ctx context.Context, userID int, referralCode string) (*entities.ReferralCode, error) {
+	code, err := s.referralCodeRepo.GetReferralByReferralCode(ctx, referralCode)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && code.UserID != userID) {
+		return nil, ErrReferralCodeNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	return code, nil
+}
+
+// campaign находит кампанию по ID
+func (s *referralService) campaign(ctx context.Context, id int) (*entities.Campaign, error) {
+	campaign, err := s.campaignRepo.GetCampaignByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrCampaignNotFound
+	}
+	return campaign, err
+}
 
-	// Проверим, не истек ли срок действия кода
-	if referral.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("referral code has expired")
+// activeReferralCode находит действующий реферальный код и готовит связь с владельцем кода
+// по правилам его кампании. Лимиты здесь проверяются только для раннего отказа, окончательно
+// они проверяются в createReferral
+func (s *referralService) activeReferralCode(ctx context.Context, referralCode string) (*entities.Referral, error) {
+	code, err := s.referralCodeRepo.GetReferralByReferralCode(ctx, referralCode)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidReferralCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if code.ExpiresAt.Before(now) {
+		return nil, ErrReferralCodeExpired
+	}
+	if err := s.checkCodeLimit(ctx, code); err != nil {
+		return nil, err
+	}
+
+	referral := &entities.Referral{ReferrerID: code.UserID, ReferralCodeID: &code.ID}
+	if code.CampaignID == nil {
+		return referral, nil
+	}
+
+	campaign, err := s.campaign(ctx, *code.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if !campaign.ActiveAt(now) {
+		return nil, ErrCampaignInactive
+	}
+	if err := s.checkCampaignLimit(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	referral.CampaignID = &campaign.ID
+	referral.ReferrerReward = campaign.ReferrerReward
+	referral.RefereeReward = campaign.RefereeReward
+
+	// Награда реферера растет вместе с его уровнем
+	if referral.ReferrerReward > 0 {
+		multiplier, err := s.tierService.RewardMultiplier(ctx, code.UserID)
+		if err != nil {
+			return nil, err
+		}
+		referral.ReferrerReward = int(math.Round(float64(referral.ReferrerReward) * multiplier))
 	}
 
 	return referral, nil
 }
 
-// RegisterWithReferralCode регистрирует нового пользователя по реферальному коду
-func (s *referralService) RegisterWithReferralCode(referralCode string, name, email, password string) (*entities.User, error) {
-	// Найдем реферальный код
-	referral, err := s.referralCodeRepo.GetReferralByReferralCode(referralCode)
+// checkCodeLimit проверяет, что по коду засчитано меньше регистраций, чем разрешено
+func (s *referralService) checkCodeLimit(ctx context.Context, code *entities.ReferralCode) error {
+	if code.MaxUses == nil {
+		return nil
+	}
+
+	used, err := s.referralRepo.CountReferralsByCode(ctx, code.ID)
 	if err != nil {
-		return nil, errors.New("invalid referral code")
+		return err
 	}
+	if used >= *code.MaxUses {
+		return ErrReferralLimitReached
+	}
+	return nil
+}
 
-	if referral.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("referral code has expired")
+// checkCampaignLimit проверяет, что в кампании засчитано меньше регистраций, чем разрешено
+func (s *referralService) checkCampaignLimit(ctx context.Context, campaign *entities.Campaign) error {
+	if campaign.MaxReferrals == nil {
+		return nil
 	}
 
-	// Создаем нового пользователя
-	authSvc := NewAuthService(s.userRepo, "")
-	user, err := authSvc.RegisterUser(name, email, password)
+	used, err := s.referralRepo.CountReferralsByCampaign(ctx, campaign.ID)
+	if err != nil {
+		return err
+	}
+	if used >= *campaign.MaxReferrals {
+		return ErrReferralLimitReached
+	}
+	return nil
+}
+
+// ValidateReferralCode проверяет реферальный код до начала регистрации
+func (s *referralService) ValidateReferralCode(ctx context.Context, referralCode string) error {
+	_, err := s.activeReferralCode(ctx, referralCode)
+	return err
+}
+
+// CreditReferral засчитывает регистрацию пользователя владельцу реферального кода
+func (s *referralService) CreditReferral(ctx context.Context, referralCode string, refereeID int) error {
+	referral, err := s.activeReferralCode(ctx, referralCode)
+	if err != nil {
+		return err
+	}
+
+	referral.RefereeID = refereeID
+	return s.createReferral(ctx, referral)
+}
+
+// createReferral сохраняет связь реферера и реферала и событие о ней в одной транзакции
+func (s *referralService) createReferral(ctx context.Context, referral *entities.Referral) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.lockReferralLimits(ctx, referral); err != nil {
+			return err
+		}
+		if err := s.referralRepo.CreateReferralLink(ctx, referral); err != nil {
+			return err
+		}
+		return publish(ctx, s.events, entities.EventReferralCreated, referral)
+	})
+}
+
+// lockReferralLimits блокирует код и кампанию до конца транзакции и повторно проверяет их лимиты.
+// Одновременные регистрации по одному коду или кампании ждут друг друга и видят уже засчитанные
+func (s *referralService) lockReferralLimits(ctx context.Context, referral *entities.Referral) error {
+	if referral.ReferralCodeID == nil {
+		return nil
+	}
+
+	code, err := s.referralCodeRepo.LockReferralCode(ctx, *referral.ReferralCodeID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidReferralCode
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.checkCodeLimit(ctx, code); err != nil {
+		return err
+	}
+
+	if referral.CampaignID == nil {
+		return nil
+	}
+
+	campaign, err := s.campaignRepo.LockCampaign(ctx, *referral.CampaignID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrCampaignNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.checkCampaignLimit(ctx, campaign)
+}
+
+// RegisterWithReferralCode регистрирует нового пользователя по реферальному коду. Пользователь, связь с
+// реферером и событие о ней сохраняются в одной транзакции: если лимит кода исчерпали одновременные
+// регистрации, пользователь не создается и клиент получает ErrReferralLimitReached
+func (s *referralService) RegisterWithReferralCode(ctx context.Context, referralCode string, name, email, password string) (*entities.User, error) {
+	// Найдем реферальный код
+	referral, err := s.activeReferralCode(ctx, referralCode)
 	if err != nil {
 		return nil, err
 	}
 
-	// Привязываем реферала к рефереру
-	err = s.referralRepo.CreateReferralLink(referral.UserID, user.ID)
+	var user *entities.User
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.authService.RegisterUser(ctx, name, email, password)
+		if err != nil {
+			return err
+		}
+
+		referral.RefereeID = user.ID
+		return s.createReferral(ctx, referral)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -126,6 +340,47 @@ func (s *referralService) RegisterWithReferralCode(referralCode string, name, em
 }
 
 // GetReferralsByReferrerID возвращает список рефералов по ID реферера
-func (s *referralService) GetReferralsByReferrerID(referrerID int) ([]*entities.Referral, error) {
-	return s.referralRepo.GetReferralsByReferrerID(referrerID)
+func (s *referralService) GetReferralsByReferrerID(ctx context.Context, referrerID int) ([]*entities.Referral, error) {
+	return s.referralRepo.GetReferralsByReferrerID(ctx, referrerID)
+}
+
+// QualifyReferral отмечает реферала квалифицированным и пересчитывает уровень реферера в той же транзакции.
+// События квалификации сохраняются только при первой отметке, а пересчет уровня повторяется при каждом вызове
+// и публикует tier.changed, если уровень сменился
+func (s *referralService) QualifyReferral(ctx context.Context, referralID int) (*entities.Referral, error) {
+	var referral *entities.Referral
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var qualified bool
+		var err error
+		referral, qualified, err = s.referralRepo.QualifyReferral(ctx, referralID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if qualified {
+			if err := publish(ctx, s.events, entities.EventReferralQualified, referral); err != nil {
+				return err
+			}
+			// Награды кампании становятся положены после квалификации
+			if referral.ReferrerReward > 0 || referral.RefereeReward > 0 {
+				if err := publish(ctx, s.events, entities.EventReferralRewarded, referral); err != nil {
+					return err
+				}
+			}
+		}
+
+		change, err := s.tierService.RecalculateTier(ctx, referral.ReferrerID)
+		if err != nil || change == nil {
+			return err
+		}
+		return publish(ctx, s.events, entities.EventTierChanged, change)
+	})
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrReferralNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return referral, nil
 }
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+)
+
+// maxStatsBuckets ограничивает длину временного ряда, чтобы один запрос не строил годы по дням
+const maxStatsBuckets = 366
+
+// ReferralStatsService интерфейс для аналитики реферальной программы
+type ReferralStatsService interface {
+	// GetStats возвращает итоги, конверсию и временной ряд за период
+	GetStats(ctx context.Context, filter entities.ReferralStatsFilter) (*entities.ReferralStats, error)
+}
+
+// referralStatsService реализация ReferralStatsService
+type referralStatsService struct {
+	referralRepo repositories.ReferralRepository
+}
+
+// NewReferralStatsService создает новый ReferralStatsService
+func NewReferralStatsService(referralRepo repositories.ReferralRepository) ReferralStatsService {
+	return &referralStatsService{referralRepo: referralRepo}
+}
+
+// GetStats проверяет период и считает итоги по временному ряду из базы
+func (s *referralStatsService) GetStats(ctx context.Context, filter entities.ReferralStatsFilter) (*entities.ReferralStats, error) {
+	if filter.Interval == "" {
+		filter.Interval = entities.StatsIntervalDay
+	}
+
+	var step int
+	switch filter.Interval {
+	case entities.StatsIntervalDay:
+		step = 1
+	case entities.StatsIntervalWeek:
+		step = 7
+	default:
+		return nil, invalidField(ErrInvalidStatsRange, "interval", "validation_oneof",
+			i18n.Args{"values": entities.StatsIntervalDay + ", " + entities.StatsIntervalWeek})
+	}
+
+	if !filter.To.After(filter.From) {
+		return nil, invalidField(ErrInvalidStatsRange, "to", "validation_after", i18n.Args{"field": "from"})
+	}
+	if days := filter.To.Sub(filter.From).Hours() / 24; days > float64(maxStatsBuckets*step) {
+		return nil, invalidField(ErrInvalidStatsRange, "to", "stats_period_too_long", i18n.Args{"max": maxStatsBuckets})
+	}
+
+	series, err := s.referralRepo.GetReferralStatsSeries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &entities.ReferralStats{
+		From:     filter.From,
+		To:       filter.To,
+		Interval: filter.Interval,
+		Series:   series,
+	}
+	for _, bucket := range series {
+		stats.Clicks += bucket.Clicks
+		stats.Signups += bucket.Signups
+		stats.Qualified += bucket.Qualified
+	}
+	stats.SignupRate = rate(stats.Signups, stats.Clicks)
+	stats.QualificationRate = rate(stats.Qualified, stats.Signups)
+
+	return stats, nil
+}
+
+// rate возвращает долю part от total или ноль, если total пуст
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralStatsService_GetStats(t *testing.T) {
+	ctx := context.Background()
+	referralRepo := mocks.NewReferralRepository(t)
+	statsService := services.NewReferralStatsService(referralRepo)
+
+	referrerID := 3
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter := entities.ReferralStatsFilter{ReferrerID: &referrerID, From: from, To: from.Add(2 * 24 * time.Hour)}
+
+	expected := filter
+	expected.Interval = entities.StatsIntervalDay
+	referralRepo.On("GetReferralStatsSeries", ctx, expected).Return([]*entities.ReferralStatsBucket{
+		{Start: from, Clicks: 10, Signups: 2, Qualified: 1},
+		{Start: from.Add(24 * time.Hour), Clicks: 10, Signups: 3},
+	}, nil)
+
+	stats, err := statsService.GetStats(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatsIntervalDay, stats.Interval)
+	assert.Equal(t, 20, stats.Clicks)
+	assert.Equal(t, 5, stats.Signups)
+	assert.Equal(t, 1, stats.Qualified)
+	assert.InDelta(t, 0.25, stats.SignupRate, 1e-9)
+	assert.InDelta(t, 0.2, stats.QualificationRate, 1e-9)
+	assert.Len(t, stats.Series, 2)
+}
+
+func TestReferralStatsService_GetStats_RejectsInvalidRange(t *testing.T) {
+	ctx := context.Background()
+	statsService := services.NewReferralStatsService(mocks.NewReferralRepository(t))
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, filter := range []entities.ReferralStatsFilter{
+		{From: from, To: from},
+		{From: from, To: from.Add(24 * time.Hour), Interval: "month"},
+		{From: from.AddDate(-2, 0, 0), To: from, Interval: entities.StatsIntervalDay},
+	} {
+		_, err := statsService.GetStats(ctx, filter)
+		assert.ErrorIs(t, err, services.ErrInvalidStatsRange)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"time"
+)
+
+// TierService интерфейс для уровней рефереров. Уровень определяется числом квалифицированных рефералов
+type TierService interface {
+	GetTierProgress(ctx context.Context, userID int) (*entities.TierProgress, error)
+	// RecalculateTier сверяет сохраненный уровень с текущим и возвращает смену или nil
+	RecalculateTier(ctx context.Context, userID int) (*entities.TierChange, error)
+	// RewardMultiplier возвращает множитель награды для текущего уровня пользователя
+	RewardMultiplier(ctx context.Context, userID int) (float64, error)
+}
+
+// tierService реализация TierService
+type tierService struct {
+	tiers        []entities.Tier // отсортированы по возрастанию порога
+	referralRepo repositories.ReferralRepository
+	tierRepo     repositories.TierRepository
+	tx           repositories.Transactor
+}
+
+// NewTierService создает новый TierService. Уровни должны быть отсортированы по возрастанию порога
+func NewTierService(tiers []entities.Tier, referralRepo repositories.ReferralRepository, tierRepo repositories.TierRepository,
+	tx repositories.Transactor) TierService {
+	return &tierService{tiers: tiers, referralRepo: referralRepo, tierRepo: tierRepo, tx: tx}
+}
+
+// tierFor возвращает достигнутый уровень и следующий за ним
+func (s *tierService) tierFor(qualified int) (current, next *entities.Tier) {
+	for i := range s.tiers {
+		if qualified < s.tiers[i].Threshold {
+			return current, &s.tiers[i]
+		}
+		current = &s.tiers[i]
+	}
+	return current, nil
+}
+
+// GetTierProgress возвращает уровень, прогресс до следующего и историю смен
+func (s *tierService) GetTierProgress(ctx context.Context, userID int) (*entities.TierProgress, error) {
+	qualified, err := s.referralRepo.CountQualifiedReferrals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, next := s.tierFor(qualified)
+	progress := &entities.TierProgress{Tier: current, Qualified: qualified, NextTier: next, Progress: 1}
+	if next != nil {
+		from := 0
+		if current != nil {
+			from = current.Threshold
+		}
+		progress.Remaining = next.Threshold - qualified
+		progress.Progress = float64(qualified-from) / float64(next.Threshold-from)
+	}
+
+	progress.History, err = s.tierRepo.ListTierChanges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// RecalculateTier пересчитывает уровень после квалификации реферала. Рефералы считаются под блокировкой
+// пользователя, поэтому одновременный пересчет видит уже записанную смену и не откатывает уровень назад
+func (s *tierService) RecalculateTier(ctx context.Context, userID int) (*entities.TierChange, error) {
+	var change *entities.TierChange
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		from, err := s.tierRepo.LockUserTier(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		qualified, err := s.referralRepo.CountQualifiedReferrals(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		var to *string
+		if current, _ := s.tierFor(qualified); current != nil {
+			to = &current.Name
+		}
+		if (from == nil && to == nil) || (from != nil && to != nil && *from == *to) {
+			return nil
+		}
+
+		change = &entities.TierChange{UserID: userID, FromTier: from, ToTier: to, Qualified: qualified, ChangedAt: time.Now()}
+		return s.tierRepo.SaveTierChange(ctx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// RewardMultiplier возвращает 1, пока пользователь не достиг первого уровня
+func (s *tierService) RewardMultiplier(ctx context.Context, userID int) (float64, error) {
+	qualified, err := s.referralRepo.CountQualifiedReferrals(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if current, _ := s.tierFor(qualified); current != nil {
+		return current.Multiplier, nil
+	}
+	return 1, nil
+}
@@ -0,0 +1,134 @@
+package services_test
+
+import (
+	"context"
+	"referral-system/internal/entities"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testTiers = []entities.Tier{
+	{Name: "bronze", Threshold: 5, Multiplier: 1.1},
+	{Name: "silver", Threshold: 20, Multiplier: 1.25},
+	{Name: "gold", Threshold: 50, Multiplier: 1.5},
+}
+
+func TestTierService_GetTierProgress(t *testing.T) {
+	ctx := context.Background()
+	referralRepo := mocks.NewReferralRepository(t)
+	tierRepo := mocks.NewTierRepository(t)
+	tierService := services.NewTierService(testTiers, referralRepo, tierRepo, inlineTx{})
+
+	bronze := "bronze"
+	history := []*entities.TierChange{{UserID: 3, ToTier: &bronze, Qualified: 5}}
+	tierRepo.On("ListTierChanges", ctx, 3).Return(history, nil)
+
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(8, nil).Once()
+	progress, err := tierService.GetTierProgress(ctx, 3)
+	require.NoError(t, err)
+	require.NotNil(t, progress.Tier)
+	assert.Equal(t, "bronze", progress.Tier.Name)
+	require.NotNil(t, progress.NextTier)
+	assert.Equal(t, "silver", progress.NextTier.Name)
+	assert.Equal(t, 12, progress.Remaining)
+	assert.InDelta(t, 0.2, progress.Progress, 1e-9)
+	assert.Equal(t, history, progress.History)
+
+	// До первого уровня прогресс считается от нуля
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(2, nil).Once()
+	progress, err = tierService.GetTierProgress(ctx, 3)
+	require.NoError(t, err)
+	assert.Nil(t, progress.Tier)
+	assert.Equal(t, 3, progress.Remaining)
+	assert.InDelta(t, 0.4, progress.Progress, 1e-9)
+
+	// На последнем уровне идти дальше некуда
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(70, nil).Once()
+	progress, err = tierService.GetTierProgress(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "gold", progress.Tier.Name)
+	assert.Nil(t, progress.NextTier)
+	assert.Equal(t, 1.0, progress.Progress)
+}
+
+func TestReferralService_QualifyReferral_RecalculatesTier(t *testing.T) {
+	ctx := context.Background()
+	referralRepo := mocks.NewReferralRepository(t)
+	tierRepo := mocks.NewTierRepository(t)
+	tx := &rollbackTx{}
+	publisher := &recordingPublisher{}
+	tierService := services.NewTierService(testTiers, referralRepo, tierRepo, tx)
+	referralService := services.NewReferralService(mocks.NewReferralCodeRepository(t), mocks.NewUserRepository(t),
+		referralRepo, mocks.NewCampaignRepository(t), nil, tierService, tx, publisher)
+
+	referralRepo.On("QualifyReferral", mock.MatchedBy(inTx), 11, mock.AnythingOfType("time.Time")).
+		Return(&entities.Referral{ID: 11, ReferrerID: 3}, true, nil)
+	bronze := "bronze"
+	// Пересчет уровня идет в транзакции квалификации
+	tierRepo.On("LockUserTier", mock.MatchedBy(inTx), 3).Return(&bronze, nil)
+	referralRepo.On("CountQualifiedReferrals", mock.MatchedBy(inTx), 3).Return(20, nil)
+	tierRepo.On("SaveTierChange", mock.MatchedBy(inTx), mock.MatchedBy(func(c *entities.TierChange) bool {
+		return c.UserID == 3 && *c.FromTier == "bronze" && *c.ToTier == "silver" && c.Qualified == 20
+	})).Return(nil)
+
+	referral, err := referralService.QualifyReferral(ctx, 11)
+	require.NoError(t, err)
+	assert.Equal(t, 11, referral.ID)
+	assert.Equal(t, []string{entities.EventReferralQualified, entities.EventTierChanged}, publisher.types())
+}
+
+func TestTierService_RecalculateTier_CountsUnderUserLock(t *testing.T) {
+	ctx := context.Background()
+	referralRepo := mocks.NewReferralRepository(t)
+	tierRepo := mocks.NewTierRepository(t)
+	tierService := services.NewTierService(testTiers, referralRepo, tierRepo, inlineTx{})
+
+	// Подсчет должен идти после блокировки, иначе устаревшее число может откатить уровень
+	var calls []string
+	bronze := "bronze"
+	tierRepo.On("LockUserTier", ctx, 3).Return(&bronze, nil).
+		Run(func(mock.Arguments) { calls = append(calls, "lock") })
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(6, nil).Once().
+		Run(func(mock.Arguments) { calls = append(calls, "count") })
+
+	change, err := tierService.RecalculateTier(ctx, 3)
+	require.NoError(t, err)
+	assert.Nil(t, change, "tier is unchanged")
+	assert.Equal(t, []string{"lock", "count"}, calls)
+
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(50, nil).Once()
+	tierRepo.On("SaveTierChange", ctx, mock.AnythingOfType("*entities.TierChange")).Return(nil)
+	change, err = tierService.RecalculateTier(ctx, 3)
+	require.NoError(t, err)
+	require.NotNil(t, change)
+	assert.Equal(t, "bronze", *change.FromTier)
+	assert.Equal(t, "gold", *change.ToTier)
+}
+
+func TestReferralService_CreditReferral_AppliesTierMultiplier(t *testing.T) {
+	ctx := context.Background()
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	referralRepo := mocks.NewReferralRepository(t)
+	campaignRepo := mocks.NewCampaignRepository(t)
+	tierService := services.NewTierService(testTiers, referralRepo, mocks.NewTierRepository(t), inlineTx{})
+	referralService := services.NewReferralService(codeRepo, mocks.NewUserRepository(t), referralRepo, campaignRepo, nil, tierService, inlineTx{}, &recordingPublisher{})
+
+	campaign := activeCampaign()
+	code := &entities.ReferralCode{ID: 8, UserID: 3, Code: "SPRING", ExpiresAt: campaign.EndsAt, CampaignID: &campaign.ID}
+	codeRepo.On("GetReferralByReferralCode", ctx, "SPRING").Return(code, nil)
+	campaignRepo.On("GetCampaignByID", ctx, campaign.ID).Return(campaign, nil)
+	codeRepo.On("LockReferralCode", ctx, code.ID).Return(code, nil)
+	campaignRepo.On("LockCampaign", ctx, campaign.ID).Return(campaign, nil)
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(6, nil)
+
+	// Бронзовый уровень увеличивает награду реферера, но не приглашенного
+	referralRepo.On("CreateReferralLink", ctx, mock.MatchedBy(func(r *entities.Referral) bool {
+		return r.ReferrerReward == 110 && r.RefereeReward == 50
+	})).Return(nil)
+	require.NoError(t, referralService.CreditReferral(ctx, "SPRING", 10))
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+	"time"
+)
+
+// emailVerificationTTL - время жизни токена подтверждения нового email
+const emailVerificationTTL = 24 * time.Hour
+
+// EmailVerificationSender отправляет на новый email пользователя токен подтверждения
+type EmailVerificationSender interface {
+	SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error
+}
+
+// UserService интерфейс для управления профилем пользователя
+type UserService interface {
+	GetUser(ctx context.Context, userID int) (*entities.User, error)
+	UpdateProfile(ctx context.Context, userID int, name, email *string, leaderboardOptOut *bool, locale *string) (*entities.User, error)
+	ConfirmEmailChange(ctx context.Context, userID int, token string) (*entities.User, error)
+	ExportData(ctx context.Context, userID int) (*entities.UserDataExport, error)
+	DeleteAccount(ctx context.Context, userID int, password string) error
+}
+
+// userService реализация UserService
+type userService struct {
+	userRepo           repositories.UserRepository
+	referralCodeRepo   repositories.ReferralCodeRepository
+	referralRepo       repositories.ReferralRepository
+	identityRepo       repositories.UserIdentityRepository
+	apiKeyRepo         repositories.APIKeyRepository
+	clickRepo          repositories.ReferralClickRepository
+	tierRepo           repositories.TierRepository
+	notificationRepo   repositories.NotificationRepository
+	verificationSender EmailVerificationSender
+}
+
+// NewUserService создает новый UserService
+func NewUserService(userRepo repositories.UserRepository,
+	referralCodeRepo repositories.ReferralCodeRepository,
+	referralRepo repositories.ReferralRepository,
+	identityRepo repositories.UserIdentityRepository,
+	apiKeyRepo repositories.APIKeyRepository,
+	clickRepo repositories.ReferralClickRepository,
+	tierRepo repositories.TierRepository,
+	notificationRepo repositories.NotificationRepository,
+	verificationSender EmailVerificationSender) UserService {
+	return &userService{
+		userRepo:           userRepo,
+		referralCodeRepo:   referralCodeRepo,
+		referralRepo:       referralRepo,
+		identityRepo:       identityRepo,
+		apiKeyRepo:         apiKeyRepo,
+		clickRepo:          clickRepo,
+		tierRepo:           tierRepo,
+		notificationRepo:   notificationRepo,
+		verificationSender: verificationSender,
+	}
+}
+
+// GetUser возвращает пользователя по ID
+func (s *userService) GetUser(ctx context.Context, userID int) (*entities.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// UpdateProfile меняет имя, язык и видимость в рейтинге и запускает подтверждение нового email.
+// Email меняется только после вызова ConfirmEmailChange с токеном из письма
+func (s *userService) UpdateProfile(ctx context.Context, userID int, name, email *string, leaderboardOptOut *bool, locale *string) (*entities.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var verificationToken string
+	if email != nil && *email != user.Email {
+		// Проверим, не занят ли email другим пользователем
+		existing, err := s.userRepo.GetUserByEmail(ctx, *email)
+		if err == nil && existing.ID != user.ID {
+			return nil, ErrUserAlreadyExists
+		}
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+
+		verificationToken, err = generateToken()
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.userRepo.SetPendingEmail(ctx, userID, *email, hashToken(verificationToken), time.Now().Add(emailVerificationTTL))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user, err = s.userRepo.UpdateProfile(ctx, userID, &entities.ProfileUpdate{
+		Name:              name,
+		LeaderboardOptOut: leaderboardOptOut,
+		Locale:            locale,
+	})
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if verificationToken != "" {
+		if err := s.verificationSender.SendEmailVerification(ctx, user, *email, verificationToken); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// ConfirmEmailChange применяет ожидающий подтверждения email, если токен верный
+func (s *userService) ConfirmEmailChange(ctx context.Context, userID int, token string) (*entities.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PendingEmail == nil || user.EmailVerificationToken == nil || user.EmailVerificationExpiresAt == nil {
+		return nil, ErrInvalidVerificationToken
+	}
+	if user.EmailVerificationExpiresAt.Before(time.Now()) || !tokenMatches(token, *user.EmailVerificationToken) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	// Изменение применяется, только если токен не заменили новым запросом смены email
+	err = s.userRepo.ConfirmEmail(ctx, userID, *user.EmailVerificationToken)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, ErrUserAlreadyExists
+	}
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, userID)
+}
+
+// ExportData собирает все данные, которые сервис хранит о пользователе
+func (s *userService) ExportData(ctx context.Context, userID int) (*entities.UserDataExport, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &entities.UserDataExport{
+		ExportedAt:    time.Now(),
+		Profile:       user,
+		ReferralCodes: []*entities.ReferralCode{},
+		Referrals:     []*entities.Referral{},
+		Identities:    []*entities.UserIdentity{},
+		APIKeys:       []*entities.APIKey{},
+		Clicks:        []*entities.ReferralClick{},
+		TierChanges:   []*entities.TierChange{},
+	}
+
+	codes, err := s.referralCodeRepo.ListReferralCodesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export.ReferralCodes = append(export.ReferralCodes, codes...)
+
+	referrals, err := s.referralRepo.GetReferralsByReferrerID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Referrals = append(export.Referrals, referrals...)
+
+	referredBy, err := s.referralRepo.GetReferralByRefereeID(ctx, userID)
+	if err == nil {
+		export.ReferredBy = referredBy
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	identities, err := s.identityRepo.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Identities = append(export.Identities, identities...)
+
+	apiKeys, err := s.apiKeyRepo.ListAPIKeys(ctx, &userID)
+	if err != nil {
+		return nil, err
+	}
+	export.APIKeys = append(export.APIKeys, apiKeys...)
+
+	clicks, err := s.clickRepo.ListClicksByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Clicks = append(export.Clicks, clicks...)
+
+	tierChanges, err := s.tierRepo.ListTierChanges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	export.TierChanges = append(export.TierChanges, tierChanges...)
+
+	export.NotificationPreferences, err = s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// DeleteAccount анонимизирует аккаунт после подтверждения паролем, а аккаунт без пароля - после свежего входа.
+// Связи в referrals сохраняются, чтобы у реферера не пропала статистика
+func (s *userService) DeleteAccount(ctx context.Context, userID int, password string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := confirmIdentity(ctx, user, password); err != nil {
+		return err
+	}
+
+	err = s.userRepo.AnonymizeUser(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// requestLocale возвращает язык, выбранный клиентом в запросе, или nil, если клиент его не указал
+func requestLocale(ctx context.Context) *string {
+	if locale, ok := i18n.LocaleFromContext(ctx); ok {
+		return &locale
+	}
+	return nil
+}
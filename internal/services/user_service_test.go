@@ -0,0 +1,132 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"referral-system/internal/auth"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserService_ExportData_IncludesAllUserData(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	codeRepo := mocks.NewReferralCodeRepository(t)
+	referralRepo := mocks.NewReferralRepository(t)
+	identityRepo := mocks.NewUserIdentityRepository(t)
+	apiKeyRepo := mocks.NewAPIKeyRepository(t)
+	clickRepo := mocks.NewReferralClickRepository(t)
+	tierRepo := mocks.NewTierRepository(t)
+	notificationRepo := mocks.NewNotificationRepository(t)
+	userService := services.NewUserService(userRepo, codeRepo, referralRepo, identityRepo, apiKeyRepo, clickRepo, tierRepo,
+		notificationRepo, nil)
+
+	userID := 7
+	userRepo.On("GetUserByID", ctx, userID).Return(&entities.User{ID: userID, Email: "example@mail.com"}, nil)
+	batchID, codeID := 5, 1
+	codeRepo.On("ListReferralCodesByUserID", ctx, userID).Return([]*entities.ReferralCode{
+		{ID: 1, UserID: userID, Code: "CODE"},
+		{ID: 2, UserID: userID, Code: "PARTNER1", BatchID: &batchID},
+	}, nil)
+	referralRepo.On("GetReferralsByReferrerID", ctx, userID).Return([]*entities.Referral{}, nil)
+	referralRepo.On("GetReferralByRefereeID", ctx, userID).Return(nil, repositories.ErrNotFound)
+	identityRepo.On("ListIdentities", ctx, userID).
+		Return([]*entities.UserIdentity{{ID: 2, UserID: userID, Provider: "google", Subject: "1234"}}, nil)
+	apiKeyRepo.On("ListAPIKeys", ctx, &userID).
+		Return([]*entities.APIKey{{ID: 3, UserID: &userID, Name: "ci", Prefix: "rk_abc", KeyHash: "secret-hash"}}, nil)
+	clickRepo.On("ListClicksByUserID", ctx, userID).
+		Return([]*entities.ReferralClick{{ID: 4, ReferralCodeID: &codeID, ReferrerID: &userID, UTMSource: "flyer", IPHash: "ip-hash"}}, nil)
+	tierRepo.On("ListTierChanges", ctx, userID).Return(nil, nil)
+	notificationRepo.On("GetPreferences", ctx, userID).
+		Return(entities.NotificationPreferences{entities.NotificationRewardGranted: false}, nil)
+
+	export, err := userService.ExportData(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, export.ReferralCodes, 2, "batch codes are exported with the personal one")
+	assert.Len(t, export.Identities, 1)
+	assert.Len(t, export.APIKeys, 1)
+	assert.Len(t, export.Clicks, 1)
+	assert.NotNil(t, export.TierChanges, "empty history is exported as an empty list")
+	assert.Equal(t, false, export.NotificationPreferences[entities.NotificationRewardGranted])
+
+	// Хэши ключей и IP не попадают в выгрузку
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"provider":"google"`)
+	assert.Contains(t, string(data), `"prefix":"rk_abc"`)
+	assert.Contains(t, string(data), `"utm_source":"flyer"`)
+	assert.Contains(t, string(data), `"tier_changes":[]`)
+	assert.NotContains(t, string(data), "secret-hash")
+	assert.NotContains(t, string(data), "ip-hash")
+}
+
+func TestUserService_DeleteAccount_PasswordlessRequiresFreshLogin(t *testing.T) {
+	userRepo := mocks.NewUserRepository(t)
+	userService := services.NewUserService(userRepo, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	userRepo.On("GetUserByID", mock.Anything, 7).Return(&entities.User{ID: 7, Email: "social@mail.com"}, nil)
+
+	stale := auth.WithAuthenticatedAt(context.Background(), time.Now().Add(-time.Hour))
+	assert.ErrorIs(t, userService.DeleteAccount(stale, 7, ""), services.ErrReauthRequired)
+
+	fresh := auth.WithAuthenticatedAt(context.Background(), time.Now())
+	userRepo.On("AnonymizeUser", fresh, 7).Return(nil)
+	require.NoError(t, userService.DeleteAccount(fresh, 7, ""))
+}
+
+func TestUserService_DeleteAccount_ChecksPassword(t *testing.T) {
+	userRepo := mocks.NewUserRepository(t)
+	userService := services.NewUserService(userRepo, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("right_password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userRepo.On("GetUserByID", mock.Anything, 7).Return(&entities.User{ID: 7, HashedPassword: string(hash)}, nil)
+
+	// Свежий вход не заменяет пароль, если он задан
+	fresh := auth.WithAuthenticatedAt(context.Background(), time.Now())
+	assert.ErrorIs(t, userService.DeleteAccount(fresh, 7, ""), services.ErrInvalidCredentials)
+}
+
+// recordingVerifications запоминает, кому отправлен код подтверждения email
+type recordingVerifications struct {
+	user  *entities.User
+	email string
+	token string
+}
+
+func (r *recordingVerifications) SendEmailVerification(_ context.Context, user *entities.User, email, token string) error {
+	r.user, r.email, r.token = user, email, token
+	return nil
+}
+
+func TestUserService_UpdateProfile_SendsVerificationToNewEmail(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mocks.NewUserRepository(t)
+	verifications := &recordingVerifications{}
+	userService := services.NewUserService(userRepo, nil, nil, nil, nil, nil, nil, nil, verifications)
+
+	locale := "ru"
+	updated := &entities.User{ID: 7, Name: "Alice", Email: "old@mail.com", Locale: &locale}
+	userRepo.On("GetUserByID", ctx, 7).Return(&entities.User{ID: 7, Email: "old@mail.com"}, nil)
+	userRepo.On("GetUserByEmail", ctx, "new@mail.com").Return(nil, repositories.ErrNotFound)
+	userRepo.On("SetPendingEmail", ctx, 7, "new@mail.com", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("UpdateProfile", ctx, 7, mock.AnythingOfType("*entities.ProfileUpdate")).Return(updated, nil)
+
+	email := "new@mail.com"
+	_, err := userService.UpdateProfile(ctx, 7, nil, &email, nil, &locale)
+	require.NoError(t, err)
+
+	// Письмо уходит на новый адрес на языке из обновленного профиля
+	assert.Equal(t, "new@mail.com", verifications.email)
+	assert.Equal(t, updated, verifications.user)
+	assert.NotEmpty(t, verifications.token)
+}
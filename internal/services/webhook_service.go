@@ -0,0 +1,439 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/i18n"
+	"referral-system/internal/repositories"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookBaseBackoff = 30 * time.Second
+	defaultWebhookMaxBackoff  = 6 * time.Hour
+
+	// webhookBatchSize - сколько доставок забирается из очереди за раз
+	webhookBatchSize = 50
+	// webhookSecretPrefix отличает секреты вебхуков от других секретов
+	webhookSecretPrefix = "whsec_"
+	// maxWebhookError - сколько символов ошибки попытки хранится в журнале
+	maxWebhookError = 500
+)
+
+// errPrivateWebhookAddress - получатель находится в локальной или внутренней сети
+var errPrivateWebhookAddress = errors.New("webhook receiver address is not public")
+
+// sharedAddressSpace - адреса провайдерского NAT (RFC 6598), они не входят в netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Заголовки запроса вебхука
+const (
+	WebhookSignatureHeader = "Webhook-Signature"
+	WebhookEventHeader     = "Webhook-Event"
+	WebhookIDHeader        = "Webhook-Id"
+)
+
+// WebhookService интерфейс для подписок на события и их доставки
+type WebhookService interface {
+	// Publish ставит событие в очередь доставки всем подписанным на него
+	Publish(ctx context.Context, event *entities.Event) error
+
+	// CreateSubscription создает подписку и возвращает ее секрет. Он показывается только один раз
+	CreateSubscription(ctx context.Context, rawURL string, eventTypes []string, createdBy *int) (*entities.WebhookSubscription, string, error)
+	ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+
+	ListDeliveries(ctx context.Context, subscriptionID int, limit int) ([]*entities.WebhookDelivery, error)
+	// ReplayDelivery повторно отправляет событие из журнала новой доставкой с тем же ID события
+	ReplayDelivery(ctx context.Context, deliveryID int64) (*entities.WebhookDelivery, error)
+	// SendTestEvent сразу отправляет подписке проверочное событие и возвращает результат попытки
+	SendTestEvent(ctx context.Context, subscriptionID int) (*entities.WebhookDelivery, error)
+
+	// ProcessDueDeliveries отправляет доставки, чье время наступило, и возвращает их число
+	ProcessDueDeliveries(ctx context.Context) (int, error)
+}
+
+// webhookService реализация WebhookService
+type webhookService struct {
+	webhookRepo  repositories.WebhookRepository
+	client       *http.Client
+	allowPrivate bool
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+}
+
+// NewWebhookService создает новый WebhookService
+func NewWebhookService(webhookRepo repositories.WebhookRepository, cfg config.Webhooks) WebhookService {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	s := &webhookService{
+		webhookRepo:  webhookRepo,
+		client:       newWebhookClient(timeout, cfg.AllowPrivateNetworks),
+		allowPrivate: cfg.AllowPrivateNetworks,
+		maxAttempts:  cfg.MaxAttempts,
+		baseBackoff:  time.Duration(cfg.BaseBackoff) * time.Second,
+		maxBackoff:   time.Duration(cfg.MaxBackoff) * time.Second,
+	}
+
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultWebhookMaxAttempts
+	}
+	if s.baseBackoff <= 0 {
+		s.baseBackoff = defaultWebhookBaseBackoff
+	}
+	if s.maxBackoff < s.baseBackoff {
+		s.maxBackoff = max(defaultWebhookMaxBackoff, s.baseBackoff)
+	}
+
+	return s
+}
+
+// newWebhookClient создает клиент для отправки вебхуков. Он не переходит по редиректам и без allowPrivate
+// не соединяется с локальными и внутренними адресами. Адрес проверяется при соединении, уже после
+// разрешения имени, поэтому DNS запись, указывающая во внутреннюю сеть, тоже не пройдет
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errPrivateWebhookAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		// Прокси из окружения не используется, иначе проверялся бы адрес прокси, а не получателя
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        webhookBatchSize,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// Ответ 3xx считается неудачной попыткой
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicAddr сообщает, что адрес не локальный, не частный и не служебный
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// SignWebhookPayload возвращает значение заголовка Webhook-Signature: t=<unix>,v1=<hex HMAC-SHA256>.
+// Подписывается строка "<unix>.<тело запроса>", получатель проверяет подпись и свежесть t
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// lease - на сколько забранная доставка скрывается от других обработчиков. Доставки пачки
+// отправляются параллельно, поэтому за это время гарантированно заканчиваются все попытки
+func (s *webhookService) lease() time.Duration {
+	return 2 * s.client.Timeout
+}
+
+// validateURL допускает только абсолютные http и https адреса. Адреса внутренней сети, указанные
+// явно, отклоняются сразу, а скрытые за DNS именем - при отправке
+func (s *webhookService) validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return invalidField(ErrInvalidWebhook, "url", "webhook_url_invalid", nil)
+	}
+	if s.allowPrivate {
+		return nil
+	}
+
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); (err == nil && !isPublicAddr(addr)) || host == "localhost" {
+		return invalidField(ErrInvalidWebhook, "url", "webhook_url_private", nil)
+	}
+	return nil
+}
+
+// CreateSubscription проверяет адрес и типы событий и создает подписку со случайным секретом
+func (s *webhookService) CreateSubscription(ctx context.Context, rawURL string, eventTypes []string, createdBy *int) (*entities.WebhookSubscription, string, error) {
+	if err := s.validateURL(rawURL); err != nil {
+		return nil, "", err
+	}
+	if len(eventTypes) == 0 {
+		return nil, "", invalidField(ErrInvalidWebhook, "event_types", "validation_required", nil)
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(entities.EventTypes, eventType) {
+			return nil, "", invalidField(ErrInvalidWebhook, "event_types", "webhook_unknown_event_type", i18n.Args{"event_type": eventType})
+		}
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	subscription := &entities.WebhookSubscription{
+		URL:        rawURL,
+		Secret:     webhookSecretPrefix + secret,
+		EventTypes: slices.Compact(slices.Sorted(slices.Values(eventTypes))),
+		CreatedBy:  createdBy,
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, "", err
+	}
+
+	return subscription, subscription.Secret, nil
+}
+
+// ListSubscriptions возвращает все подписки
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	return s.webhookRepo.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription удаляет подписку и ее журнал
+func (s *webhookService) DeleteSubscription(ctx context.Context, id int) error {
+	err := s.webhookRepo.DeleteSubscription(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// subscription находит подписку по ID
+func (s *webhookService) subscription(ctx context.Context, id int) (*entities.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetSubscription(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, err
+}
+
+// ListDeliveries возвращает журнал доставок подписки
+func (s *webhookService) ListDeliveries(ctx context.Context, subscriptionID int, limit int) ([]*entities.WebhookDelivery, error) {
+	if _, err := s.subscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// newDelivery готовит доставку события подписке. Доставка будет отправлена не раньше at
+func newDelivery(subscriptionID int, eventID, eventType string, payload []byte, at time.Time) *entities.WebhookDelivery {
+	return &entities.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         entities.DeliveryStatusPending,
+		NextAttemptAt:  &at,
+	}
+}
+
+// enqueue ставит событие в очередь доставки подписке
+func (s *webhookService) enqueue(ctx context.Context, subscriptionID int, eventID, eventType string, payload []byte, at time.Time) (*entities.WebhookDelivery, error) {
+	delivery := newDelivery(subscriptionID, eventID, eventType, payload, at)
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Publish создает доставку для каждой подписки на тип события. Доставки сохраняются вместе, а уникальный
+// индекс по подписке и ID события отбрасывает уже созданные, поэтому повторная публикация того же события
+// outbox не дублирует доставки. Отправляет их ProcessDueDeliveries
+func (s *webhookService) Publish(ctx context.Context, event *entities.Event) error {
+	subscriptions, err := s.webhookRepo.ListSubscriptionsForEvent(ctx, event.Type)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*entities.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, newDelivery(subscription.ID, event.ID, event.Type, payload, now))
+	}
+	return s.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// ReplayDelivery ставит в очередь копию доставки со ссылкой на исходную. Получатель может отсеять ее по ID события
+func (s *webhookService) ReplayDelivery(ctx context.Context, deliveryID int64) (*entities.WebhookDelivery, error) {
+	original, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := newDelivery(original.SubscriptionID, original.EventID, original.EventType, original.Payload, time.Now())
+	delivery.ReplayOf = &original.ID
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// SendTestEvent отправляет проверочное событие без ожидания очереди. При неудаче оно повторяется как обычная доставка
+func (s *webhookService) SendTestEvent(ctx context.Context, subscriptionID int) (*entities.WebhookDelivery, error) {
+	subscription, err := s.subscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newEvent(entities.EventWebhookTest, map[string]int{"subscription_id": subscription.ID})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Очередь не заберет доставку, пока идет эта попытка
+	delivery, err := s.enqueue(ctx, subscription.ID, event.ID, event.Type, payload, time.Now().Add(s.lease()))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attempt(ctx, subscription, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ProcessDueDeliveries забирает из очереди доставки, чье время наступило, и отправляет их параллельно,
+// чтобы вся пачка уложилась в lease
+func (s *webhookService) ProcessDueDeliveries(ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), s.lease(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[int]*entities.WebhookSubscription)
+	for _, delivery := range deliveries {
+		if _, ok := subscriptions[delivery.SubscriptionID]; ok {
+			continue
+		}
+		subscription, err := s.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			// Подписку удалили вместе с ее доставками
+			subscriptions[delivery.SubscriptionID] = nil
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		subscriptions[delivery.SubscriptionID] = subscription
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(deliveries))
+	for i, delivery := range deliveries {
+		subscription := subscriptions[delivery.SubscriptionID]
+		if subscription == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.attempt(ctx, subscription, delivery)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+// attempt отправляет доставку и сохраняет результат. Ошибки получателя не возвращаются,
+// а планируют следующую попытку или переводят доставку в failed
+func (s *webhookService) attempt(ctx context.Context, subscription *entities.WebhookSubscription, delivery *entities.WebhookDelivery) error {
+	status, err := s.send(ctx, subscription, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = entities.DeliveryStatusSucceeded
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = entities.DeliveryStatusFailed
+		delivery.Error = truncate(err.Error(), maxWebhookError)
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(backoff(s.baseBackoff, s.maxBackoff, delivery.Attempts))
+		delivery.Error = truncate(err.Error(), maxWebhookError)
+		delivery.NextAttemptAt = &next
+	}
+
+	// Результат сохраняется, даже если запрос, запустивший отправку, уже отменен
+	return s.webhookRepo.UpdateDelivery(context.WithoutCancel(ctx), delivery)
+}
+
+// send выполняет POST запрос с подписанным телом. Успехом считается любой ответ 2xx
+func (s *webhookService) send(ctx context.Context, subscription *entities.WebhookSubscription, delivery *entities.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, time.Now().Unix(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,324 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"referral-system/internal/config"
+	"referral-system/internal/entities"
+	"referral-system/internal/repositories"
+	"referral-system/internal/services"
+	"referral-system/internal/services/mocks"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct {
+	events []*entities.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event *entities.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) types() []string {
+	types := make([]string, 0, len(p.events))
+	for _, event := range p.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	signature := services.SignWebhookPayload("whsec_test", 1700000000, []byte(`{"id":"1"}`))
+	assert.Equal(t, "t=1700000000,v1=11bf4466ea17c3df3fd743af0b435368e16b7a05eb8eced85e8c4670767bdec5", signature)
+}
+
+func TestWebhookService_CreateSubscription_Validates(t *testing.T) {
+	ctx := context.Background()
+	webhookService := services.NewWebhookService(mocks.NewWebhookRepository(t), config.Webhooks{})
+
+	_, _, err := webhookService.CreateSubscription(ctx, "ftp://example.com", []string{entities.EventReferralCreated}, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidWebhook)
+
+	_, _, err = webhookService.CreateSubscription(ctx, "https://example.com/hook", []string{"user.deleted"}, nil)
+	assert.ErrorIs(t, err, services.ErrInvalidWebhook)
+
+	// Адреса внутренней сети, указанные явно, отклоняются сразу
+	for _, rawURL := range []string{"http://127.0.0.1/hook", "http://localhost:8080/hook", "http://169.254.169.254/latest",
+		"http://10.0.0.5/hook", "http://[::1]/hook", "http://[::ffff:192.168.0.1]/hook"} {
+		_, _, err = webhookService.CreateSubscription(ctx, rawURL, []string{entities.EventReferralCreated}, nil)
+		assert.ErrorIs(t, err, services.ErrInvalidWebhook, rawURL)
+	}
+}
+
+func TestWebhookService_CreateSubscription_ReturnsSecret(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{})
+
+	webhookRepo.On("CreateSubscription", ctx, mock.MatchedBy(func(s *entities.WebhookSubscription) bool {
+		return s.URL == "https://example.com/hook" && strings.HasPrefix(s.Secret, "whsec_") &&
+			assert.ObjectsAreEqual([]string{entities.EventReferralCreated, entities.EventReferralQualified}, s.EventTypes)
+	})).Return(nil)
+
+	subscription, secret, err := webhookService.CreateSubscription(ctx, "https://example.com/hook",
+		[]string{entities.EventReferralQualified, entities.EventReferralCreated, entities.EventReferralQualified}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, subscription.Secret, secret)
+}
+
+func TestWebhookService_Publish_EnqueuesForSubscribers(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{})
+
+	webhookRepo.On("ListSubscriptionsForEvent", ctx, entities.EventReferralCreated).
+		Return([]*entities.WebhookSubscription{{ID: 1}, {ID: 2}}, nil)
+	// Доставки события сохраняются вместе, чтобы повтор публикации не дублировал часть из них
+	webhookRepo.On("CreateDeliveries", ctx, mock.MatchedBy(func(deliveries []*entities.WebhookDelivery) bool {
+		if len(deliveries) != 2 || deliveries[0].SubscriptionID != 1 || deliveries[1].SubscriptionID != 2 {
+			return false
+		}
+		for _, d := range deliveries {
+			if d.EventID != "evt_1" || d.Status != entities.DeliveryStatusPending || d.NextAttemptAt == nil {
+				return false
+			}
+		}
+		return true
+	})).Return(nil).Once()
+
+	event := &entities.Event{ID: "evt_1", Type: entities.EventReferralCreated, Data: map[string]int{"id": 5}}
+	require.NoError(t, webhookService.Publish(ctx, event))
+}
+
+func TestWebhookService_ProcessDueDeliveries_SignsRequest(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{AllowPrivateNetworks: true})
+
+	payload := []byte(`{"id":"evt_1","type":"referral.created"}`)
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, payload, body)
+
+		// Получатель проверяет подпись по своей копии секрета
+		header := r.Header.Get(services.WebhookSignatureHeader)
+		ts, _, _ := strings.Cut(strings.TrimPrefix(header, "t="), ",")
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, services.SignWebhookPayload("whsec_test", timestamp, body), header)
+
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	delivery := &entities.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "evt_1",
+		EventType: entities.EventReferralCreated, Payload: payload, Status: entities.DeliveryStatusPending}
+	webhookRepo.On("ClaimDueDeliveries", ctx, mock.AnythingOfType("time.Time"), 20*time.Second, 50).
+		Return([]*entities.WebhookDelivery{delivery}, nil)
+	webhookRepo.On("GetSubscription", ctx, 1).
+		Return(&entities.WebhookSubscription{ID: 1, URL: server.URL, Secret: "whsec_test"}, nil)
+	webhookRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	processed, err := webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	r := <-received
+	assert.Equal(t, entities.EventReferralCreated, r.Header.Get(services.WebhookEventHeader))
+	assert.Equal(t, "evt_1", r.Header.Get(services.WebhookIDHeader))
+	assert.Equal(t, entities.DeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, *delivery.ResponseStatus)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.NotNil(t, delivery.DeliveredAt)
+}
+
+func TestWebhookService_ProcessDueDeliveries_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{MaxAttempts: 3, BaseBackoff: 60, AllowPrivateNetworks: true})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	delivery := &entities.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "evt_1", Payload: []byte(`{}`),
+		Status: entities.DeliveryStatusPending, Attempts: 1}
+	webhookRepo.On("ClaimDueDeliveries", ctx, mock.AnythingOfType("time.Time"), mock.Anything, 50).
+		Return([]*entities.WebhookDelivery{delivery}, nil)
+	webhookRepo.On("GetSubscription", ctx, 1).
+		Return(&entities.WebhookSubscription{ID: 1, URL: server.URL, Secret: "whsec_test"}, nil)
+	webhookRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	// Вторая неудача откладывает доставку на удвоенную паузу
+	start := time.Now()
+	_, err := webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, *delivery.ResponseStatus)
+	assert.Contains(t, delivery.Error, "503")
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, start.Add(2*time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+
+	// Последняя попытка переводит доставку в failed
+	_, err = webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.DeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestWebhookService_ProcessDueDeliveries_RefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach a loopback receiver")
+	}))
+	defer server.Close()
+
+	// Адрес подписки проверяется еще и при соединении: так отклоняются и имена, разрешающиеся во внутреннюю сеть
+	delivery := &entities.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "evt_1", Payload: []byte(`{}`),
+		Status: entities.DeliveryStatusPending}
+	webhookRepo.On("ClaimDueDeliveries", ctx, mock.AnythingOfType("time.Time"), mock.Anything, 50).
+		Return([]*entities.WebhookDelivery{delivery}, nil)
+	webhookRepo.On("GetSubscription", ctx, 1).
+		Return(&entities.WebhookSubscription{ID: 1, URL: server.URL, Secret: "whsec_test"}, nil)
+	webhookRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	_, err := webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.DeliveryStatusPending, delivery.Status)
+	assert.Contains(t, delivery.Error, "not public")
+}
+
+func TestWebhookService_ProcessDueDeliveries_DoesNotFollowRedirects(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{AllowPrivateNetworks: true})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			t.Error("redirect must not be followed")
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	delivery := &entities.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "evt_1", Payload: []byte(`{}`),
+		Status: entities.DeliveryStatusPending}
+	webhookRepo.On("ClaimDueDeliveries", ctx, mock.AnythingOfType("time.Time"), mock.Anything, 50).
+		Return([]*entities.WebhookDelivery{delivery}, nil)
+	webhookRepo.On("GetSubscription", ctx, 1).
+		Return(&entities.WebhookSubscription{ID: 1, URL: server.URL + "/hook", Secret: "whsec_test"}, nil)
+	webhookRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	_, err := webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, http.StatusFound, *delivery.ResponseStatus)
+}
+
+func TestWebhookService_ProcessDueDeliveries_SendsBatchInParallel(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{Timeout: 5, AllowPrivateNetworks: true})
+
+	// Получатель отвечает, только когда пришли все запросы пачки. При отправке по одной
+	// каждая попытка ждала бы до таймаута, и пачка не уложилась бы в lease
+	const batch = 3
+	var inFlight sync.WaitGroup
+	inFlight.Add(batch)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Done()
+		inFlight.Wait()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	deliveries := make([]*entities.WebhookDelivery, 0, batch)
+	for i := range batch {
+		deliveries = append(deliveries, &entities.WebhookDelivery{ID: int64(i + 1), SubscriptionID: 1,
+			EventID: "evt_" + strconv.Itoa(i), Payload: []byte(`{}`), Status: entities.DeliveryStatusPending})
+	}
+	webhookRepo.On("ClaimDueDeliveries", ctx, mock.AnythingOfType("time.Time"), 10*time.Second, 50).Return(deliveries, nil)
+	webhookRepo.On("GetSubscription", ctx, 1).
+		Return(&entities.WebhookSubscription{ID: 1, URL: server.URL, Secret: "whsec_test"}, nil).Once()
+	webhookRepo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil).Times(batch)
+
+	start := time.Now()
+	processed, err := webhookService.ProcessDueDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, batch, processed)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	for _, delivery := range deliveries {
+		assert.Equal(t, entities.DeliveryStatusSucceeded, delivery.Status)
+	}
+}
+
+func TestWebhookService_ReplayDelivery_KeepsEventID(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := mocks.NewWebhookRepository(t)
+	webhookService := services.NewWebhookService(webhookRepo, config.Webhooks{})
+
+	original := &entities.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "evt_1",
+		EventType: entities.EventReferralCreated, Payload: []byte(`{}`), Status: entities.DeliveryStatusFailed, Attempts: 8}
+	webhookRepo.On("GetDelivery", ctx, int64(7)).Return(original, nil)
+	webhookRepo.On("GetDelivery", ctx, int64(8)).Return(nil, repositories.ErrNotFound)
+	webhookRepo.On("CreateDelivery", ctx, mock.MatchedBy(func(d *entities.WebhookDelivery) bool {
+		return d.SubscriptionID == 1 && d.EventID == "evt_1" && d.Attempts == 0 && d.Status == entities.DeliveryStatusPending &&
+			d.ReplayOf != nil && *d.ReplayOf == 7
+	})).Return(nil)
+
+	_, err := webhookService.ReplayDelivery(ctx, 7)
+	require.NoError(t, err)
+
+	_, err = webhookService.ReplayDelivery(ctx, 8)
+	assert.ErrorIs(t, err, services.ErrWebhookDeliveryNotFound)
+}
+
+func TestReferralService_QualifyReferral_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	referralRepo := mocks.NewReferralRepository(t)
+	tierRepo := mocks.NewTierRepository(t)
+	publisher := &recordingPublisher{}
+	referralService := services.NewReferralService(mocks.NewReferralCodeRepository(t), mocks.NewUserRepository(t),
+		referralRepo, mocks.NewCampaignRepository(t), nil, services.NewTierService(testTiers, referralRepo, tierRepo, inlineTx{}), inlineTx{}, publisher)
+
+	referral := &entities.Referral{ID: 11, ReferrerID: 3, ReferrerReward: 100}
+	referralRepo.On("QualifyReferral", ctx, 11, mock.AnythingOfType("time.Time")).Return(referral, true, nil).Once()
+	referralRepo.On("QualifyReferral", ctx, 11, mock.AnythingOfType("time.Time")).Return(referral, false, nil).Once()
+	referralRepo.On("CountQualifiedReferrals", ctx, 3).Return(1, nil)
+	tierRepo.On("LockUserTier", ctx, 3).Return(nil, nil)
+
+	_, err := referralService.QualifyReferral(ctx, 11)
+	require.NoError(t, err)
+	assert.Equal(t, []string{entities.EventReferralQualified, entities.EventReferralRewarded}, publisher.types())
+
+	// Повторная квалификация не публикует события заново
+	_, err = referralService.QualifyReferral(ctx, 11)
+	require.NoError(t, err)
+	assert.Len(t, publisher.events, 2)
+
+	data, err := json.Marshal(publisher.events[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"referral.qualified"`)
+}
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"referral-system/internal/i18n"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Границы, проверяемые кастомными правилами
+const (
+	// PasswordMaxLength ограничен 72 байтами - больше bcrypt не учитывает.
+	// Остальные требования к паролю проверяет настраиваемая парольная политика
+	PasswordMaxLength = 72
+
+	NameMinLength = 2
+	NameMaxLength = 100
+
+	// ExpiresInMin и ExpiresInMax - допустимое время жизни реферального кода в секундах
+	ExpiresInMin = 60
+	ExpiresInMax = 365 * 24 * 60 * 60
+)
+
+// FieldError описывает ошибку валидации конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"` // код сообщения в каталоге, не зависит от языка
+	Message string `json:"message"`
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("validation: unexpected gin validator engine")
+	}
+
+	if err := Register(v); err != nil {
+		panic(fmt.Errorf("validation: %w", err))
+	}
+}
+
+// Register добавляет в валидатор кастомные правила и имена полей из json тегов
+func Register(v *validator.Validate) error {
+	v.RegisterTagNameFunc(jsonFieldName)
+
+	rules := map[string]validator.Func{
+		"password":   validatePassword,
+		"username":   validateName,
+		"expires_in": validateExpiresIn,
+		"locale":     validateLocale,
+	}
+
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Details превращает ошибку биндинга в список ошибок по полям с сообщениями на языке locale
+func Details(err error, locale string) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	details := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		msg := message(fe)
+		details = append(details, FieldError{Field: fe.Field(), Code: msg.Code, Message: msg.Translate(locale)})
+	}
+
+	return details
+}
+
+func message(fe validator.FieldError) i18n.Message {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return i18n.Message{Code: "validation_required"}
+	case "email":
+		return i18n.Message{Code: "validation_email"}
+	case "password":
+		return i18n.Message{Code: "validation_password", Args: i18n.Args{"max": PasswordMaxLength}}
+	case "username":
+		return i18n.Message{Code: "validation_username", Args: i18n.Args{"min": NameMinLength, "max": NameMaxLength}}
+	case "expires_in":
+		return i18n.Message{Code: "validation_expires_in", Args: i18n.Args{"min": ExpiresInMin, "max": ExpiresInMax}}
+	case "max":
+		return i18n.Message{Code: "validation_max", Args: i18n.Args{"max": fe.Param()}}
+	case "locale":
+		return i18n.Message{Code: "validation_locale", Args: i18n.Args{"locales": strings.Join(i18n.Supported(), ", ")}}
+	default:
+		return i18n.Message{Code: "validation_failed", Args: i18n.Args{"rule": fe.Tag()}}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validatePassword проверяет, что пароль не пустой и помещается в лимит bcrypt
+func validatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	return password != "" && len(password) <= PasswordMaxLength
+}
+
+// validateName проверяет длину имени и отсутствие управляющих символов
+func validateName(fl validator.FieldLevel) bool {
+	name := strings.TrimSpace(fl.Field().String())
+	length := utf8.RuneCountInString(name)
+	if length < NameMinLength || length > NameMaxLength {
+		return false
+	}
+
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateExpiresIn проверяет время жизни реферального кода в секундах
+func validateExpiresIn(fl validator.FieldLevel) bool {
+	seconds := fl.Field().Int()
+	return seconds >= ExpiresInMin && seconds <= ExpiresInMax
+}
+
+// validateLocale проверяет, что для языка есть каталог сообщений
+func validateLocale(fl validator.FieldLevel) bool {
+	return i18n.IsSupported(fl.Field().String())
+}
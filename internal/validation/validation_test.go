@@ -0,0 +1,74 @@
+package validation_test
+
+import (
+	"referral-system/internal/validation"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Name      string `json:"name" validate:"username"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"password"`
+	ExpiresIn int64  `json:"expires_in" validate:"expires_in"`
+	Locale    string `json:"locale" validate:"omitempty,locale"`
+}
+
+func newValidator(t *testing.T) *validator.Validate {
+	v := validator.New()
+	require.NoError(t, validation.Register(v))
+	return v
+}
+
+func TestRules(t *testing.T) {
+	v := newValidator(t)
+	valid := request{Name: "John", Email: "john@mail.com", Password: "secret123", ExpiresIn: 3600}
+
+	tests := []struct {
+		name   string
+		modify func(r *request)
+		field  string
+	}{
+		{name: "valid", modify: func(r *request) {}},
+		{name: "invalid email", modify: func(r *request) { r.Email = "john" }, field: "email"},
+		{name: "empty password", modify: func(r *request) { r.Password = "" }, field: "password"},
+		{name: "password longer than bcrypt limit", modify: func(r *request) { r.Password = strings.Repeat("a1", 37) }, field: "password"},
+		{name: "short name", modify: func(r *request) { r.Name = "J" }, field: "name"},
+		{name: "name with control characters", modify: func(r *request) { r.Name = "Jo\nhn" }, field: "name"},
+		{name: "negative expires_in", modify: func(r *request) { r.ExpiresIn = -1 }, field: "expires_in"},
+		{name: "too long expires_in", modify: func(r *request) { r.ExpiresIn = validation.ExpiresInMax + 1 }, field: "expires_in"},
+		{name: "supported locale", modify: func(r *request) { r.Locale = "ru" }},
+		{name: "unsupported locale", modify: func(r *request) { r.Locale = "de" }, field: "locale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			details := validation.Details(v.Struct(req), "en")
+			if tt.field == "" {
+				assert.Empty(t, details)
+				return
+			}
+
+			require.Len(t, details, 1)
+			assert.Equal(t, tt.field, details[0].Field)
+			assert.NotEmpty(t, details[0].Message)
+		})
+	}
+}
+
+func TestDetails_Localized(t *testing.T) {
+	v := newValidator(t)
+
+	details := validation.Details(v.Struct(request{Name: "John", Password: "secret123", ExpiresIn: 3600}), "ru")
+	require.Len(t, details, 1)
+	assert.Equal(t, "email", details[0].Field)
+	assert.Equal(t, "validation_required", details[0].Code)
+	assert.Equal(t, "обязательное поле", details[0].Message)
+}
@@ -0,0 +1 @@
+ALTER TABLE referrals DROP CONSTRAINT IF EXISTS referrals_referee_id_key;
\ No newline at end of file
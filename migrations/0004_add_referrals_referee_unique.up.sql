@@ -0,0 +1 @@
+ALTER TABLE referrals ADD CONSTRAINT referrals_referee_id_key UNIQUE (referee_id);
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE referrals
+    DROP CONSTRAINT IF EXISTS referrals_referrer_id_fkey,
+    DROP CONSTRAINT IF EXISTS referrals_referee_id_fkey,
+    ADD CONSTRAINT referrals_referrer_id_fkey FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE CASCADE,
+    ADD CONSTRAINT referrals_referee_id_fkey FOREIGN KEY (referee_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
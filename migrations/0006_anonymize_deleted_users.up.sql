@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+-- Удаленные аккаунты анонимизируются, а не удаляются, поэтому история рефералов
+-- не должна исчезать вместе со строкой пользователя
+ALTER TABLE referrals
+    DROP CONSTRAINT IF EXISTS referrals_referrer_id_fkey,
+    DROP CONSTRAINT IF EXISTS referrals_referee_id_fkey,
+    ADD CONSTRAINT referrals_referrer_id_fkey FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT referrals_referee_id_fkey FOREIGN KEY (referee_id) REFERENCES users(id) ON DELETE RESTRICT;
@@ -0,0 +1,12 @@
+ALTER TABLE referrals
+    DROP COLUMN IF EXISTS referral_code_id,
+    DROP COLUMN IF EXISTS campaign_id,
+    DROP COLUMN IF EXISTS referrer_reward,
+    DROP COLUMN IF EXISTS referee_reward,
+    DROP COLUMN IF EXISTS created_at;
+
+ALTER TABLE referral_codes
+    DROP COLUMN IF EXISTS campaign_id,
+    DROP COLUMN IF EXISTS max_uses;
+
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    code_ttl INT NOT NULL, -- время жизни кодов кампании в секундах
+    referrer_reward INT NOT NULL DEFAULT 0,
+    referee_reward INT NOT NULL DEFAULT 0,
+    max_uses_per_code INT,
+    max_referrals INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT campaigns_window_check CHECK (ends_at > starts_at)
+);
+
+ALTER TABLE referral_codes
+    ADD COLUMN campaign_id INT REFERENCES campaigns(id) ON DELETE RESTRICT,
+    ADD COLUMN max_uses INT;
+
+-- Награды фиксируются в момент регистрации, чтобы изменение кампании не меняло историю
+ALTER TABLE referrals
+    ADD COLUMN referral_code_id INT REFERENCES referral_codes(id) ON DELETE SET NULL,
+    ADD COLUMN campaign_id INT REFERENCES campaigns(id) ON DELETE RESTRICT,
+    ADD COLUMN referrer_reward INT NOT NULL DEFAULT 0,
+    ADD COLUMN referee_reward INT NOT NULL DEFAULT 0,
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS referrals_referral_code_id_idx ON referrals (referral_code_id);
+CREATE INDEX IF NOT EXISTS referrals_campaign_id_idx ON referrals (campaign_id);
+CREATE INDEX IF NOT EXISTS referral_codes_campaign_id_idx ON referral_codes (campaign_id);
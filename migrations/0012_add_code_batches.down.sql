@@ -0,0 +1,3 @@
+ALTER TABLE referral_codes DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS code_batches;
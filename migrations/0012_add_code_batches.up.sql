@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS code_batches (
+    id SERIAL PRIMARY KEY,
+    campaign_id INT NOT NULL REFERENCES campaigns(id) ON DELETE RESTRICT,
+    partner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- кому засчитываются регистрации по кодам
+    size INT NOT NULL CHECK (size > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS code_batches_campaign_id_idx ON code_batches (campaign_id);
+
+ALTER TABLE referral_codes ADD COLUMN batch_id INT REFERENCES code_batches(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS referral_codes_batch_id_idx ON referral_codes (batch_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS referral_clicks (
+    id BIGSERIAL PRIMARY KEY,
+    referral_code_id INT NOT NULL REFERENCES referral_codes(id) ON DELETE CASCADE,
+    referrer_url TEXT NOT NULL DEFAULT '',
+    utm_source VARCHAR(255) NOT NULL DEFAULT '',
+    utm_medium VARCHAR(255) NOT NULL DEFAULT '',
+    utm_campaign VARCHAR(255) NOT NULL DEFAULT '',
+    utm_term VARCHAR(255) NOT NULL DEFAULT '',
+    utm_content VARCHAR(255) NOT NULL DEFAULT '',
+    ip_hash VARCHAR(64) NOT NULL, -- HMAC-SHA256 от IP, сам адрес не хранится
+    clicked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS referral_clicks_referral_code_id_idx ON referral_clicks (referral_code_id, clicked_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS referral_clicks_clicked_at_idx;
+DROP INDEX IF EXISTS referrals_qualified_at_idx;
+DROP INDEX IF EXISTS referrals_referrer_id_qualified_at_idx;
+DROP INDEX IF EXISTS referrals_created_at_idx;
+DROP INDEX IF EXISTS referrals_referrer_id_created_at_idx;
+
+ALTER TABLE referrals DROP COLUMN IF EXISTS qualified_at;
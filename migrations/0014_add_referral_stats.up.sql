@@ -0,0 +1,10 @@
+-- Реферал квалифицирован, когда выполнил целевое действие, например первую оплату
+ALTER TABLE referrals ADD COLUMN qualified_at TIMESTAMP;
+
+-- Индексы под агрегаты статистики по реферерам и по всей системе
+CREATE INDEX IF NOT EXISTS referrals_referrer_id_created_at_idx ON referrals (referrer_id, created_at);
+CREATE INDEX IF NOT EXISTS referrals_created_at_idx ON referrals (created_at);
+CREATE INDEX IF NOT EXISTS referrals_referrer_id_qualified_at_idx ON referrals (referrer_id, qualified_at)
+    WHERE qualified_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS referrals_qualified_at_idx ON referrals (qualified_at) WHERE qualified_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS referral_clicks_clicked_at_idx ON referral_clicks (clicked_at);
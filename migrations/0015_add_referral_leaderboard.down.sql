@@ -0,0 +1,3 @@
+DROP MATERIALIZED VIEW IF EXISTS referral_leaderboard;
+
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_opt_out;
@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Снимок рейтинга по квалифицированным рефералам. Обновляется приложением по расписанию,
+-- поэтому запросы рейтинга не сканируют referrals
+CREATE MATERIALIZED VIEW IF NOT EXISTS referral_leaderboard AS
+WITH periods (period, since) AS (
+    VALUES ('week', date_trunc('week', LOCALTIMESTAMP)),
+           ('month', date_trunc('month', LOCALTIMESTAMP)),
+           ('all', '-infinity'::timestamp)
+)
+SELECT p.period,
+       r.referrer_id AS user_id,
+       COUNT(*) AS qualified,
+       RANK() OVER (PARTITION BY p.period ORDER BY COUNT(*) DESC) AS rank,
+       LOCALTIMESTAMP AS refreshed_at
+FROM periods p
+JOIN referrals r ON r.qualified_at >= p.since
+JOIN users u ON u.id = r.referrer_id AND u.deleted_at IS NULL AND NOT u.leaderboard_opt_out
+GROUP BY p.period, r.referrer_id;
+
+-- Уникальный индекс нужен для REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS referral_leaderboard_period_user_id_idx ON referral_leaderboard (period, user_id);
+CREATE INDEX IF NOT EXISTS referral_leaderboard_period_rank_idx ON referral_leaderboard (period, rank);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS tier_changes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS tier;
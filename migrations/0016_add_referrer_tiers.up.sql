@@ -0,0 +1,13 @@
+-- Текущий уровень реферера. NULL - уровень еще не получен
+ALTER TABLE users ADD COLUMN tier VARCHAR(32);
+
+CREATE TABLE IF NOT EXISTS tier_changes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_tier VARCHAR(32),
+    to_tier VARCHAR(32),
+    qualified INT NOT NULL, -- число квалифицированных рефералов в момент смены
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS tier_changes_user_id_idx ON tier_changes (user_id, changed_at);
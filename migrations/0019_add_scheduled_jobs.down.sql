@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS referral_codes_expires_at_idx;
+
+ALTER TABLE referral_codes DROP COLUMN IF EXISTS expiry_notified_at;
+
+DROP TABLE IF EXISTS referral_codes_archive;
+DROP TABLE IF EXISTS job_runs;
@@ -0,0 +1,25 @@
+-- Последний запуск каждой задачи планировщика. Запуск на одно и то же время выполняется один раз
+CREATE TABLE IF NOT EXISTS job_runs (
+    name VARCHAR(64) PRIMARY KEY,
+    scheduled_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+-- Истекшие коды без переходов и регистраций переносятся сюда, чтобы не копиться в referral_codes
+CREATE TABLE IF NOT EXISTS referral_codes_archive (
+    id INT PRIMARY KEY,
+    user_id INT,
+    code VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    campaign_id INT,
+    max_uses INT,
+    batch_id INT,
+    created_at TIMESTAMP NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE referral_codes ADD COLUMN expiry_notified_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS referral_codes_expires_at_idx ON referral_codes (expires_at);
@@ -0,0 +1,18 @@
+-- Отключенные пользователем уведомления. Без строки уведомление включено
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    email BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, type)
+);
+
+-- Отправленные уведомления. События публикуются хотя бы один раз, ключ не дает отправить письмо дважды
+CREATE TABLE IF NOT EXISTS sent_notifications (
+    key VARCHAR(128) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS sent_notifications_user_id_idx ON sent_notifications (user_id, sent_at);
@@ -0,0 +1,2 @@
+-- Язык писем и уведомлений пользователя. NULL - язык по умолчанию из конфига
+ALTER TABLE users ADD COLUMN locale VARCHAR(8);
@@ -0,0 +1,7 @@
+-- Корзины ограничения частоты запросов. tat - время, к которому корзина наполнится полностью
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(320) PRIMARY KEY,
+    tat TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limits_tat_idx ON rate_limits (tat);
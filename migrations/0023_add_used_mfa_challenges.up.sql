@@ -0,0 +1,7 @@
+-- Использованные токены второго шага входа. Токен принимается один раз, отметка хранится до его истечения
+CREATE TABLE IF NOT EXISTS used_mfa_challenges (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS used_mfa_challenges_expires_at_idx ON used_mfa_challenges (expires_at);
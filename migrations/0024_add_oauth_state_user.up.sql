@@ -0,0 +1,2 @@
+-- Пользователь, который привязывает аккаунт провайдера из своей сессии. Пусто для обычного входа
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE CASCADE;
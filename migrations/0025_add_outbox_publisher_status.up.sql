@@ -0,0 +1,8 @@
+-- Издатели, которые уже приняли событие: при повторе событие отправляется только остальным.
+-- failed_at отмечает событие, не опубликованное за максимальное число попыток
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS published_to TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL AND failed_at IS NULL;
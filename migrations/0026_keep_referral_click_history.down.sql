@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS referral_clicks_campaign_id_idx;
+DROP INDEX IF EXISTS referral_clicks_referrer_id_idx;
+
+DELETE FROM referral_clicks WHERE referral_code_id IS NULL;
+
+ALTER TABLE referral_clicks
+    DROP CONSTRAINT IF EXISTS referral_clicks_referral_code_id_fkey,
+    ADD CONSTRAINT referral_clicks_referral_code_id_fkey
+        FOREIGN KEY (referral_code_id) REFERENCES referral_codes(id) ON DELETE CASCADE,
+    ALTER COLUMN referral_code_id SET NOT NULL,
+    DROP COLUMN IF EXISTS campaign_id,
+    DROP COLUMN IF EXISTS referrer_id;
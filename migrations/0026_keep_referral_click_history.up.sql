@@ -0,0 +1,19 @@
+-- Переход хранит реферера и кампанию, чтобы статистика не зависела от того, существует ли еще код.
+-- Удаление или архивация кода больше не удаляет историю переходов
+ALTER TABLE referral_clicks
+    ADD COLUMN IF NOT EXISTS referrer_id INT REFERENCES users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS campaign_id INT REFERENCES campaigns(id) ON DELETE RESTRICT;
+
+UPDATE referral_clicks c
+SET referrer_id = rc.user_id, campaign_id = rc.campaign_id
+FROM referral_codes rc
+WHERE rc.id = c.referral_code_id;
+
+ALTER TABLE referral_clicks
+    ALTER COLUMN referral_code_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS referral_clicks_referral_code_id_fkey,
+    ADD CONSTRAINT referral_clicks_referral_code_id_fkey
+        FOREIGN KEY (referral_code_id) REFERENCES referral_codes(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS referral_clicks_referrer_id_idx ON referral_clicks (referrer_id, clicked_at);
+CREATE INDEX IF NOT EXISTS referral_clicks_campaign_id_idx ON referral_clicks (campaign_id, clicked_at);
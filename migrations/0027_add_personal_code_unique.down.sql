@@ -0,0 +1 @@
+DROP INDEX IF EXISTS referral_codes_personal_user_id_key;
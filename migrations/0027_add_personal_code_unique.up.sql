@@ -0,0 +1,11 @@
+-- У пользователя один личный код. Лишние личные коды переносятся в архив, остается самый новый
+WITH archived AS (
+    DELETE FROM referral_codes
+    WHERE batch_id IS NULL AND user_id IS NOT NULL
+      AND id NOT IN (SELECT MAX(id) FROM referral_codes WHERE batch_id IS NULL AND user_id IS NOT NULL GROUP BY user_id)
+    RETURNING id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at
+)
+INSERT INTO referral_codes_archive (id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, archived_at)
+SELECT id, user_id, code, expires_at, campaign_id, max_uses, batch_id, created_at, NOW() FROM archived;
+
+CREATE UNIQUE INDEX IF NOT EXISTS referral_codes_personal_user_id_key ON referral_codes (user_id) WHERE batch_id IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS webhook_deliveries_event_key;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS replay_of;
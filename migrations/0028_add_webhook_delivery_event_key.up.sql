@@ -0,0 +1,15 @@
+-- Повторная отправка из журнала создает новую доставку того же события и ссылается на исходную
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS replay_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE CASCADE;
+
+-- Уже сохраненные дубли остаются в журнале как повторы первой доставки события
+UPDATE webhook_deliveries d SET replay_of = f.first_id
+FROM (
+    SELECT subscription_id, event_id, MIN(id) AS first_id
+    FROM webhook_deliveries
+    GROUP BY subscription_id, event_id
+    HAVING COUNT(*) > 1
+) f
+WHERE d.subscription_id = f.subscription_id AND d.event_id = f.event_id AND d.id <> f.first_id;
+
+-- Событие публикуется подписке не больше одного раза, сколько бы раз его ни передал outbox
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_key ON webhook_deliveries (subscription_id, event_id) WHERE replay_of IS NULL;